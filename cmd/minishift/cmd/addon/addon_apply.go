@@ -25,6 +25,8 @@ import (
 	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/manager"
 	"github.com/minishift/minishift/pkg/minishift/clusterup"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/minishift/oc"
@@ -37,18 +39,26 @@ var (
 	addonsApplyCmd = &cobra.Command{
 		Use:   "apply ADDON_NAME ...",
 		Short: "Applies the specified add-ons.",
-		Long:  "Applies the specified add-ons. You can specify one or more add-ons, regardless of whether the add-on is enabled or disabled.",
-		Run:   runApplyAddon,
+		Long: `Applies the specified add-ons. You can specify one or more add-ons, regardless of whether the add-on is enabled or disabled.
+Use --dry-run to print the interpolated commands without executing them. Without any add-on names, --dry-run prints the plan for
+all enabled add-ons in the order in which they are applied during start-up.
+Use --rollback to revert the already executed commands of an add-on if one of its commands fails.`,
+		Run: runApplyAddon,
 	}
+
+	applyDryRun   bool
+	applyRollback bool
 )
 
 func init() {
 	addonsApplyCmd.Flags().AddFlag(util.AddOnEnvFlag)
+	addonsApplyCmd.Flags().BoolVar(&applyDryRun, dryRunFlag, false, "Prints the commands of the add-ons and verifies their requirements without executing them. Does not require a running VM.")
+	addonsApplyCmd.Flags().BoolVar(&applyRollback, rollbackFlag, false, "Rolls back a failing add-on using its undo commands or, if it has none, its remove commands.")
 	AddonsCmd.AddCommand(addonsApplyCmd)
 }
 
 func runApplyAddon(cmd *cobra.Command, args []string) {
-	if len(args) == 0 {
+	if len(args) == 0 && !applyDryRun {
 		atexit.ExitWithMessage(1, emptyAddOnError)
 	}
 
@@ -60,6 +70,11 @@ func runApplyAddon(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if applyDryRun {
+		planApplyAddons(addOnManager, args)
+		return
+	}
+
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
 
@@ -85,16 +100,73 @@ func runApplyAddon(cmd *cobra.Command, args []string) {
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error applying the add-on: %s", err.Error()))
 	}
 
-	for i := range args {
-		addonName := args[i]
-		addon := addOnManager.Get(addonName)
+	addOns, err := resolveAddOnsToApply(addOnManager, args)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
+	}
+
+	for _, addon := range addOns {
 		addonContext, err := clusterup.GetExecutionContext(ip, routingSuffix, sshUser, viper.GetStringSlice(configCmd.AddonEnv.Name), ocRunner, sshCommander)
 		if err != nil {
 			atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
 		}
-		err = addOnManager.ApplyAddOn(addon, addonContext)
+		if !applyRollback {
+			err = addOnManager.ApplyAddOn(addon, addonContext)
+			if err != nil {
+				atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
+			}
+			continue
+		}
+
+		report, err := addOnManager.ApplyAddOnWithRollback(addon, addonContext)
 		if err != nil {
+			if report != nil {
+				fmt.Print(report.String())
+			}
 			atexit.ExitWithMessage(1, fmt.Sprint("Error applying the add-on: ", err))
 		}
 	}
 }
+
+func planApplyAddons(addOnManager *manager.AddOnManager, addonNames []string) {
+	ip, routingSuffix, sshUser := getDryRunContextVariables()
+	if len(addonNames) == 0 {
+		printPlans(addOnManager.PlanEnabled(getDryRunExecutionContext(ip, routingSuffix, sshUser)))
+		return
+	}
+
+	var plans []*manager.Plan
+	for _, addonName := range addonNames {
+		plans = append(plans, addOnManager.PlanApply(addOnManager.Get(addonName), getDryRunExecutionContext(ip, routingSuffix, sshUser)))
+	}
+	printPlans(plans)
+}
+
+// resolveAddOnsToApply returns the specified add-ons together with the add-ons they depend on in the order in which they
+// need to be applied. Dependencies which are already applied are omitted.
+func resolveAddOnsToApply(addOnManager *manager.AddOnManager, addonNames []string) ([]addon.AddOn, error) {
+	requested := make(map[string]bool)
+	var addOns []addon.AddOn
+	for _, addonName := range addonNames {
+		requested[addonName] = true
+		addOns = append(addOns, addOnManager.Get(addonName))
+	}
+
+	resolved, err := addOnManager.ResolveDependencies(addOns)
+	if err != nil {
+		return nil, err
+	}
+
+	var toApply []addon.AddOn
+	for _, addOn := range resolved {
+		name := addOn.MetaData().Name()
+		if !requested[name] && addOnManager.IsApplied(name) {
+			continue
+		}
+		if !requested[name] {
+			fmt.Println(fmt.Sprintf("-- Add-on '%s' is applied as dependency", name))
+		}
+		toApply = append(toApply, addOn)
+	}
+	return toApply, nil
+}
@@ -18,7 +18,9 @@ package addon
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/minishift/minishift/pkg/minishift/addon"
 	"github.com/minishift/minishift/pkg/minishift/addon/manager"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -64,6 +66,7 @@ func enableAddon(addOnManager *manager.AddOnManager, addOnName string, priority
 		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot enable the add-on '%s': %s", addOnName, err.Error()))
 	} else {
 		fmt.Println(fmt.Sprintf("Add-on '%s' enabled", addOnName))
+		printDisabledDependencies(addOnManager, addOnName)
 	}
 
 	minishiftConfig.InstanceConfig.AddonConfig[addOnConfig.Name] = addOnConfig
@@ -71,3 +74,22 @@ func enableAddon(addOnManager *manager.AddOnManager, addOnName string, priority
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error writing addon config data: %v", err))
 	}
 }
+
+// printDisabledDependencies lists the add-ons the specified add-on depends on which are not enabled themselves,
+// since they get applied together with the add-on.
+func printDisabledDependencies(addOnManager *manager.AddOnManager, addOnName string) {
+	addOns, err := addOnManager.ResolveDependencies([]addon.AddOn{addOnManager.Get(addOnName)})
+	if err != nil {
+		return
+	}
+
+	var disabled []string
+	for _, addOn := range addOns {
+		if addOn.MetaData().Name() != addOnName && !addOn.IsEnabled() {
+			disabled = append(disabled, addOn.MetaData().Name())
+		}
+	}
+	if len(disabled) > 0 {
+		fmt.Println(fmt.Sprintf("Add-on '%s' depends on the disabled add-ons [%s], which are applied together with it", addOnName, strings.Join(disabled, ", ")))
+	}
+}
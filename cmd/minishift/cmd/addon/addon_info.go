@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const maskedDefault = "********"
+
+var addonsInfoCmd = &cobra.Command{
+	Use:   "info ADDON_NAME",
+	Short: "Displays the metadata and variables of the specified add-on.",
+	Long:  "Displays the metadata of the specified add-on together with a table of the variables it accepts, including their type, default value and allowed values.",
+	Run:   runInfoAddon,
+}
+
+func init() {
+	AddonsCmd.AddCommand(addonsInfoCmd)
+}
+
+func runInfoAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnError)
+	}
+
+	addonName := args[0]
+	addOnManager := GetAddOnManager()
+
+	if !addOnManager.IsInstalled(addonName) {
+		atexit.ExitWithMessage(0, fmt.Sprintf(noAddOnMessage, addonName))
+	}
+
+	if err := printAddOnInfo(addOnManager.Get(addonName).MetaData(), os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+}
+
+func printAddOnInfo(meta addon.AddOnMeta, writer io.Writer) error {
+	fmt.Fprintln(writer, fmt.Sprintf("Name              : %s", meta.Name()))
+	if meta.Version() != "" {
+		fmt.Fprintln(writer, fmt.Sprintf("Version           : %s", meta.Version()))
+	}
+	fmt.Fprintln(writer, fmt.Sprintf("Description       : %s", strings.Join(meta.Description(), fmt.Sprintf("\n%20s", " "))))
+	if meta.Url() != "" {
+		fmt.Fprintln(writer, fmt.Sprintf("Url               : %s", meta.Url()))
+	}
+	if meta.OpenShiftVersion() != "" {
+		fmt.Fprintln(writer, fmt.Sprintf("Openshift Version : %s", meta.OpenShiftVersion()))
+	}
+	if meta.MinishiftVersion() != "" {
+		fmt.Fprintln(writer, fmt.Sprintf("Minishift Version : %s", meta.MinishiftVersion()))
+	}
+
+	dependencies, err := meta.Dependencies()
+	if err != nil {
+		return err
+	}
+	if len(dependencies) > 0 {
+		var required []string
+		for _, dependency := range dependencies {
+			required = append(required, dependency.String())
+		}
+		fmt.Fprintln(writer, fmt.Sprintf("Depends On        : %s", strings.Join(required, ", ")))
+	}
+
+	variables, err := infoVariables(meta)
+	if err != nil {
+		return err
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(writer)
+	display := new(tabwriter.Writer)
+	display.Init(writer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(display, "VARIABLE\tTYPE\tREQUIRED\tDEFAULT\tALLOWED VALUES\tDESCRIPTION")
+	for _, variable := range variables {
+		defaultValue := variable.Default
+		if variable.Secret && defaultValue != "" {
+			defaultValue = maskedDefault
+		}
+		fmt.Fprintln(display, fmt.Sprintf("%s\t%s\t%t\t%s\t%s\t%s", variable.Name, variableType(variable),
+			variable.IsRequired(), defaultValue, allowedValues(variable), variable.Description))
+	}
+	return display.Flush()
+}
+
+// infoVariables returns the declared variables of the add-on, followed by the variables which are only listed in the
+// Var-Defaults or Required-Vars headers.
+func infoVariables(meta addon.AddOnMeta) ([]*addon.Variable, error) {
+	variables, err := meta.Variables()
+	if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool)
+	for _, variable := range variables {
+		declared[variable.Name] = true
+	}
+
+	varDefaults, err := meta.VarDefaults()
+	if err != nil {
+		return nil, err
+	}
+	for _, varDefault := range varDefaults {
+		if !declared[varDefault.Key] {
+			variables = append(variables, &addon.Variable{Name: varDefault.Key, Type: addon.StringVariable, Default: varDefault.Value, HasDefault: true})
+			declared[varDefault.Key] = true
+		}
+	}
+
+	requiredVars, err := meta.RequiredVars()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range requiredVars {
+		if !declared[name] {
+			variables = append(variables, &addon.Variable{Name: name, Type: addon.StringVariable})
+			declared[name] = true
+		}
+	}
+	return variables, nil
+}
+
+func variableType(variable *addon.Variable) string {
+	if variable.Secret {
+		return fmt.Sprintf("%s (secret)", variable.Type)
+	}
+	return variable.Type
+}
+
+func allowedValues(variable *addon.Variable) string {
+	switch {
+	case len(variable.Values) > 0:
+		return strings.Join(variable.Values, ", ")
+	case variable.Pattern != nil:
+		return fmt.Sprintf("matching %s", variable.Pattern)
+	case variable.Type == addon.PortVariable:
+		return "1-65535"
+	case variable.Type == addon.BoolVariable:
+		return "true, false"
+	}
+	return ""
+}
@@ -44,8 +44,12 @@ var (
 var addonsInstallCmd = &cobra.Command{
 	Use:   "install [SOURCE]",
 	Short: "Installs the specified add-on.",
-	Long:  "Installs the add-on from the specified file path and verifies the installation.",
-	Run:   runInstallAddon,
+	Long: `Installs the add-on from the specified source and verifies the installation.
+The source can be a local directory or archive, a file:// or http(s):// URL pointing to a tar, tar.gz or zip archive,
+or a git repository in the form git+<repository-url>[#<ref>[:<subdir>]].
+The digests of add-on bundles created by 'minishift addons package' are verified. If the 'addon-trusted-key' setting
+is configured, only bundles signed with the matching private key can be installed.`,
+	Run: runInstallAddon,
 }
 
 func init() {
@@ -71,6 +75,7 @@ func runInstallAddon(cmd *cobra.Command, args []string) {
 
 	source := args[0]
 
+	configureTrustedKey(addOnManager)
 	addOnName, err := addOnManager.Install(source, force)
 	if err != nil {
 		atexit.ExitWithMessage(1, fmt.Sprintf(failedPluginInstallation, err.Error()))
@@ -0,0 +1,100 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/lint"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	lintFormatFlag = "format"
+	lintFormatText = "text"
+	lintFormatJson = "json"
+
+	emptyAddOnDirError = "You must specify the directory of the add-on to lint."
+)
+
+var lintFormat string
+
+var addonsLintCmd = &cobra.Command{
+	Use:   "lint ADDON_DIR [ADDON_DIR...]",
+	Short: "Checks the specified add-on directories for problems.",
+	Long: `Checks the add-on and add-on remove files of the specified add-on directories for problems without applying them.
+Reported are parse errors, unknown headers, undeclared or unused variables, missing referenced files and add-ons which change the cluster without providing a remove file.
+Use '--format json' to get machine-readable diagnostics, eg for CI. The command exits with status 1 if any error has been found.`,
+	Run: runLintAddon,
+}
+
+func init() {
+	addonsLintCmd.Flags().StringVar(&lintFormat, lintFormatFlag, lintFormatText, "The output format of the diagnostics. One of text or json.")
+	AddonsCmd.AddCommand(addonsLintCmd)
+}
+
+func runLintAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnDirError)
+	}
+	if lintFormat != lintFormatText && lintFormat != lintFormatJson {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Unsupported format '%s'. Use one of %s or %s.", lintFormat, lintFormatText, lintFormatJson))
+	}
+
+	reports := []*lint.Report{}
+	hasErrors := false
+	for _, addOnDir := range args {
+		report, err := lint.Lint(addOnDir)
+		if err != nil {
+			atexit.ExitWithMessage(1, err.Error())
+		}
+		reports = append(reports, report)
+		hasErrors = hasErrors || report.HasErrors()
+	}
+
+	if err := printLintReports(reports, lintFormat, os.Stdout); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	if hasErrors {
+		atexit.Exit(1)
+	}
+}
+
+func printLintReports(reports []*lint.Report, format string, writer io.Writer) error {
+	if format == lintFormatJson {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(reports)
+	}
+
+	for _, report := range reports {
+		if len(report.Diagnostics) == 0 {
+			fmt.Fprintln(writer, fmt.Sprintf("Add-on '%s': no problems found", report.AddOn))
+			continue
+		}
+		fmt.Fprintln(writer, fmt.Sprintf("Add-on '%s':", report.AddOn))
+		for _, diagnostic := range report.Diagnostics {
+			fmt.Fprintln(writer, fmt.Sprintf("  %s", diagnostic))
+		}
+	}
+	return nil
+}
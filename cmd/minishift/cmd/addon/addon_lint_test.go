@@ -0,0 +1,81 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/testing/cli"
+	"github.com/minishift/minishift/pkg/minishift/addon/lint"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_addon_dir_must_be_specified_for_lint_command(t *testing.T) {
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, emptyAddOnDirError))
+
+	runLintAddon(nil, nil)
+}
+
+func Test_lint_reports_can_be_printed_as_json(t *testing.T) {
+	reports := []*lint.Report{
+		{
+			AddOn: "acme",
+			Diagnostics: []*lint.Diagnostic{
+				{Severity: lint.Error, File: "acme.addon", Line: 5, Rule: lint.RuleUndeclaredVariable, Message: "Variable 'FOO' is unknown"},
+			},
+		},
+	}
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, printLintReports(reports, lintFormatJson, out))
+
+	var decoded []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Len(t, decoded, 1)
+	assert.Equal(t, "acme", decoded[0]["addon"])
+	diagnostic := decoded[0]["diagnostics"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "error", diagnostic["severity"])
+	assert.Equal(t, "acme.addon", diagnostic["file"])
+	assert.Equal(t, float64(5), diagnostic["line"])
+	assert.Equal(t, "undeclared-variable", diagnostic["rule"])
+}
+
+func Test_lint_reports_can_be_printed_as_text(t *testing.T) {
+	reports := []*lint.Report{
+		{AddOn: "foo", Diagnostics: []*lint.Diagnostic{}},
+		{
+			AddOn: "acme",
+			Diagnostics: []*lint.Diagnostic{
+				{Severity: lint.Warning, File: "acme.addon", Rule: lint.RuleUnusedVariable, Message: "Variable 'FOO' is declared but never used"},
+			},
+		},
+	}
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, printLintReports(reports, lintFormatText, out))
+	assert.Equal(t, `Add-on 'foo': no problems found
+Add-on 'acme':
+  acme.addon: warning: Variable 'FOO' is declared but never used [unused-variable]
+`, out.String())
+}
@@ -0,0 +1,75 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/bundle"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	outputDirFlag = "output-dir"
+	signKeyFlag   = "sign-key"
+)
+
+var (
+	outputDir string
+	signKey   string
+)
+
+var addonsPackageCmd = &cobra.Command{
+	Use:   "package ADDON_DIR",
+	Short: "Packages the specified add-on directory into a bundle.",
+	Long: `Packages the add-on in the specified directory into a bundle named <name>-<version>.tar.gz.
+The bundle contains a manifest with the SHA-256 digest of each file, which is verified when the bundle gets installed.
+If a private key is specified, a detached signature of the bundle is created with the suffix .sig.`,
+	Run: runPackageAddon,
+}
+
+func init() {
+	addonsPackageCmd.Flags().StringVar(&outputDir, outputDirFlag, ".", "The directory to write the bundle to.")
+	addonsPackageCmd.Flags().StringVar(&signKey, signKeyFlag, "", "The PEM encoded RSA or ECDSA private key to sign the bundle with.")
+	AddonsCmd.AddCommand(addonsPackageCmd)
+}
+
+func runPackageAddon(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		atexit.ExitWithMessage(1, "You must specify the directory of the add-on to package.")
+	}
+
+	var signingKey crypto.Signer
+	if signKey != "" {
+		var err error
+		if signingKey, err = bundle.ReadPrivateKey(signKey); err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Cannot read the signing key: %s", err.Error()))
+		}
+	}
+
+	bundlePath, err := bundle.Create(args[0], outputDir, signingKey)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot package the add-on: %s", err.Error()))
+	}
+
+	fmt.Println(fmt.Sprintf("Add-on bundle '%s' created", bundlePath))
+	if signingKey != nil {
+		fmt.Println(fmt.Sprintf("Signature '%s%s' created", bundlePath, bundle.SignatureSuffix))
+	}
+}
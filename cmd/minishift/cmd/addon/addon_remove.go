@@ -25,6 +25,7 @@ import (
 	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/addon/manager"
 	"github.com/minishift/minishift/pkg/minishift/clusterup"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/minishift/oc"
@@ -40,10 +41,13 @@ var (
 		Long:  "Removes the specified add-ons. You can specify one or more add-ons, regardless of whether the add-on is enabled or disabled.",
 		Run:   runRemoveAddon,
 	}
+
+	removeDryRun bool
 )
 
 func init() {
 	addonsRemoveCmd.Flags().AddFlag(util.AddOnEnvFlag)
+	addonsRemoveCmd.Flags().BoolVar(&removeDryRun, dryRunFlag, false, "Prints the remove commands of the add-ons and verifies their requirements without executing them. Does not require a running VM.")
 	AddonsCmd.AddCommand(addonsRemoveCmd)
 }
 
@@ -63,6 +67,16 @@ func runRemoveAddon(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if removeDryRun {
+		ip, routingSuffix, sshUser := getDryRunContextVariables()
+		var plans []*manager.Plan
+		for _, addonName := range args {
+			plans = append(plans, addOnManager.PlanRemove(addOnManager.Get(addonName), getDryRunExecutionContext(ip, routingSuffix, sshUser)))
+		}
+		printPlans(plans)
+		return
+	}
+
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
 
@@ -0,0 +1,128 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	addOnConfig "github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var addonStatusFormat = `Time              : {{.Time}}
+Action            : {{.Action}}
+Outcome           : {{.Outcome}}
+{{- if .FailedCommand}}
+Failed Command    : {{.FailedCommand}}
+{{- end}}
+{{- if .Error}}
+Error             : {{.Error}}
+{{- end}}
+Minishift Version : {{.MinishiftVersion}}
+Openshift Version : {{.OpenShiftVersion}}
+Variables         : {{.Variables}}
+
+`
+
+type DisplayApplyRecord struct {
+	Time             string
+	Action           string
+	Outcome          string
+	FailedCommand    string
+	Error            string
+	MinishiftVersion string
+	OpenShiftVersion string
+	Variables        string
+}
+
+var addonsStatusCmd = &cobra.Command{
+	Use:   "status ADDON_NAME",
+	Short: "Displays the apply history of the specified add-on.",
+	Long:  "Displays when and with which outcome the specified add-on was applied or removed against the current OpenShift cluster, starting with the most recent action.",
+	Run:   runStatusAddon,
+}
+
+func init() {
+	AddonsCmd.AddCommand(addonsStatusCmd)
+}
+
+func runStatusAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnError)
+	}
+
+	addonName := args[0]
+	addOnManager := GetAddOnManager()
+
+	if !addOnManager.IsInstalled(addonName) {
+		atexit.ExitWithMessage(0, fmt.Sprintf(noAddOnMessage, addonName))
+	}
+
+	statusTemplate, err := template.New("status").Parse(addonStatusFormat)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error creating the status template: %s", err.Error()))
+	}
+
+	addOn := addOnManager.Get(addonName)
+	fmt.Println(fmt.Sprintf("Add-on '%s' is %s and %s", addonName, stringFromStatus(addOn.IsEnabled()),
+		stringFromApplyRecord(addOnManager.LastRecord(addonName))))
+	fmt.Println()
+
+	if err := printApplyHistory(addOnManager.History(addonName), os.Stdout, statusTemplate); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error executing the template: %s", err.Error()))
+	}
+}
+
+// printApplyHistory prints the specified apply records, most recent first.
+func printApplyHistory(history []*addOnConfig.AddOnApplyRecord, writer io.Writer, template *template.Template) error {
+	for i := len(history) - 1; i >= 0; i-- {
+		if err := template.Execute(writer, displayApplyRecord(history[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func displayApplyRecord(record *addOnConfig.AddOnApplyRecord) DisplayApplyRecord {
+	display := DisplayApplyRecord{
+		Time:             record.Timestamp.Format(time.RFC1123),
+		Action:           record.Action,
+		Outcome:          record.Outcome,
+		Error:            record.Error,
+		MinishiftVersion: record.MinishiftVersion,
+		OpenShiftVersion: record.OpenShiftVersion,
+	}
+	if record.FailedCommand >= 0 && !record.IsSuccess() {
+		display.FailedCommand = fmt.Sprintf("#%d %s", record.FailedCommand+1, record.Command)
+	}
+
+	var variables []string
+	for name, value := range record.Variables {
+		variables = append(variables, fmt.Sprintf("%s=%s", name, value))
+	}
+	sort.Strings(variables)
+	display.Variables = strings.Join(variables, ", ")
+
+	return display
+}
@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"fmt"
+
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var addonsUpdateCmd = &cobra.Command{
+	Use:   "update ADDON_NAME",
+	Short: "Updates the specified add-on.",
+	Long:  "Updates the specified add-on by re-installing it from the source it was originally installed from.",
+	Run:   runUpdateAddon,
+}
+
+func init() {
+	AddonsCmd.AddCommand(addonsUpdateCmd)
+}
+
+func runUpdateAddon(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		atexit.ExitWithMessage(1, emptyAddOnError)
+	}
+
+	addonName := args[0]
+	addOnManager := GetAddOnManager()
+
+	if !addOnManager.IsInstalled(addonName) {
+		atexit.ExitWithMessage(0, fmt.Sprintf(noAddOnMessage, addonName))
+	}
+
+	configureTrustedKey(addOnManager)
+	origin, err := addOnManager.Update(addonName)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot update the add-on '%s': %s", addonName, err.Error()))
+	}
+
+	if origin.Revision != "" {
+		fmt.Println(fmt.Sprintf("Add-on '%s' updated from '%s' (revision %s)", addonName, origin.Source, origin.Revision))
+	} else {
+		fmt.Println(fmt.Sprintf("Add-on '%s' updated from '%s'", addonName, origin.Source))
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"strings"
 
 	"github.com/minishift/minishift/pkg/minishift/addon"
+	addOnConfig "github.com/minishift/minishift/pkg/minishift/addon/config"
 	"github.com/minishift/minishift/pkg/minishift/addon/manager"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
@@ -40,6 +41,7 @@ Url               : {{.Url}}
 Openshift Version : {{.RequiredOpenshiftVerison}}
 Enabled           : {{.Status}}
 Priority          : {{.Priority}}
+Apply Status      : {{.ApplyStatus}}
 
 `
 var verboseListTemplate *template.Template
@@ -51,12 +53,13 @@ type DisplayAddOn struct {
 	Priority                 int
 	Url                      string
 	RequiredOpenshiftVerison string
+	ApplyStatus              string
 }
 
 var addonsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Lists all installed Minishift add-ons.",
-	Long:  "Lists all installed Minishift add-ons and their current status, such as enabled/disabled.",
+	Long:  "Lists all installed Minishift add-ons and their current status, such as enabled/disabled and the outcome of their last application.",
 	Run:   runListCommand,
 }
 
@@ -84,14 +87,15 @@ func printAddOnList(manager *manager.AddOnManager, writer io.Writer, template *t
 		description := strings.Join(addon.MetaData().Description(), fmt.Sprintf("\n%13s", " "))
 		addonInfo := DisplayAddOn{addon.MetaData().Name(), description,
 			stringFromStatus(addon.IsEnabled()), addon.GetPriority(),
-			addon.MetaData().Url(), addon.MetaData().OpenShiftVersion()}
+			addon.MetaData().Url(), addon.MetaData().OpenShiftVersion(),
+			stringFromApplyRecord(manager.LastRecord(addon.MetaData().Name()))}
 		if verbose {
 			err := template.Execute(writer, addonInfo)
 			if err != nil {
 				atexit.ExitWithMessage(1, fmt.Sprintf("Error executing the template: %s", err.Error()))
 			}
 		} else {
-			fmt.Fprintln(display, fmt.Sprintf("- %s\t : %s\tP(%v)", addonInfo.Name, addonInfo.Status, addonInfo.Priority))
+			fmt.Fprintln(display, fmt.Sprintf("- %s\t : %s\tP(%v)\t%s", addonInfo.Name, addonInfo.Status, addonInfo.Priority, addonInfo.ApplyStatus))
 		}
 	}
 	display.Flush()
@@ -109,3 +113,25 @@ func stringFromStatus(addonStatus bool) string {
 	}
 	return "disabled"
 }
+
+// stringFromApplyRecord returns a short description of the outcome of the specified apply record.
+func stringFromApplyRecord(record *addOnConfig.AddOnApplyRecord) string {
+	if record == nil {
+		return "not applied"
+	}
+
+	switch {
+	case record.Action == addOnConfig.ApplyAction && record.IsSuccess():
+		return "applied"
+	case record.Action == addOnConfig.ApplyAction:
+		return "apply failed"
+	case record.Action == addOnConfig.RollbackAction && record.IsSuccess():
+		return "rolled back"
+	case record.Action == addOnConfig.RollbackAction:
+		return "rollback failed"
+	case record.IsSuccess():
+		return "removed"
+	default:
+		return "remove failed"
+	}
+}
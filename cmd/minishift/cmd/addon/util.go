@@ -20,19 +20,50 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/docker/machine/libmachine"
 	"github.com/docker/machine/libmachine/drivers"
 	"github.com/docker/machine/libmachine/provision"
+	configCmd "github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/addon/bundle"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
 	addOnConfig "github.com/minishift/minishift/pkg/minishift/addon/config"
 	"github.com/minishift/minishift/pkg/minishift/addon/manager"
+	"github.com/minishift/minishift/pkg/minishift/clusterup"
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/minishift/docker"
 	"github.com/minishift/minishift/pkg/minishift/openshift"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	dryRunFlag   = "dry-run"
+	rollbackFlag = "rollback"
+
+	ipPlaceholder            = "<ip>"
+	routingSuffixPlaceholder = "<routing-suffix>"
+	userPlaceholder          = "<user>"
+)
+
+// configureTrustedKey makes the add-on manager verify the signatures of add-on bundles against the public key
+// configured via the 'addon-trusted-key' setting.
+func configureTrustedKey(addOnManager *manager.AddOnManager) {
+	keyPath := viper.GetString(configCmd.AddonTrustedKey.Name)
+	if keyPath == "" {
+		return
+	}
+
+	key, err := bundle.ReadPublicKey(keyPath)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot read the trusted add-on key: %s", err.Error()))
+	}
+	addOnManager.SetTrustedKey(key)
+}
+
 // GetAddOnManager returns the addon manager
 func GetAddOnManager() *manager.AddOnManager {
 	addOnConfigs := minishiftConfig.InstanceConfig.AddonConfig
@@ -103,3 +134,49 @@ func determineRoutingSuffix(driver drivers.Driver) string {
 	// making assumptions about the master config here. In case the config structure changes, the code might panic here
 	return config["routingConfig"].(map[interface{}]interface{})["subdomain"].(string)
 }
+
+// getDryRunContextVariables returns the VM specific variables used to plan add-on commands. The variables are only
+// resolved if the VM is running, otherwise placeholders are returned in their place.
+func getDryRunContextVariables() (string, string, string) {
+	ip, routingSuffix, sshUser := ipPlaceholder, routingSuffixPlaceholder, userPlaceholder
+
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+
+	host, err := api.Load(constants.MachineName)
+	if err == nil && util.IsHostRunning(host.Driver) {
+		if hostIp, err := host.Driver.GetIP(); err == nil {
+			ip = hostIp
+		}
+		routingSuffix = determineRoutingSuffix(host.Driver)
+		sshUser = host.Driver.GetSSHUsername()
+	}
+
+	return ip, routingSuffix, sshUser
+}
+
+// getDryRunExecutionContext creates an execution context used to plan add-on commands, which has no means to
+// communicate with the VM.
+func getDryRunExecutionContext(ip string, routingSuffix string, sshUser string) *command.ExecutionContext {
+	context, err := clusterup.GetExecutionContext(ip, routingSuffix, sshUser, viper.GetStringSlice(configCmd.AddonEnv.Name), nil, nil)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error creating the add-on execution context: %s", err.Error()))
+	}
+
+	return context
+}
+
+// printPlans prints the specified plans and exits with a non zero exit code in case any of the plans has problems
+func printPlans(plans []*manager.Plan) {
+	failed := false
+	for _, plan := range plans {
+		fmt.Print(plan.String())
+		if plan.HasProblems() {
+			failed = true
+		}
+	}
+
+	if failed {
+		atexit.ExitWithMessage(1, "The add-on plan contains errors.")
+	}
+}
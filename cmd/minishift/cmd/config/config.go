@@ -55,6 +55,7 @@ var (
 	InsecureRegistry      = createConfigSetting("insecure-registry", SetSlice, nil, nil, true, nil)
 	RegistryMirror        = createConfigSetting("registry-mirror", SetSlice, nil, nil, true, nil)
 	AddonEnv              = createConfigSetting("addon-env", SetSlice, nil, nil, true, nil)
+	AddonTrustedKey       = createConfigSetting("addon-trusted-key", SetString, []setFn{validations.IsValidPath}, nil, true, nil)
 	RemoteIPAddress       = createConfigSetting("remote-ipaddress", SetString, nil, nil, true, nil)
 	RemoteSSHUser         = createConfigSetting("remote-ssh-user", SetString, nil, nil, true, nil)
 	SSHKeyToConnectRemote = createConfigSetting("remote-ssh-key", SetString, nil, nil, true, nil)
@@ -79,6 +80,10 @@ var (
 	LocalProxy          = createConfigSetting("local-proxy", SetBool, nil, nil, true, nil)
 	LocalProxyReencrypt = createConfigSetting("local-proxy-reencrypt", SetBool, nil, nil, true, nil)
 	LocalProxyUpstream  = createConfigSetting("local-proxy-upstream", SetString, []setFn{validations.IsValidProxy}, nil, true, nil)
+	LocalProxyAllow     = createConfigSetting("local-proxy-allow", SetSlice, nil, nil, true, nil)
+	LocalProxyDeny      = createConfigSetting("local-proxy-deny", SetSlice, nil, nil, true, nil)
+	LocalProxyLegacyCA  = createConfigSetting("local-proxy-legacy-ca", SetBool, nil, nil, true, nil)
+	LocalProxyRoutes    = createConfigSetting("local-proxy-routes", SetSlice, []setFn{validations.IsValidProxyRoutes}, nil, true, nil)
 
 	// Subscription Manager
 	Username         = createConfigSetting("username", SetString, nil, nil, true, nil)
@@ -96,13 +101,16 @@ var (
 	// Services
 	ServicesSftpPort       = createConfigSetting("hostfolders-sftp-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
 	ServicesLocalProxyPort = createConfigSetting("services-proxy-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
+	ServicesDnsPort        = createConfigSetting("services-dns-port", SetInt, []setFn{validations.IsValidPort}, nil, true, nil)
+	ServicesDnsUpstream    = createConfigSetting("services-dns-upstream", SetString, []setFn{validations.IsValidIPv4Address}, nil, true, nil)
 
 	// No Provision
 	NoProvision = createConfigSetting("no-provision", SetBool, nil, nil, true, nil)
 
 	// Image caching
-	ImageCaching = createConfigSetting("image-caching", SetBool, nil, nil, true, true)
-	CacheImages  = createConfigSetting("cache-images", SetSlice, nil, nil, false, nil)
+	ImageCaching          = createConfigSetting("image-caching", SetBool, nil, nil, true, true)
+	CacheImages           = createConfigSetting("cache-images", SetSlice, nil, nil, false, nil)
+	ImageCacheConcurrency = createConfigSetting("image-cache-concurrency", SetInt, []setFn{validations.IsPositive}, nil, true, nil)
 
 	// Pre-flight checks (before start)
 	SkipDeprecationCheck      = createConfigSetting("skip-check-deprecation", SetBool, nil, nil, true, nil)
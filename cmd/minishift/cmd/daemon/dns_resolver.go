@@ -0,0 +1,69 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var daemonDnsResolverCmd = &cobra.Command{
+	Use:    "dns-resolver",
+	Short:  "Resolves the routing suffix to the IP of the Minishift VM.",
+	Long:   `Starts a DNS server on host which resolves the names of the routing suffix to the IP of the Minishift VM and forwards all other queries to an upstream DNS server.`,
+	Run:    runDnsResolver,
+	Hidden: true,
+}
+
+func init() {
+	DaemonCmd.AddCommand(daemonDnsResolverCmd)
+}
+
+func runDnsResolver(cmd *cobra.Command, args []string) {
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dns.ResolverLogFile()), 0755); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	logFile, err := os.OpenFile(dns.ResolverLogFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	defer logFile.Close()
+
+	addr := dns.ResolverAddress(viper.GetInt(config.ServicesDnsPort.Name))
+	upstream := dns.ResolverUpstream(viper.GetString(config.ServicesDnsUpstream.Name))
+	if err := dns.RunResolver(host.Driver, addr, upstream, logFile); err != nil {
+		logFile.WriteString(err.Error() + "\n")
+		atexit.ExitWithMessage(1, err.Error())
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var daemonHostFolderMonitorCmd = &cobra.Command{
+	Use:    "hostfolder-monitor",
+	Short:  "Remounts stale host folders.",
+	Long:   `Checks the mounted host folders periodically and remounts stale host folders.`,
+	Run:    runHostFolderMonitor,
+	Hidden: true,
+}
+
+func init() {
+	DaemonCmd.AddCommand(daemonHostFolderMonitorCmd)
+}
+
+func runHostFolderMonitor(cmd *cobra.Command, args []string) {
+	hostFolderManager, err := hostfolder.NewManager(minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hostfolder.MonitorLogFile()), 0755); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	logFile, err := os.OpenFile(hostfolder.MonitorLogFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	defer logFile.Close()
+
+	hostfolder.NewMonitor(hostFolderManager, host.Driver, logFile).Run(hostfolder.MonitorInterval)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var daemonHostFolderSyncCmd = &cobra.Command{
+	Use:    "hostfolder-sync HOST_FOLDER_NAME",
+	Short:  "Keeps a host folder of type sync and its copy in the VM in sync.",
+	Long:   `Keeps a host folder of type sync and its copy in the VM in sync.`,
+	Run:    runHostFolderSync,
+	Hidden: true,
+}
+
+func init() {
+	DaemonCmd.AddCommand(daemonHostFolderSyncCmd)
+}
+
+func runHostFolderSync(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		atexit.ExitWithMessage(1, "Usage: minishift daemon hostfolder-sync HOST_FOLDER_NAME")
+	}
+
+	hostFolderManager, err := hostfolder.NewManager(minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	syncHostFolder, ok := hostFolderManager.HostFolder(args[0]).(*hostfolder.SyncHostFolder)
+	if !ok {
+		atexit.ExitWithMessage(1, fmt.Sprintf("no host folder of type %s with name '%s' defined", hostfolder.SYNC, args[0]))
+	}
+
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	if err := syncHostFolder.Watch(host.Driver); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+}
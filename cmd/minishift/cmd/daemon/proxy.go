@@ -17,8 +17,15 @@ limitations under the License.
 package daemon
 
 import (
+	"fmt"
+	"log"
+
 	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minishift/network/proxy"
+	minishiftTLS "github.com/minishift/minishift/pkg/minishift/tls"
+	"github.com/minishift/minishift/pkg/util"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -65,5 +72,23 @@ func runProxy(cmd *cobra.Command, args []string) {
 		proxyReEncrypt = proxyReEncryptFlag
 	}
 
-	proxy.StartProxy(proxyPort, proxyUpstreamAddr, proxyReEncrypt)
+	ca, err := minishiftTLS.ProxyCA(viper.GetBool(config.LocalProxyLegacyCA.Name))
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error loading the proxy CA: %s", err))
+	}
+
+	routes, err := util.ParseProxyRoutes(viper.GetStringSlice(config.LocalProxyRoutes.Name))
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	rules := proxy.NewHostRules(viper.GetStringSlice(config.LocalProxyAllow.Name), viper.GetStringSlice(config.LocalProxyDeny.Name))
+
+	accessLog, err := proxy.NewAccessLog(proxy.AccessLogFile(state.InstanceDirs.Logs), proxy.DefaultAccessLogMaxSize, proxy.DefaultAccessLogBackups)
+	if err != nil {
+		// the proxy is still usable without access log
+		log.Println(err)
+	}
+
+	proxy.StartProxy(proxyPort, proxyUpstreamAddr, routes, proxyReEncrypt, ca, rules, accessLog)
 }
@@ -20,7 +20,12 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/sftpfs"
 	"github.com/minishift/minishift/pkg/util/filehelper"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/pkg/sftp"
@@ -130,16 +135,10 @@ func serveConnections(listener net.Listener, serverConfig *ssh.ServerConfig) {
 					}
 				}(requests)
 
-				serverOptions := []sftp.ServerOption{
-					sftp.WithDebug(os.Stderr),
-				}
-				server, err := sftp.NewServer(
-					channel,
-					serverOptions...,
-				)
-				if err != nil {
-					glog.Fatal(err)
-				}
+				// Only the configured host folders are served. The configuration is re-read per
+				// channel to pick up host folders which got added after the daemon was started.
+				fileSystem := sftpfs.NewFileSystem(sshfsHostFolders())
+				server := sftp.NewRequestServer(channel, fileSystem.Handlers())
 				if err := server.Serve(); err == io.EOF {
 					server.Close()
 					atomic.AddUint64(&connectionCount, ^uint64(0))
@@ -158,6 +157,34 @@ func serveConnections(listener net.Listener, serverConfig *ssh.ServerConfig) {
 	}
 }
 
+// sshfsHostFolders returns the currently configured SSHFS host folders of the instance as well as the
+// host folders configured for all instances.
+func sshfsHostFolders() []hostFolderConfig.HostFolderConfig {
+	var configs []hostFolderConfig.HostFolderConfig
+
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(minishiftConstants.GetInstanceConfigPath())
+	if err != nil {
+		glog.Errorf("Unable to read instance config: %s", err)
+	} else {
+		configs = append(configs, instanceConfig.HostFolders...)
+	}
+
+	allInstancesConfig, err := minishiftConfig.NewAllInstancesConfig(constants.AllInstanceConfigPath)
+	if err != nil {
+		glog.Errorf("Unable to read all instances config: %s", err)
+	} else {
+		configs = append(configs, allInstancesConfig.HostFolders...)
+	}
+
+	var sshfsConfigs []hostFolderConfig.HostFolderConfig
+	for _, config := range configs {
+		if config.Type == hostfolder.SSHFS.String() {
+			sshfsConfigs = append(sshfsConfigs, config)
+		}
+	}
+	return sshfsConfigs
+}
+
 func serverConfig() *ssh.ServerConfig {
 	// An SSH server is represented by a ServerConfig, which holds certificate details and handles authentication
 	config := ssh.ServerConfig{
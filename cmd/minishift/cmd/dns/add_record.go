@@ -0,0 +1,50 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dnsAddRecordCmd = &cobra.Command{
+		Use:   "add-record HOST_NAME IP_ADDRESS",
+		Short: "Adds a DNS record to the DNS server",
+		Long:  "Adds a DNS record resolving the host name to the IP address to the DNS server. An existing record of the host name is replaced.",
+		Run:   addRecord,
+	}
+)
+
+func addRecord(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		atexit.ExitWithMessage(1, "Usage: minishift dns add-record HOST_NAME IP_ADDRESS")
+	}
+
+	if err := dns.AddRecord(minishiftConfig.InstanceConfig, args[0], args[1]); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error adding the DNS record: %s", err.Error()))
+	}
+	applyRecords()
+}
+
+func init() {
+	DnsCmd.AddCommand(dnsAddRecordCmd)
+}
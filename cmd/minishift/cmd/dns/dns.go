@@ -17,6 +17,17 @@ limitations under the License.
 package dns
 
 import (
+	"fmt"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/provision"
+	"github.com/docker/machine/libmachine/state"
+	minishiftState "github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
 )
 
@@ -32,3 +43,21 @@ var DnsCmd = &cobra.Command{
 		cmd.Help()
 	},
 }
+
+// applyRecords pushes the DNS records of the instance to the VM, provided it is running. Otherwise they get applied
+// with the next start of the DNS server.
+func applyRecords() {
+	api := libmachine.NewClient(minishiftState.InstanceDirs.Home, minishiftState.InstanceDirs.Certs)
+	defer api.Close()
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil || !drivers.MachineInState(host.Driver, state.Running)() {
+		fmt.Println("The DNS records will be applied when the DNS server is started.")
+		return
+	}
+
+	sshCommander := provision.GenericSSHCommander{Driver: host.Driver}
+	if err := dns.ApplyRecords(sshCommander, minishiftConfig.InstanceConfig); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error applying the DNS records: %s", err.Error()))
+	}
+}
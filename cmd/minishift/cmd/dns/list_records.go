@@ -0,0 +1,55 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dnsListRecordsCmd = &cobra.Command{
+		Use:   "list-records",
+		Short: "Lists the DNS records of the DNS server",
+		Long:  "Lists the DNS records added to the DNS server",
+		Run:   listRecords,
+	}
+)
+
+func listRecords(cmd *cobra.Command, args []string) {
+	names := dns.RecordNames(minishiftConfig.InstanceConfig)
+	if len(names) == 0 {
+		fmt.Println("No DNS records defined")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(writer, "Name\tIP")
+	for _, name := range names {
+		fmt.Fprintln(writer, fmt.Sprintf("%s\t%s", name, minishiftConfig.InstanceConfig.DnsRecords[name]))
+	}
+	writer.Flush()
+}
+
+func init() {
+	DnsCmd.AddCommand(dnsListRecordsCmd)
+}
@@ -19,11 +19,13 @@ package hostfolder
 import (
 	"fmt"
 	"runtime"
+	"strconv"
 	"strings"
 
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/filesync"
 	"github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
@@ -48,11 +50,27 @@ const (
 	interactiveFlag      = "interactive"
 	instanceOnlyFlag     = "instance-only"
 	usersShareFlag       = "users-share"
+	readOnlyFlag         = "read-only"
+	ignoreFlag           = "ignore"
+	uidFlag              = "uid"
+	gidFlag              = "gid"
+	fileModeFlag         = "file-mode"
+	dirModeFlag          = "dir-mode"
+	cacheFlag            = "cache"
+	asPVFlag             = "as-pv"
 )
 
 var (
 	instanceOnly bool
 	usersShare   bool
+	readOnly     bool
+	ignore       string
+	uid          string
+	gid          string
+	fileMode     string
+	dirMode      string
+	cacheMode    string
+	asPV         string
 	interactive  bool
 	shareType    string
 	source       string
@@ -69,12 +87,20 @@ var addCmd = &cobra.Command{
 
 func init() {
 	HostFolderCmd.AddCommand(addCmd)
-	addCmd.Flags().StringVarP(&shareType, shareTypeFlag, "t", "sshfs", "The host folder type. Allowed types are [cifs|sshfs].")
+	addCmd.Flags().StringVarP(&shareType, shareTypeFlag, "t", "sshfs", "The host folder type. Allowed types are [cifs|sshfs|sync].")
 	addCmd.Flags().StringVar(&source, sourceFlag, "", "The source of the host folder.")
 	addCmd.Flags().StringVar(&target, targetFlag, "", "The target (mount point) of the host folder.")
 	addCmd.Flags().StringVar(&options, optionsFlag, "", "Host folder type specific options.")
 	addCmd.Flags().BoolVar(&instanceOnly, instanceOnlyFlag, false, "Defines the host folder only for the current Minishift instance.")
 	addCmd.Flags().BoolVarP(&interactive, interactiveFlag, "i", false, "Allows to interactively provide the required parameters.")
+	addCmd.Flags().BoolVar(&readOnly, readOnlyFlag, false, "Only allows read access to the host folder from within the VM. Supported for cifs and sshfs host folders.")
+	addCmd.Flags().StringVar(&uid, uidFlag, "", "The user id owning the files of the mounted host folder. Supported for cifs and sshfs host folders.")
+	addCmd.Flags().StringVar(&gid, gidFlag, "", "The group id owning the files of the mounted host folder. Supported for cifs and sshfs host folders.")
+	addCmd.Flags().StringVar(&fileMode, fileModeFlag, "", "The octal permissions of the files of the mounted host folder, for example 0666. Supported for cifs and sshfs host folders.")
+	addCmd.Flags().StringVar(&dirMode, dirModeFlag, "", "The octal permissions of the directories of the mounted host folder, for example 0777. Supported for cifs and sshfs host folders.")
+	addCmd.Flags().StringVar(&cacheMode, cacheFlag, "", fmt.Sprintf("The caching of the mounted host folder. Allowed modes are [%s]. Supported for cifs and sshfs host folders.", strings.Join(hostFolderConfig.ValidCacheModes, "|")))
+	addCmd.Flags().StringVar(&asPV, asPVFlag, "", "Exposes the mounted host folder as OpenShift persistent volume of the specified size, for example 10Gi.")
+	addCmd.Flags().StringVar(&ignore, ignoreFlag, "", "Comma separated list of file patterns which are not synchronized. Supported for sync host folders.")
 
 	// Windows-only
 	if runtime.GOOS == "windows" {
@@ -111,6 +137,10 @@ func addHostFolder(cmd *cobra.Command, args []string) {
 		atexit.ExitWithMessage(1, fmt.Sprintf("there is already a host folder with the name '%s' defined", name))
 	}
 
+	if err := hostFolderConfig.ValidateMountOptions(shareType, mountOptions()); err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
 	switch shareType {
 	case hostFolderConfig.CIFS.String():
 		if interactive {
@@ -124,6 +154,12 @@ func addHostFolder(cmd *cobra.Command, args []string) {
 		} else {
 			addSSHFSNonInteractive(hostFolderManager, name)
 		}
+	case hostFolderConfig.SYNC.String():
+		if interactive {
+			addSyncInteractive(hostFolderManager, name)
+		} else {
+			addSyncNonInteractive(hostFolderManager, name)
+		}
 	default:
 		atexit.ExitWithMessage(1, fmt.Sprintf(unknownType, shareType))
 	}
@@ -146,6 +182,9 @@ func addSSHFSInteractive(manager *hostFolderConfig.Manager, name string) error {
 
 	mountPath := readInputForMountPoint(name)
 
+	readOnlyInput := strings.ToLower(util.ReadInputFromStdin("Read-only [y/N]"))
+	readOnly = readOnly || readOnlyInput == "y" || readOnlyInput == "yes"
+
 	config := config.HostFolderConfig{
 		Name: name,
 		Type: hostFolderConfig.SSHFS.String(),
@@ -154,6 +193,7 @@ func addSSHFSInteractive(manager *hostFolderConfig.Manager, name string) error {
 			config.MountPoint: mountPath,
 		},
 	}
+	addMountOptions(config.Options)
 	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
 	manager.Add(hostFolder, !instanceOnly)
 
@@ -182,12 +222,71 @@ func addSSHFSNonInteractive(manager *hostFolderConfig.Manager, name string) erro
 			config.ExtraOptions: options,
 		},
 	}
+	addMountOptions(config.Options)
 	hostFolder := hostFolderConfig.NewSSHFSHostFolder(config, minishiftConfig.AllInstancesConfig)
 	manager.Add(hostFolder, !instanceOnly)
 
 	return nil
 }
 
+func addSyncInteractive(manager *hostFolderConfig.Manager, name string) {
+	source := util.ReadInputFromStdin("Source path")
+	source, err := homedir.Expand(source)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	if source == "" {
+		atexit.ExitWithMessage(1, noSource)
+	}
+
+	if runtime.GOOS == "windows" {
+		source = minishiftStrings.ConvertSlashes(source)
+	}
+
+	mountPath := readInputForMountPoint(name)
+
+	if ignore == "" {
+		ignore = util.ReadInputFromStdin("Ignore patterns (comma separated)")
+	}
+
+	addSync(manager, name, source, mountPath)
+}
+
+func addSyncNonInteractive(manager *hostFolderConfig.Manager, name string) {
+	if source == "" {
+		atexit.ExitWithMessage(1, noSource)
+	}
+
+	if runtime.GOOS == "windows" {
+		source = minishiftStrings.ConvertSlashes(source)
+	}
+
+	if target == "" {
+		atexit.ExitWithMessage(1, noTarget)
+	}
+
+	addSync(manager, name, source, target)
+}
+
+func addSync(manager *hostFolderConfig.Manager, name string, source string, target string) {
+	options := map[string]string{
+		config.Source:     source,
+		config.MountPoint: target,
+		config.Ignore:     strings.Join(filesync.ParseIgnore(ignore).Patterns(), ","),
+	}
+	if asPV != "" {
+		options[config.PVSize] = asPV
+	}
+	config := config.HostFolderConfig{
+		Name:    name,
+		Type:    hostFolderConfig.SYNC.String(),
+		Options: options,
+	}
+	hostFolder := hostFolderConfig.NewSyncHostFolder(config)
+	manager.Add(hostFolder, !instanceOnly)
+}
+
 func addCIFSInteractive(manager *hostFolderConfig.Manager, name string) error {
 	var uncPath string
 	if usersShare {
@@ -231,6 +330,7 @@ func addCIFSInteractive(manager *hostFolderConfig.Manager, name string) error {
 			config.ExtraOptions: options,
 		},
 	}
+	addMountOptions(config.Options)
 	hostFolder := hostFolderConfig.NewCifsHostFolder(config)
 	manager.Add(hostFolder, !instanceOnly)
 
@@ -273,17 +373,46 @@ func addCIFSNonInteractive(manager *hostFolderConfig.Manager, name string) {
 			config.Domain:     domain,
 		},
 	}
+	addMountOptions(config.Options)
 	hostFolder := hostFolderConfig.NewCifsHostFolder(config)
 	manager.Add(hostFolder, !instanceOnly)
 }
 
+// mountOptions returns the structured mount options specified via flags.
+func mountOptions() map[string]string {
+	options := map[string]string{
+		config.UID:       uid,
+		config.GID:       gid,
+		config.FileMode:  fileMode,
+		config.DirMode:   dirMode,
+		config.CacheMode: cacheMode,
+		config.PVSize:    asPV,
+	}
+	if readOnly {
+		options[config.ReadOnly] = strconv.FormatBool(readOnly)
+	}
+
+	for key, value := range options {
+		if value == "" {
+			delete(options, key)
+		}
+	}
+	return options
+}
+
+func addMountOptions(options map[string]string) {
+	for key, value := range mountOptions() {
+		options[key] = value
+	}
+}
+
 // returns the name and full-name for shareType
 func readNameAndTypeInteractive() (string, string) {
 	name := util.ReadInputFromStdin("Name")
 	if name == "" {
 		atexit.ExitWithMessage(1, noName)
 	}
-	shareType := strings.ToLower(util.ReadInputFromStdin("Type [sshfs, cifs (S/c)]"))
+	shareType := strings.ToLower(util.ReadInputFromStdin("Type [sshfs, cifs, sync (S/c/sync)]"))
 
 	if shareType == "s" || shareType == "" {
 		return name, hostFolderConfig.SSHFS.String()
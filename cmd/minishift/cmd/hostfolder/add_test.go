@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"github.com/minishift/minishift/cmd/testing/cli"
 	"github.com/minishift/minishift/pkg/minishift/config"
+	hostFolderConfig "github.com/minishift/minishift/pkg/minishift/hostfolder/config"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -158,3 +159,56 @@ func Test_unknown_host_folder_type_exits(t *testing.T) {
 	shareType = "snafu"
 	addHostFolder(nil, []string{"foo"})
 }
+
+func Test_add_sync_host_folder(t *testing.T) {
+	var err error
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	config.InstanceConfig, err = config.NewInstanceConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	config.AllInstancesConfig, err = config.NewAllInstancesConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	assert.NoError(t, err, "Unexpected error setting instance config")
+
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+	defer viper.Reset()
+	defer func() {
+		shareType = "sshfs"
+		ignore = ""
+	}()
+
+	source = "/home/johndoe/code"
+	target = "/mnt/sda1/code"
+	shareType = "sync"
+	ignore = " node_modules, *.log,"
+	addHostFolder(nil, []string{"code"})
+
+	hostFolders := config.AllInstancesConfig.HostFolders
+	assert.Len(t, hostFolders, 1)
+	assert.Equal(t, "code", hostFolders[0].Name)
+	assert.Equal(t, "sync", hostFolders[0].Type)
+	assert.Equal(t, "/home/johndoe/code", hostFolders[0].Option(hostFolderConfig.Source))
+	assert.Equal(t, "/mnt/sda1/code", hostFolders[0].MountPoint())
+	assert.Equal(t, "node_modules,*.log", hostFolders[0].Option(hostFolderConfig.Ignore))
+}
+
+func Test_mount_options_are_validated(t *testing.T) {
+	var err error
+	tmpMinishiftHomeDir := cli.SetupTmpMinishiftHome(t)
+	config.InstanceConfig, err = config.NewInstanceConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	config.AllInstancesConfig, err = config.NewAllInstancesConfig(filepath.Join(tmpMinishiftHomeDir, "config"))
+	assert.NoError(t, err, "Unexpected error setting instance config")
+
+	tee := cli.CreateTee(t, true)
+	defer cli.TearDown(tmpMinishiftHomeDir, tee)
+	defer viper.Reset()
+	defer func() {
+		shareType = "sshfs"
+		uid = ""
+	}()
+
+	atexit.RegisterExitHandler(cli.VerifyExitCodeAndMessage(t, tee, 1, "invalid uid 'root', it needs to be a non-negative number"))
+	source = "/home/johndoe"
+	target = "/var/tmp"
+	shareType = "sshfs"
+	uid = "root"
+	addHostFolder(nil, []string{"foo"})
+}
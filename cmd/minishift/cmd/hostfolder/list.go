@@ -49,7 +49,9 @@ var listCmd = &cobra.Command{
 
 		for _, info := range mountInfos {
 			mounted := "N"
-			if info.Mounted {
+			if info.Stale {
+				mounted = "stale"
+			} else if info.Mounted {
 				mounted = "Y"
 			}
 
@@ -23,6 +23,7 @@ import (
 	"github.com/minishift/minishift/cmd/minishift/cmd/util"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
 )
@@ -49,6 +50,7 @@ var mountCmd = &cobra.Command{
 		util.ExitIfNotRunning(host.Driver, constants.MachineName)
 
 		hostFolderManager := getHostFolderManager()
+		setOcRunner(hostFolderManager)
 		err = nil
 		if mountAll {
 			fmt.Println("-- Mounting host folders")
@@ -64,6 +66,9 @@ var mountCmd = &cobra.Command{
 			atexit.ExitWithMessage(1, err.Error())
 		}
 
+		if err := hostfolder.EnsureMonitorRunning(); err != nil {
+			fmt.Println(fmt.Sprintf("WARN: Unable to start the host folder monitor: %s", err))
+		}
 	},
 }
 
@@ -31,6 +31,7 @@ var removeCmd = &cobra.Command{
 		}
 
 		hostFolderManager := getHostFolderManager()
+		setOcRunner(hostFolderManager)
 
 		name := args[0]
 		err := hostFolderManager.Remove(name)
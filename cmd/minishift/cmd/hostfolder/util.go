@@ -19,8 +19,10 @@ package hostfolder
 import (
 	"fmt"
 	cmdConfig "github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/pkg/minikube/constants"
 	"github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/minishift/oc"
 	"github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/viper"
@@ -52,6 +54,14 @@ func getHostFolderManager() *hostfolder.Manager {
 	return hostFolderManager
 }
 
+// setOcRunner enables the management of host folder persistent volumes, provided the cluster has been provisioned.
+func setOcRunner(hostFolderManager *hostfolder.Manager) {
+	ocRunner, err := oc.NewOcRunner(config.InstanceStateConfig.OcPath, constants.KubeConfigPath)
+	if err == nil {
+		hostFolderManager.SetOcRunner(ocRunner)
+	}
+}
+
 func readInputForMountPoint(name string) string {
 	defaultMountPoint := getHostFolderMountPath(name)
 	mountPointText := fmt.Sprintf("Mountpoint [%s]", defaultMountPoint)
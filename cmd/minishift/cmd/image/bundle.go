@@ -0,0 +1,35 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var ImageBundleCmd = &cobra.Command{
+	Use:   "bundle SUBCOMMAND [flags]",
+	Short: "Creates and loads image bundles for machines without network access.",
+	Long: `Creates and loads image bundles for machines without network access.
+An image bundle is a tar archive containing the cached images of an OpenShift version together with the cached oc binary and ISO.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	ImageCmd.AddCommand(ImageBundleCmd)
+}
@@ -0,0 +1,169 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	units "github.com/docker/go-units"
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/cache"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	minishiftUtil "github.com/minishift/minishift/pkg/minishift/util"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/minishift/minishift/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	bundleOutputFlag = "output"
+)
+
+var (
+	bundleOpenShiftVersion string
+	bundleOutput           string
+
+	bundleCreateCmd = &cobra.Command{
+		Use:   "create [image ...]",
+		Short: "Creates an image bundle from the image cache.",
+		Long: `Creates an image bundle containing the images of the specified OpenShift version, the configured cache images and the specified images.
+The cached oc binary of the OpenShift version and the cached ISO are added to the bundle as well. All images need to be exported to the cache beforehand.`,
+		Run: createBundle,
+	}
+)
+
+func createBundle(cmd *cobra.Command, args []string) {
+	if bundleOutput == "" {
+		atexit.ExitWithMessage(1, fmt.Sprintf("You need to specify the bundle file using --%s.", bundleOutputFlag))
+	}
+
+	openShiftVersion := bundleOpenShiftVersion
+	if openShiftVersion == "" {
+		openShiftVersion = configuredOpenShiftVersion()
+	}
+	if !strings.HasPrefix(openShiftVersion, constants.VersionPrefix) {
+		openShiftVersion = constants.VersionPrefix + openShiftVersion
+	}
+
+	images, err := bundleImages(openShiftVersion, minishiftConfig.InstanceConfig.CacheImages, args)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Invalid image name: %v", err))
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	options := &image.BundleOptions{
+		CacheDir:         state.InstanceDirs.Cache,
+		ImageCacheDir:    state.InstanceDirs.ImageCache,
+		Images:           images,
+		Files:            bundleFiles(openShiftVersion),
+		OpenShiftVersion: openShiftVersion,
+	}
+	manifest, err := handler.CreateBundle(bundleOutput, options)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image bundle: %v", err))
+	}
+
+	var size int64
+	for _, file := range manifest.Files {
+		size += file.Size
+	}
+	fmt.Println(fmt.Sprintf("Created the image bundle '%s' for OpenShift %s with %d images and %d files (%s)",
+		bundleOutput, openShiftVersion, len(manifest.Images), len(manifest.Files), units.HumanSize(float64(size))))
+}
+
+// bundleImages returns the normalized names of the images of the OpenShift version, the configured cache images and
+// the specified images, without duplicates.
+func bundleImages(openShiftVersion string, configured []string, args []string) ([]string, error) {
+	images := append(image.GetOpenShiftImageNames(openShiftVersion), configured...)
+	normalized, err := normalizeImageNames(append(images, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	var unique []string
+	seen := make(map[string]bool)
+	for _, name := range normalized {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique, nil
+}
+
+// bundleFiles returns the paths of the cached oc binary and ISO relative to the cache directory. Files which are not
+// cached are skipped.
+func bundleFiles(openShiftVersion string) []string {
+	var files []string
+
+	oc := cache.Oc{OpenShiftVersion: openShiftVersion, MinishiftCacheDir: state.InstanceDirs.Cache}
+	if file, ok := cachedFile(filepath.Join(oc.GetCacheFilepath(), constants.OC_BINARY_NAME)); ok {
+		files = append(files, file)
+	} else {
+		fmt.Println(fmt.Sprintf("WARN: The oc binary of OpenShift %s is not cached and is not added to the bundle", openShiftVersion))
+	}
+
+	if isoPath := cachedIsoPath(viper.GetString(config.ISOUrl.Name)); isoPath != "" {
+		if file, ok := cachedFile(isoPath); ok {
+			files = append(files, file)
+		} else {
+			fmt.Println("WARN: The ISO is not cached and is not added to the bundle")
+		}
+	}
+	return files
+}
+
+// cachedIsoPath returns the path of the cached ISO for the specified iso-url setting. ISOs referenced by a file URI
+// are not cached and an empty string is returned.
+func cachedIsoPath(isoUrl string) string {
+	if isoUrl == "" || strings.ToLower(isoUrl) == minishiftConstants.CentOsIsoAlias {
+		isoUrl = constants.DefaultCentOsIsoUrl
+	}
+	if strings.HasPrefix(isoUrl, "file:") {
+		return ""
+	}
+	return filepath.Join(state.InstanceDirs.IsoCache, minishiftUtil.GetIsoPath(isoUrl), filepath.Base(isoUrl))
+}
+
+func cachedFile(path string) (string, bool) {
+	if !filehelper.Exists(path) {
+		return "", false
+	}
+	relative, err := filepath.Rel(state.InstanceDirs.Cache, path)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(relative), true
+}
+
+func init() {
+	bundleCreateCmd.Flags().StringVar(&bundleOpenShiftVersion, config.OpenshiftVersion.Name, "", fmt.Sprintf("The OpenShift version to bundle the images for, eg %s. Defaults to the configured OpenShift version.", version.GetOpenShiftVersion()))
+	bundleCreateCmd.Flags().StringVarP(&bundleOutput, bundleOutputFlag, "o", "", "The file to write the image bundle to.")
+	ImageBundleCmd.AddCommand(bundleCreateCmd)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleLoadCmd = &cobra.Command{
+		Use:   "load BUNDLE",
+		Short: "Loads an image bundle into the cache.",
+		Long: `Loads an image bundle created by 'minishift image bundle create' into the cache. The content of the bundle is verified against its manifest before it is added.
+Once loaded, 'minishift start' with the bundled OpenShift version does not need network access to get the images, the oc binary and the ISO.`,
+		Run: loadBundle,
+	}
+)
+
+func loadBundle(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		atexit.ExitWithMessage(1, "You need to specify the image bundle to load.")
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	result, err := handler.LoadBundle(args[0], state.InstanceDirs.Cache, state.InstanceDirs.ImageCache)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot load the image bundle: %v", err))
+	}
+
+	for _, file := range result.LoadedFiles {
+		fmt.Println(fmt.Sprintf("Added '%s' to the cache", file))
+	}
+	fmt.Println(fmt.Sprintf("Loaded %d images of OpenShift %s. %d blobs were added, %d blobs were cached already.",
+		len(result.Manifest.Images), result.Manifest.OpenShiftVersion, result.LoadedBlobs, result.SkippedBlobs))
+}
+
+func init() {
+	ImageBundleCmd.AddCommand(bundleLoadCmd)
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_bundle_images_are_normalized_without_duplicates(t *testing.T) {
+	images, err := bundleImages("v3.11.0", []string{"acme/foo:v1", "openshift/origin-haproxy-router:v3.11.0"}, []string{"acme/bar", "acme/foo:v1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"openshift/origin-control-plane:v3.11.0",
+		"openshift/origin-docker-registry:v3.11.0",
+		"openshift/origin-haproxy-router:v3.11.0",
+		"acme/foo:v1",
+		"acme/bar:latest",
+	}, images)
+
+	_, err = bundleImages("v3.11.0", nil, []string{"Invalid/Name"})
+	assert.Error(t, err)
+}
+
+func Test_cached_iso_path(t *testing.T) {
+	origDirs := state.InstanceDirs
+	defer func() { state.InstanceDirs = origDirs }()
+	state.InstanceDirs = state.GetMinishiftDirsStructure("/minishift")
+
+	isoCache := state.InstanceDirs.IsoCache
+	assert.Equal(t, filepath.Join(isoCache, "centos", "v1.2.3", "minishift-centos7.iso"),
+		cachedIsoPath("https://example.com/download/v1.2.3/minishift-centos7.iso"))
+	assert.Equal(t, filepath.Join(isoCache, "unnamed", "custom.iso"), cachedIsoPath("https://example.com/custom.iso"))
+	assert.Equal(t, "", cachedIsoPath("file:///tmp/custom.iso"))
+	assert.Contains(t, cachedIsoPath(""), "minishift-centos7.iso")
+	assert.Equal(t, cachedIsoPath(""), cachedIsoPath("centos"))
+}
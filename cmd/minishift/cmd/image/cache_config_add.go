@@ -56,6 +56,7 @@ func addConfiguredImage(cmd *cobra.Command, args []string) {
 	if err := minishiftConfig.InstanceConfig.Write(); err != nil {
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error writing the cache image to config: %v", err))
 	}
+	recordProfileImages()
 }
 
 func init() {
@@ -97,6 +97,7 @@ func exportImage(cmd *cobra.Command, args []string) {
 		CachedImages:      normalizedImageNames,
 		Out:               out,
 		ImageMissStrategy: image.Pull,
+		Concurrency:       viper.GetInt(config.ImageCacheConcurrency.Name),
 	}
 
 	_, err = handler.ExportImages(imageCacheConfig, overwrite)
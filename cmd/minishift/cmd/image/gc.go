@@ -0,0 +1,117 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/minishift/profile"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	gcDryRunFlag    = "dry-run"
+	gcForceFlag     = "force"
+	gcMaxSizeFlag   = "max-size"
+	gcUnusedForFlag = "unused-for"
+)
+
+var (
+	gcDryRun    bool
+	gcForce     bool
+	gcMaxSize   string
+	gcUnusedFor time.Duration
+
+	imageGcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Removes the cached images which are not needed by any profile.",
+		Long: `Removes the cached images which are not needed by any profile, ie images which are neither part of the cache-images setting nor of the OpenShift version of a profile.
+Blobs of the cache which do not belong to any image are removed as well.
+Using --max-size and --unused-for, the least recently used images can be evicted even though they are needed by a profile. They are exported again on the next start of the profile.`,
+		Run: gcImages,
+	}
+)
+
+func gcImages(cmd *cobra.Command, args []string) {
+	options := &image.GCOptions{
+		Profiles:      profile.GetProfileList(),
+		ProfileImages: map[string][]string{constants.ProfileName: profileImages()},
+		MaxUnused:     gcUnusedFor,
+		DryRun:        gcDryRun,
+		Force:         gcForce,
+	}
+	if gcMaxSize != "" {
+		maxSize, err := units.FromHumanSize(gcMaxSize)
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Invalid value '%s' for --%s: %v", gcMaxSize, gcMaxSizeFlag, err))
+		}
+		options.MaxSize = maxSize
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	result, err := handler.CollectGarbage(state.InstanceDirs.ImageCache, options)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot collect the garbage of the image cache: %v", err))
+	}
+
+	printGCResult(result, gcDryRun, os.Stdout)
+}
+
+func printGCResult(result *image.GCResult, dryRun bool, out io.Writer) {
+	action, reclaimed := "Removed", "Reclaimed"
+	if dryRun {
+		action, reclaimed = "Would remove", "Would reclaim"
+	}
+
+	for _, removed := range result.RemovedImages {
+		fmt.Fprintln(out, fmt.Sprintf("%s '%s' (%s, %s)", action, removed.Name, removed.Reason, units.HumanSize(float64(removed.Size))))
+	}
+	for _, kept := range result.KeptImages {
+		fmt.Fprintln(out, fmt.Sprintf("Kept '%s' (%s)", kept.Name, kept.Reason))
+	}
+	if len(result.RemovedProfiles) > 0 {
+		fmt.Fprintln(out, fmt.Sprintf("Dropped the image references of the deleted profiles: %s", strings.Join(result.RemovedProfiles, ", ")))
+	}
+	if len(result.UnrecordedProfiles) > 0 {
+		fmt.Fprintln(out, fmt.Sprintf("WARN: The profiles %s have not recorded the images they need yet. Start them once to record their images, or use --%s to remove the unreferenced images anyway.",
+			strings.Join(result.UnrecordedProfiles, ", "), gcForceFlag))
+	}
+
+	fmt.Fprintln(out, fmt.Sprintf("%s %s, including %d orphaned blobs. The image cache size is %s.",
+		reclaimed, units.HumanSize(float64(result.ReclaimedBytes)), result.OrphanedBlobs, units.HumanSize(float64(result.CacheSize))))
+}
+
+func init() {
+	imageGcCmd.Flags().BoolVar(&gcDryRun, gcDryRunFlag, false, "Only shows which images would be removed, without changing the cache.")
+	imageGcCmd.Flags().BoolVar(&gcForce, gcForceFlag, false, "Removes the unreferenced images even though some profiles have not recorded the images they need yet.")
+	imageGcCmd.Flags().StringVar(&gcMaxSize, gcMaxSizeFlag, "", "The maximum size of the image cache, eg 10GB. The least recently used images are evicted until the cache fits.")
+	imageGcCmd.Flags().DurationVar(&gcUnusedFor, gcUnusedForFlag, 0, "Evicts the images which have not been imported or exported for the specified duration, eg 720h.")
+	ImageCmd.AddCommand(imageGcCmd)
+}
@@ -0,0 +1,58 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_gc_result_is_printed(t *testing.T) {
+	result := &image.GCResult{
+		RemovedImages: []*image.RemovedImage{
+			{Name: "acme/foo:v1", Reason: image.Unreferenced, Size: 2000000},
+			{Name: "acme/bar:v1", Reason: image.CacheSize, Size: 1000000},
+		},
+		KeptImages: []*image.KeptImage{
+			{Name: "acme/baz:v1", Reason: image.UnrecordedProfiles},
+			{Name: "acme/qux:v1", Reason: image.UnknownLastUse},
+		},
+		RemovedProfiles:    []string{"old"},
+		UnrecordedProfiles: []string{"new"},
+		OrphanedBlobs:      2,
+		ReclaimedBytes:     3500000,
+		CacheSize:          5000000000,
+	}
+
+	out := new(bytes.Buffer)
+	printGCResult(result, false, out)
+	assert.Equal(t, `Removed 'acme/foo:v1' (unreferenced, 2 MB)
+Removed 'acme/bar:v1' (cache size, 1 MB)
+Kept 'acme/baz:v1' (profiles without recorded images)
+Kept 'acme/qux:v1' (last use unknown)
+Dropped the image references of the deleted profiles: old
+WARN: The profiles new have not recorded the images they need yet. Start them once to record their images, or use --force to remove the unreferenced images anyway.
+Reclaimed 3.5 MB, including 2 orphaned blobs. The image cache size is 5 GB.
+`, out.String())
+
+	out = new(bytes.Buffer)
+	printGCResult(&image.GCResult{}, true, out)
+	assert.Equal(t, "Would reclaim 0 B, including 0 orphaned blobs. The image cache size is 0 B.\n", out.String())
+}
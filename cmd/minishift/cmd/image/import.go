@@ -98,6 +98,7 @@ func importImage(cmd *cobra.Command, args []string) {
 		CachedImages:      normalizedImageNames,
 		Out:               os.Stdout,
 		ImageMissStrategy: image.Skip,
+		Concurrency:       viper.GetInt(config.ImageCacheConcurrency.Name),
 	}
 
 	importedImages, err := handler.ImportImages(imageCacheConfig)
@@ -17,21 +17,42 @@ limitations under the License.
 package image
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	units "github.com/docker/go-units"
 	"github.com/docker/machine/libmachine"
 	"github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
 	"github.com/spf13/cobra"
 )
 
+const (
+	listFormatTable    = "table"
+	listFormatJson     = "json"
+	listFormatTemplate = "template"
+)
+
 var (
 	dockerDaemonImages bool
+	listDetails        bool
+	listFormat         string
+	listTemplate       string
 
 	imageCacheListCmd = &cobra.Command{
 		Use:   "list ",
 		Short: "Displays the locally cached images.",
-		Long:  "Displays the locally cached images.",
-		Run:   listImages,
+		Long: `Displays the locally cached images.
+Use --details to display the digest, size, number of layers, creation time and last import or export time of each cached image, together with the profiles which need it.`,
+		Run: listImages,
 	}
 )
 
@@ -39,7 +60,13 @@ func listImages(cmd *cobra.Command, args []string) {
 	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
 	defer api.Close()
 
-	if dockerDaemonImages {
+	if listDetails && dockerDaemonImages {
+		atexit.ExitWithMessage(1, "The details can only be displayed for cached images.")
+	}
+
+	if listDetails {
+		listCachedImageDetails()
+	} else if dockerDaemonImages {
 		listDockerDaemonImages(api)
 	} else {
 		listCachedImages()
@@ -54,6 +81,86 @@ func listCachedImages() {
 	}
 }
 
+func listCachedImageDetails() {
+	var tmpl *template.Template
+	switch listFormat {
+	case listFormatTable, listFormatJson:
+	case listFormatTemplate:
+		if listTemplate == "" {
+			atexit.ExitWithMessage(1, "You need to specify the template to use with --template.")
+		}
+		var err error
+		if tmpl, err = template.New("list").Parse(listTemplate); err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Error creating the list template: %v", err))
+		}
+	default:
+		atexit.ExitWithMessage(1, fmt.Sprintf("Unsupported format '%s'. Use one of %s, %s or %s.", listFormat, listFormatTable, listFormatJson, listFormatTemplate))
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	details, err := handler.GetCachedImageDetails(state.InstanceDirs.ImageCache)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot read the image cache: %v", err))
+	}
+
+	if err := printImageDetails(details, listFormat, tmpl, os.Stdout, time.Now()); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error printing the image details: %v", err))
+	}
+}
+
+func printImageDetails(details []*image.ImageDetails, format string, tmpl *template.Template, out io.Writer, now time.Time) error {
+	switch format {
+	case listFormatJson:
+		jsonData, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, string(jsonData))
+	case listFormatTemplate:
+		for _, image := range details {
+			if err := tmpl.Execute(out, image); err != nil {
+				return err
+			}
+			fmt.Fprintln(out)
+		}
+	default:
+		display := new(tabwriter.Writer)
+		display.Init(out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(display, "NAME\tDIGEST\tSIZE\tLAYERS\tCREATED\tLAST USED\tPROFILES")
+		for _, image := range details {
+			profiles := "-"
+			if len(image.Profiles) > 0 {
+				profiles = strings.Join(image.Profiles, ",")
+			}
+			fmt.Fprintln(display, fmt.Sprintf("%s\t%s\t%s\t%d\t%s\t%s\t%s", image.Name, shortDigest(image.Digest),
+				units.HumanSize(float64(image.Size)), image.Layers, timeAgo(image.Created, now, "unknown"),
+				timeAgo(image.LastUsed, now, "never"), profiles))
+		}
+		return display.Flush()
+	}
+	return nil
+}
+
+// shortDigest abbreviates the specified digest to 12 hex characters, the way Docker displays image IDs
+func shortDigest(digest string) string {
+	hex := digest[strings.Index(digest, ":")+1:]
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+func timeAgo(t time.Time, now time.Time, unset string) string {
+	if t.IsZero() {
+		return unset
+	}
+	return fmt.Sprintf("%s ago", units.HumanDuration(now.Sub(t)))
+}
+
 func listDockerDaemonImages(api *libmachine.Client) {
 	images := getDockerDaemonImages(api)
 	if len(images) == 0 {
@@ -71,5 +178,9 @@ func printImageList(images []string) {
 
 func init() {
 	imageCacheListCmd.Flags().BoolVar(&dockerDaemonImages, "vm", false, "Prints the available images in the Docker daemon.")
+	imageCacheListCmd.Flags().BoolVar(&listDetails, "details", false, "Prints the details of the cached images.")
+	imageCacheListCmd.Flags().StringVar(&listFormat, "format", listFormatTable, "The output format of the details. One of table, json or template.")
+	imageCacheListCmd.Flags().StringVar(&listTemplate, "template", "",
+		`Go template to apply to each image when using '--format template', eg '{{.Name}} {{.Size}}'. For the available fields, see ImageDetails at: https://godoc.org/github.com/minishift/minishift/pkg/minishift/docker/image#ImageDetails`)
 	ImageCmd.AddCommand(imageCacheListCmd)
 }
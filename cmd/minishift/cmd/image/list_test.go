@@ -0,0 +1,80 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testNow     = time.Date(2018, 7, 10, 12, 0, 0, 0, time.UTC)
+	testDetails = []*image.ImageDetails{
+		{
+			Name:     "openshift/origin-control-plane:v3.11.0",
+			Digest:   "sha256:0123456789abcdef0123456789abcdef",
+			Size:     250000000,
+			Layers:   5,
+			Created:  testNow.Add(-72 * time.Hour),
+			LastUsed: testNow.Add(-2 * time.Hour),
+			Profiles: []string{"minishift", "other"},
+		},
+		{
+			Name:     "acme/foo:v1",
+			Digest:   "sha256:fedcba9876543210",
+			Size:     1500000,
+			Layers:   1,
+			Profiles: []string{},
+		},
+	}
+)
+
+func Test_image_details_are_printed_as_table(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, printImageDetails(testDetails, listFormatTable, nil, out, testNow))
+	assert.Equal(t, `NAME                                    DIGEST        SIZE    LAYERS  CREATED     LAST USED    PROFILES
+openshift/origin-control-plane:v3.11.0  0123456789ab  250 MB  5       3 days ago  2 hours ago  minishift,other
+acme/foo:v1                             fedcba987654  1.5 MB  1       unknown     never        -
+`, out.String())
+}
+
+func Test_image_details_are_printed_as_json(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NoError(t, printImageDetails(testDetails, listFormatJson, nil, out, testNow))
+
+	var details []*image.ImageDetails
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &details))
+	assert.Equal(t, len(testDetails), len(details))
+	assert.Equal(t, "openshift/origin-control-plane:v3.11.0", details[0].Name)
+	assert.Equal(t, int64(250000000), details[0].Size)
+	assert.Contains(t, out.String(), `"lastUsed": "2018-07-10T10:00:00Z"`)
+}
+
+func Test_image_details_are_printed_with_template(t *testing.T) {
+	tmpl, err := template.New("list").Parse("{{.Name}} {{.Layers}}")
+	assert.NoError(t, err)
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, printImageDetails(testDetails, listFormatTemplate, tmpl, out, testNow))
+	assert.Equal(t, "openshift/origin-control-plane:v3.11.0 5\nacme/foo:v1 1\n", out.String())
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/machine/libmachine"
+	"github.com/minishift/minishift/cmd/minishift/cmd/addon"
+	"github.com/minishift/minishift/cmd/minishift/cmd/util"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
+	"github.com/minishift/minishift/pkg/minishift/oc"
+	"github.com/minishift/minishift/pkg/minishift/openshift"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pushToProjectFlag = "to-project"
+)
+
+var (
+	pushToProject string
+	pushName      string
+	pushToken     string
+	pushTLSVerify bool
+
+	imagePushCmd = &cobra.Command{
+		Use:   "push IMAGE",
+		Short: "Pushes a cached image or a local image archive into the OpenShift integrated registry.",
+		Long: `Pushes a cached image or a local image archive into the OpenShift integrated registry, creating an image stream tag in the specified project.
+IMAGE is either the name of a cached image or an archive prefixed with its format, eg docker-archive:/tmp/foo.tar or oci-archive:/tmp/foo.tar.
+The push is authenticated with the token of the user currently logged in with oc. To reach the registry from the host, enable the registry-route add-on.`,
+		Run: pushImage,
+	}
+)
+
+func pushImage(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		atexit.ExitWithMessage(1, "You need to specify the image to push.")
+	}
+	if pushToProject == "" {
+		atexit.ExitWithMessage(1, fmt.Sprintf("You need to specify the project to push the image to using --%s.", pushToProjectFlag))
+	}
+
+	api := libmachine.NewClient(state.InstanceDirs.Home, state.InstanceDirs.Certs)
+	defer api.Close()
+
+	util.ExitIfUndefined(api, constants.MachineName)
+
+	host, err := api.Load(constants.MachineName)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	util.ExitIfNotRunning(host.Driver, constants.MachineName)
+
+	source := args[0]
+	if !strings.HasPrefix(source, "docker-archive:") && !strings.HasPrefix(source, "oci-archive:") {
+		if source, err = normalizeImageName(source); err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Invalid image name '%s': %v", args[0], err))
+		}
+	}
+
+	registryRoute := addon.GetAddOnManager().Get("registry-route")
+	registryAddonEnabled := registryRoute != nil && registryRoute.IsEnabled()
+	registry, err := openshift.GetDockerRegistryInfo(registryAddonEnabled, minishiftConfig.InstanceStateConfig.OpenshiftVersion)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot determine the address of the registry: %v", err))
+	}
+
+	token := pushToken
+	if token == "" {
+		token = currentUserToken()
+	}
+
+	handler, err := image.NewLocalOnlyOciImageHandler()
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot create the image handler: %v", err))
+	}
+
+	options := &image.PushOptions{
+		Source:    source,
+		CacheDir:  state.InstanceDirs.ImageCache,
+		Registry:  registry,
+		Project:   pushToProject,
+		Name:      pushName,
+		Token:     token,
+		TLSVerify: pushTLSVerify,
+		Out:       os.Stdout,
+	}
+	target, err := handler.PushImage(options)
+	if err != nil {
+		if !registryAddonEnabled {
+			atexit.ExitWithMessage(1, fmt.Sprintf("%v\nIf the registry cannot be reached from the host, enable the registry-route add-on.", err))
+		}
+		atexit.ExitWithMessage(1, err.Error())
+	}
+
+	fmt.Println(fmt.Sprintf("Pushed '%s' to '%s'", args[0], target))
+}
+
+// currentUserToken returns the token of the user currently logged in with oc
+func currentUserToken() string {
+	ocRunner, err := oc.NewOcRunner(minishiftConfig.InstanceStateConfig.OcPath, constants.KubeConfigPath)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot determine the token of the current user: %v", err))
+	}
+
+	outBuffer, errBuffer := new(bytes.Buffer), new(bytes.Buffer)
+	if exitCode := ocRunner.RunAsUser("whoami -t", outBuffer, errBuffer); exitCode != 0 {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Cannot determine the token of the current user. Log in using 'oc login' or specify the token using --token: %s", errBuffer.String()))
+	}
+	return strings.TrimSpace(outBuffer.String())
+}
+
+func init() {
+	imagePushCmd.Flags().StringVar(&pushToProject, pushToProjectFlag, "", "The project to push the image to.")
+	imagePushCmd.Flags().StringVar(&pushName, "name", "", "The name and tag of the image stream tag, eg foo:v1. Defaults to the name and tag of the image.")
+	imagePushCmd.Flags().StringVar(&pushToken, "token", "", "The token to authenticate against the registry. Defaults to the token of the user currently logged in with oc.")
+	imagePushCmd.Flags().BoolVar(&pushTLSVerify, "tls-verify", false, "Verifies the TLS certificate of the registry. The registry uses a self-signed certificate by default.")
+	ImageCmd.AddCommand(imagePushCmd)
+}
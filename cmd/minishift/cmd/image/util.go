@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/containers/image/docker/reference"
 	"github.com/docker/machine/libmachine"
@@ -32,7 +33,9 @@ import (
 	"github.com/minishift/minishift/pkg/minishift/docker/image"
 	pkgUtil "github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/minishift/minishift/pkg/version"
 	"github.com/pkg/errors"
+	"github.com/spf13/viper"
 )
 
 func getCachedImages(cacheDir string) []string {
@@ -146,3 +149,39 @@ func deleteCachedImages(cacheDir string) error {
 	}
 	return nil
 }
+
+// recordProfileImages records the images needed by the current profile in the image cache. Recorded images are kept
+// by 'minishift image gc'.
+func recordProfileImages() {
+	if err := image.RecordProfileImages(state.InstanceDirs.ImageCache, constants.ProfileName, profileImages()); err != nil {
+		fmt.Println(fmt.Sprintf("WARN: Unable to record the cached images of profile '%s': %v", constants.ProfileName, err))
+	}
+}
+
+// profileImages returns the images needed by the current profile, ie its configured cache images and the images of its
+// OpenShift version.
+func profileImages() []string {
+	images := append([]string{}, viperConfig.InstanceConfig.CacheImages...)
+	return append(images, image.GetOpenShiftImageNames(profileOpenShiftVersion())...)
+}
+
+// profileOpenShiftVersion returns the OpenShift version the current profile runs. If the profile has not been started
+// since it was created or deleted, the configured version is returned.
+func profileOpenShiftVersion() string {
+	if viperConfig.InstanceStateConfig != nil && viperConfig.InstanceStateConfig.OpenshiftVersion != "" {
+		return viperConfig.InstanceStateConfig.OpenshiftVersion
+	}
+	return configuredOpenShiftVersion()
+}
+
+// configuredOpenShiftVersion returns the configured OpenShift version, or the default version if none is configured.
+func configuredOpenShiftVersion() string {
+	openShiftVersion := viper.GetString(config.OpenshiftVersion.Name)
+	if openShiftVersion == "" {
+		openShiftVersion = version.GetOpenShiftVersion()
+	}
+	if !strings.HasPrefix(openShiftVersion, constants.VersionPrefix) {
+		openShiftVersion = constants.VersionPrefix + openShiftVersion
+	}
+	return openShiftVersion
+}
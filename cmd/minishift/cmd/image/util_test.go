@@ -18,8 +18,13 @@ package image
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/minishift/minishift/cmd/minishift/cmd/config"
+	viperConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/version"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,3 +46,23 @@ func Test_normalize_image_names(t *testing.T) {
 		assert.Equal(t, normalizedImage, test.normalizedImage, fmt.Sprintf("Normalizing '%s' should have returned '%s', got '%s'", test.image, normalizedImage, test.normalizedImage))
 	}
 }
+
+func Test_profile_openshift_version_falls_back_to_configured_version(t *testing.T) {
+	instanceState := viperConfig.InstanceStateConfig
+	defer func() {
+		viperConfig.InstanceStateConfig = instanceState
+		viper.Reset()
+	}()
+
+	viperConfig.InstanceStateConfig = nil
+	assert.Equal(t, "v"+strings.TrimPrefix(version.GetOpenShiftVersion(), "v"), profileOpenShiftVersion())
+
+	viper.Set(config.OpenshiftVersion.Name, "3.10.0")
+	assert.Equal(t, "v3.10.0", profileOpenShiftVersion())
+
+	viperConfig.InstanceStateConfig = &viperConfig.InstanceStateConfigType{}
+	assert.Equal(t, "v3.10.0", profileOpenShiftVersion())
+
+	viperConfig.InstanceStateConfig.OpenshiftVersion = "v3.11.0"
+	assert.Equal(t, "v3.11.0", profileOpenShiftVersion())
+}
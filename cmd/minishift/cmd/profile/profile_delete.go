@@ -27,6 +27,7 @@ import (
 	cmdState "github.com/minishift/minishift/cmd/minishift/state"
 	"github.com/minishift/minishift/pkg/minikube/cluster"
 	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/docker/image"
 	profileActions "github.com/minishift/minishift/pkg/minishift/profile"
 	pkgUtil "github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -93,6 +94,11 @@ func runProfileDelete(cmd *cobra.Command, args []string) {
 		fmt.Println(fmt.Sprintf("Deleted: '%s'", profileDirs.Home))
 	}
 
+	// The image cache is shared by all profiles, only the references of the deleted profile are dropped
+	if err := image.RemoveProfileImages(profileDirs.ImageCache, profileName); err != nil {
+		fmt.Println(fmt.Sprintf("WARN: Unable to remove the cached image references of profile '%s': %v", profileName, err))
+	}
+
 	fmt.Println(fmt.Sprintf("Profile '%s' deleted successfully.", profileName))
 
 	// When active profile is deleted, reset the active profile to default profile
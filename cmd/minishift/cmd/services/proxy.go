@@ -0,0 +1,126 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	configCmd "github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/cmd/minishift/state"
+	"github.com/minishift/minishift/pkg/minishift/network/proxy"
+	minishiftTLS "github.com/minishift/minishift/pkg/minishift/tls"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	proxyLogLines     int
+	proxyCAOutputFile string
+
+	serviceProxyCmd = &cobra.Command{
+		Use:   "proxy SUBCOMMAND [flags]",
+		Short: "Inspect the local proxy service.",
+		Long:  "Inspect the local proxy service.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	serviceProxyLogCmd = &cobra.Command{
+		Use:   "log",
+		Short: "Show the recent requests handled by the local proxy.",
+		Long:  "Show the recent requests handled by the local proxy, including the requests denied by the allow and deny rules.",
+		Run:   runServiceProxyLog,
+	}
+
+	serviceProxyCACmd = &cobra.Command{
+		Use:   "ca SUBCOMMAND [flags]",
+		Short: "Manage the CA of the local proxy.",
+		Long:  "Manage the CA the local proxy uses to re-encrypt traffic. The CA is generated on first use.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	serviceProxyCAExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the CA certificate of the local proxy.",
+		Long:  "Export the CA certificate of the local proxy in PEM format, for example to trust it on the host.",
+		Run:   runServiceProxyCAExport,
+	}
+
+	serviceProxyCARotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "Replace the CA of the local proxy with a newly generated one.",
+		Long:  "Replace the CA of the local proxy with a newly generated one. The proxy service and the Minishift VM need to be restarted to use the new CA.",
+		Run:   runServiceProxyCARotate,
+	}
+)
+
+func init() {
+	serviceProxyLogCmd.Flags().IntVarP(&proxyLogLines, "lines", "n", 20, "The number of requests to show.")
+	serviceProxyCmd.AddCommand(serviceProxyLogCmd)
+	serviceProxyCAExportCmd.Flags().StringVarP(&proxyCAOutputFile, "output", "o", "", "The file to write the CA certificate to, instead of stdout.")
+	serviceProxyCACmd.AddCommand(serviceProxyCAExportCmd)
+	serviceProxyCACmd.AddCommand(serviceProxyCARotateCmd)
+	serviceProxyCmd.AddCommand(serviceProxyCACmd)
+	ServicesCmd.AddCommand(serviceProxyCmd)
+}
+
+func runServiceProxyLog(cmd *cobra.Command, args []string) {
+	entries, err := proxy.TailAccessLog(proxy.AccessLogFile(state.InstanceDirs.Logs), proxyLogLines)
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error reading the proxy access log: %s", err))
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No requests logged")
+		return
+	}
+	for _, entry := range entries {
+		fmt.Println(entry.String())
+	}
+}
+
+func runServiceProxyCAExport(cmd *cobra.Command, args []string) {
+	ca, err := minishiftTLS.ProxyCA(viper.GetBool(configCmd.LocalProxyLegacyCA.Name))
+	if err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error loading the proxy CA: %s", err))
+	}
+
+	if proxyCAOutputFile == "" {
+		fmt.Print(string(ca.Cert))
+		return
+	}
+	if err := ioutil.WriteFile(proxyCAOutputFile, ca.Cert, 0644); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error writing the proxy CA certificate: %s", err))
+	}
+}
+
+func runServiceProxyCARotate(cmd *cobra.Command, args []string) {
+	if _, err := minishiftTLS.RotateCA(minishiftTLS.CADir()); err != nil {
+		atexit.ExitWithMessage(1, fmt.Sprintf("Error generating a new proxy CA: %s", err))
+	}
+
+	fmt.Println(fmt.Sprintf("Generated a new proxy CA certificate '%s'.", minishiftTLS.CACertPath(minishiftTLS.CADir())))
+	if viper.GetBool(configCmd.LocalProxyLegacyCA.Name) {
+		fmt.Println(fmt.Sprintf("WARN: The new CA is not used as long as '%s' is set.", configCmd.LocalProxyLegacyCA.Name))
+	}
+	fmt.Println("Restart the proxy service and Minishift for the new CA to take effect.")
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package services
 
 import (
+	"fmt"
 	"runtime"
 
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
 	"github.com/minishift/minishift/pkg/minishift/network/proxy"
 	"github.com/minishift/minishift/pkg/minishift/systemtray"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -65,6 +68,14 @@ func runServiceStart(cmd *cobra.Command, args []string) {
 		atexit.ExitWithMessage(0, "Start functionality for SFTP daemon is not available")
 	case minishiftConstants.ProxyDaemon:
 		proxy.EnsureProxyDaemonRunning()
+	case minishiftConstants.HostFolderMonitorDaemon:
+		if err := hostfolder.EnsureMonitorRunning(); err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Error starting the host folder monitor: %s", err))
+		}
+	case minishiftConstants.DnsResolverDaemon:
+		if err := dns.EnsureResolverRunning(); err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Error starting the DNS resolver: %s", err))
+		}
 	default:
 		return
 	}
@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"runtime"
+
+	configCmd "github.com/minishift/minishift/cmd/minishift/cmd/config"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
+	"github.com/minishift/minishift/pkg/minishift/network/proxy"
+	"github.com/minishift/minishift/pkg/minishift/systemtray"
+	"github.com/minishift/minishift/pkg/util/os/atexit"
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	serviceStatusNotSpecifiedError = "You must specify a service to query (use 'minishift services list' to find available services)."
+)
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of a minishift service.",
+	Long:  "Show the status of a minishift service.",
+	Run:   runServiceStatus,
+}
+
+func init() {
+	ServicesCmd.AddCommand(daemonStatusCmd)
+}
+
+func runServiceStatus(cmd *cobra.Command, args []string) {
+	if len(args) <= 0 {
+		atexit.ExitWithMessage(1, serviceStatusNotSpecifiedError)
+	}
+	service := args[0]
+	if !minishiftStrings.Contains(minishiftConstants.ValidServices, service) {
+		atexit.ExitWithMessage(1, InvalidServiceNameError)
+	}
+
+	var pid int
+	switch service {
+	case minishiftConstants.SystemtrayDaemon:
+		if runtime.GOOS == "linux" {
+			atexit.ExitWithMessage(0, "System tray is not supported in Linux")
+		}
+		pid = systemtray.NewMinishiftTray(minishiftConfig.AllInstancesConfig).GetPID()
+	case minishiftConstants.SftpdDaemon:
+		atexit.ExitWithMessage(0, "Status functionality for SFTP daemon is not available")
+	case minishiftConstants.ProxyDaemon:
+		pid = proxy.GetPID()
+	case minishiftConstants.HostFolderMonitorDaemon:
+		pid = hostfolder.MonitorPID()
+	case minishiftConstants.DnsResolverDaemon:
+		pid = dns.ResolverPID()
+		if pid > 0 {
+			fmt.Println(fmt.Sprintf("%s: Running (PID %d, listening on %s)", service, pid, dns.ResolverAddress(viper.GetInt(configCmd.ServicesDnsPort.Name))))
+			return
+		}
+	default:
+		return
+	}
+
+	if pid > 0 {
+		fmt.Println(fmt.Sprintf("%s: Running (PID %d)", service, pid))
+	} else {
+		fmt.Println(fmt.Sprintf("%s: Stopped", service))
+	}
+}
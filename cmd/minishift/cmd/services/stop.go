@@ -23,6 +23,8 @@ import (
 
 	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
 	minishiftConstants "github.com/minishift/minishift/pkg/minishift/constants"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder"
+	"github.com/minishift/minishift/pkg/minishift/network/dns"
 	"github.com/minishift/minishift/pkg/minishift/network/proxy"
 	"github.com/minishift/minishift/pkg/minishift/systemtray"
 	"github.com/minishift/minishift/pkg/util/os/atexit"
@@ -83,6 +85,18 @@ func runServiceStop(cmd *cobra.Command, args []string) {
 			proc.Kill()
 			atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
 		}
+	case minishiftConstants.HostFolderMonitorDaemon:
+		if pid := hostfolder.MonitorPID(); pid > 0 {
+			proc, _ := os.FindProcess(pid)
+			proc.Kill()
+			atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
+		}
+	case minishiftConstants.DnsResolverDaemon:
+		if pid := dns.ResolverPID(); pid > 0 {
+			proc, _ := os.FindProcess(pid)
+			proc.Kill()
+			atexit.ExitWithMessage(0, fmt.Sprintf("Killed process with PID: %d\n", pid))
+		}
 	default:
 		return
 	}
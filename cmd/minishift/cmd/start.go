@@ -48,6 +48,7 @@ import (
 	"github.com/minishift/minishift/pkg/minishift/hostfolder"
 	minishiftNetwork "github.com/minishift/minishift/pkg/minishift/network"
 	minishiftProxy "github.com/minishift/minishift/pkg/minishift/network/proxy"
+	"github.com/minishift/minishift/pkg/minishift/oc"
 	"github.com/minishift/minishift/pkg/minishift/openshift"
 	profileActions "github.com/minishift/minishift/pkg/minishift/profile"
 	"github.com/minishift/minishift/pkg/minishift/provisioner"
@@ -68,6 +69,7 @@ const (
 	commandName             = "start"
 	defaultInsecureRegistry = "172.30.0.0/16"
 	genericDriver           = "generic"
+	forceAddOnsFlag         = "force-addons"
 )
 
 var (
@@ -145,6 +147,8 @@ For the latter see 'minishift config -h'.`,
 	startFlagSet = initStartFlags()
 	startCmd.Flags().AddFlagSet(startFlagSet)
 	startCmd.Flags().AddFlagSet(initSubscriptionManagerFlags())
+	// not part of startFlagSet, since it must not be persisted into the configuration
+	startCmd.Flags().Bool(forceAddOnsFlag, false, "Apply the enabled add-ons even if they are already applied successfully against the OpenShift cluster.")
 
 	viper.BindPFlags(startCmd.Flags())
 	RootCmd.AddCommand(startCmd)
@@ -215,7 +219,13 @@ func runStart(cmd *cobra.Command, args []string) {
 	// Forcibly set nameservers when configured
 	minishiftNetwork.AddNameserversToInstance(hostVm.Driver, getSlice(configCmd.NameServers.Name))
 	// to support intermediate proxy
-	minishiftTLS.SetCACertificate(hostVm.Driver)
+	if viper.GetBool(configCmd.LocalProxyReencrypt.Name) {
+		ca, err := minishiftTLS.ProxyCA(viper.GetBool(configCmd.LocalProxyLegacyCA.Name))
+		if err != nil {
+			atexit.ExitWithMessage(1, fmt.Sprintf("Error loading the proxy CA: %s", err))
+		}
+		minishiftTLS.SetCACertificate(hostVm.Driver, ca.Cert)
+	}
 
 	ip, _ := hostVm.Driver.GetIP()
 	localProxy := viper.GetBool(configCmd.LocalProxy.Name)
@@ -294,6 +304,7 @@ func runStart(cmd *cobra.Command, args []string) {
 			SSHCommander:         sshCommander,
 			OcBinaryPathInsideVM: fmt.Sprintf("%s/oc", minishiftConstants.OcPathInsideVM),
 			SshUser:              sshCommander.Driver.GetSSHUsername(),
+			ForceAddOns:          viper.GetBool(forceAddOnsFlag),
 		}
 
 		clusterUpParams := cmdUtil.DetermineClusterUpParameters(clusterUpConfig, strings.TrimSpace(dockerbridgeSubnet), clusterUpFlagSet)
@@ -338,6 +349,10 @@ func runStart(cmd *cobra.Command, args []string) {
 				atexit.ExitWithMessage(1, fmt.Sprintf("Could not set oc CLI context for '%s' profile: %v", profileActions.GetActiveProfile(), err))
 			}
 		}
+
+		if !viper.GetBool(configCmd.WriteConfig.Name) {
+			exposeHostFolders(hostVm.Driver)
+		}
 	}
 }
 
@@ -540,6 +555,31 @@ func autoMountHostFolders(driver drivers.Driver) {
 	if isAutoMount() && hostFolderManager.ExistAny() {
 		fmt.Println("-- Mounting host folders")
 		hostFolderManager.MountAll(driver)
+		if err := hostfolder.EnsureMonitorRunning(); err != nil {
+			fmt.Println(fmt.Sprintf("WARN: Unable to start the host folder monitor: %s", err))
+		}
+	}
+}
+
+// exposeHostFolders creates the persistent volumes of the mounted host folders which are exposed as persistent volumes.
+func exposeHostFolders(driver drivers.Driver) {
+	hostFolderManager, err := hostfolder.NewManager(minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig)
+	if err != nil {
+		atexit.ExitWithMessage(1, err.Error())
+	}
+	if !hostFolderManager.ExistAny() {
+		return
+	}
+
+	ocRunner, err := oc.NewOcRunner(minishiftConfig.InstanceStateConfig.OcPath, constants.KubeConfigPath)
+	if err != nil {
+		fmt.Println(fmt.Sprintf("WARN: Unable to expose host folders as persistent volumes: %s", err))
+		return
+	}
+	hostFolderManager.SetOcRunner(ocRunner)
+
+	if err := hostFolderManager.ExposeAll(driver); err != nil {
+		fmt.Println(fmt.Sprintf("WARN: Unable to expose host folders as persistent volumes: %s", err))
 	}
 }
 
@@ -578,11 +618,14 @@ func importContainerImages(driver drivers.Driver, api libmachine.API, openShiftV
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error determining Docker settings for image import: %v", err))
 	}
 
+	recordCachedImages(images)
+
 	handler := getImageHandler(driver, envMap)
 	config := &image.ImageCacheConfig{
 		HostCacheDir: state.InstanceDirs.ImageCache,
 		CachedImages: images,
 		Out:          os.Stdout,
+		Concurrency:  viper.GetInt(configCmd.ImageCacheConcurrency.Name),
 	}
 	_, err = handler.ImportImages(config)
 	if err != nil {
@@ -590,6 +633,14 @@ func importContainerImages(driver drivers.Driver, api libmachine.API, openShiftV
 	}
 }
 
+// recordCachedImages records the images needed by the current profile in the image cache, so that they are kept by
+// 'minishift image gc'
+func recordCachedImages(images []string) {
+	if err := image.RecordProfileImages(state.InstanceDirs.ImageCache, constants.ProfileName, images); err != nil {
+		fmt.Println(fmt.Sprintf("  WARN: Unable to record the cached images of profile '%s': %v", constants.ProfileName, err))
+	}
+}
+
 func getImageHandler(driver drivers.Driver, envMap map[string]string) image.ImageHandler {
 	handler, err := image.NewOciImageHandler(driver, envMap)
 	if err != nil {
@@ -617,6 +668,8 @@ func exportContainerImages(driver drivers.Driver, api libmachine.API, version st
 		atexit.ExitWithMessage(1, fmt.Sprintf("Error determining Docker settings for image import: %v", err))
 	}
 
+	recordCachedImages(images)
+
 	handler := getImageHandler(driver, envMap)
 	config := &image.ImageCacheConfig{
 		HostCacheDir: state.InstanceDirs.ImageCache,
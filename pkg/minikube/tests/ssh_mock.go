@@ -20,9 +20,11 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/binary"
 	"io"
 	"net"
 	"strconv"
+	"sync"
 
 	"fmt"
 
@@ -38,6 +40,12 @@ type SSHServer struct {
 	Transfers            *bytes.Buffer
 	HadASessionRequested bool
 	CommandToOutput      map[string]string
+	// CommandToExitStatus specifies the exit status of commands which should fail.
+	CommandToExitStatus map[string]uint32
+
+	// commandHistory stores the raw commands in the order they got executed against the server.
+	commandHistory []string
+	historyLock    sync.Mutex
 }
 
 // NewSSHServer returns a NewSSHServer instance, ready for use.
@@ -61,6 +69,13 @@ func NewSSHServer() (*SSHServer, error) {
 	return s, nil
 }
 
+// CommandHistory returns the raw commands in the order they got executed against the server.
+func (s *SSHServer) CommandHistory() []string {
+	s.historyLock.Lock()
+	defer s.historyLock.Unlock()
+	return append([]string(nil), s.commandHistory...)
+}
+
 type execRequest struct {
 	Command string
 }
@@ -109,12 +124,17 @@ func (s *SSHServer) Start() (int, error) {
 						return
 					}
 					s.Commands[cmd.Command] = 1
+					s.historyLock.Lock()
+					s.commandHistory = append(s.commandHistory, cmd.Command)
+					s.historyLock.Unlock()
 
 					// Write specified command output as mocked ssh output
 					if val, ok := s.CommandToOutput[cmd.Command]; ok {
 						channel.Write([]byte(val))
 					}
-					channel.SendRequest("exit-status", false, []byte{0, 0, 0, 0})
+					exitStatus := make([]byte, 4)
+					binary.BigEndian.PutUint32(exitStatus, s.CommandToExitStatus[cmd.Command])
+					channel.SendRequest("exit-status", false, exitStatus)
 
 					// Store anything that comes in over stdin.
 					io.Copy(s.Transfers, channel)
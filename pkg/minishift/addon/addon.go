@@ -169,3 +169,17 @@ func (a ByStatusThenPriorityThenName) Less(i, j int) bool {
 
 	return a[i].MetaData().Name() < a[j].MetaData().Name()
 }
+
+// ByPriorityThenName implements sort.Interface for []AddOn based primarily on the addon priority and secondarily
+// on the addon name.
+type ByPriorityThenName []AddOn
+
+func (a ByPriorityThenName) Len() int      { return len(a) }
+func (a ByPriorityThenName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByPriorityThenName) Less(i, j int) bool {
+	if a[i].GetPriority() != a[j].GetPriority() {
+		return a[i].GetPriority() < a[j].GetPriority()
+	}
+
+	return a[i].MetaData().Name() < a[j].MetaData().Name()
+}
@@ -24,7 +24,10 @@ import (
 	"strings"
 )
 
-var requiredMetaTags = []string{NameMetaTagName, DescriptionMetaTagName}
+var (
+	requiredMetaTags     = []string{NameMetaTagName, DescriptionMetaTagName}
+	dependencyNameRegexp = regexp.MustCompile(`^[a-zA-Z][-_a-zA-Z0-9]*$`)
+)
 
 const (
 	requiredVars             = "Required-Vars"
@@ -32,12 +35,27 @@ const (
 	DescriptionMetaTagName   = "Description"
 	RequiredOpenShiftVersion = "OpenShift-Version"
 	RequiredMinishiftVersion = "Minishift-Version"
+	AddOnVersionMetaTagName  = "Addon-Version"
 	anyOpenShiftVersion      = ""
 	anyMinishiftVersion      = ""
 	varDefaults              = "Var-Defaults"
 	dependsOn                = "Depends-On"
 )
 
+// knownMetaTags contains all meta tags which have a meaning for Minishift.
+var knownMetaTags = []string{NameMetaTagName, DescriptionMetaTagName, requiredVars, varDefaults, RequiredOpenShiftVersion,
+	RequiredMinishiftVersion, AddOnVersionMetaTagName, dependsOn, VariableMetaTagName, "url", "URL", "Url"}
+
+// IsKnownMetaTag returns true if the specified meta tag name is understood by Minishift, false otherwise.
+func IsKnownMetaTag(name string) bool {
+	for _, tag := range knownMetaTags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
 type RequiredVar struct {
 	Key   string
 	Value string
@@ -53,9 +71,25 @@ type AddOnMeta interface {
 	OpenShiftVersion() string
 	MinishiftVersion() string
 	Dependency() ([]string, error)
+	Dependencies() ([]*Dependency, error)
+	Version() string
+	Variables() ([]*Variable, error)
 	Url() string
 }
 
+// Dependency is a dependency on another add-on, optionally restricted to a range of add-on versions.
+type Dependency struct {
+	Name         string
+	VersionRange *VersionRange // nil if any version is accepted
+}
+
+func (d *Dependency) String() string {
+	if d.VersionRange == nil {
+		return d.Name
+	}
+	return fmt.Sprintf("%s %s", d.Name, d.VersionRange)
+}
+
 type DefaultAddOnMeta struct {
 	headers map[string]interface{}
 }
@@ -73,8 +107,14 @@ func NewAddOnMeta(headers map[string]interface{}) (AddOnMeta, error) {
 	if err := varDefaultsCheck(headers); err != nil {
 		return nil, err
 	}
+	if err := addOnVersionCheck(headers); err != nil {
+		return nil, err
+	}
+	if _, err := parseVariables(headers); err != nil {
+		return nil, err
+	}
 	if !checkDependencySemantic(headers) {
-		return nil, fmt.Errorf("The Dependencies should be a comma seperated list of Add-ons.")
+		return nil, fmt.Errorf("The Dependencies should be a comma seperated list of Add-ons with optional version constraints, eg. 'foo >= 1.2, bar'.")
 	}
 
 	metaData := &DefaultAddOnMeta{headers: headers}
@@ -152,28 +192,129 @@ func (meta *DefaultAddOnMeta) MinishiftVersion() string {
 	return anyMinishiftVersion
 }
 
+// Dependency returns the names of the add-ons this add-on depends on.
 func (meta *DefaultAddOnMeta) Dependency() ([]string, error) {
+	dependencies, err := meta.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		names = append(names, dependency.Name)
+	}
+	return names, nil
+}
+
+// Dependencies returns the add-ons this add-on depends on together with their required version ranges.
+func (meta *DefaultAddOnMeta) Dependencies() ([]*Dependency, error) {
 	if val, contains := meta.headers[dependsOn].(string); contains {
-		return minishiftStrings.SplitAndTrim(val, ",")
+		return parseDependencies(val)
 	}
-	return []string{}, nil
+	return []*Dependency{}, nil
+}
+
+// Version returns the version of the add-on. The empty string is returned if the add-on does not specify a version.
+func (meta *DefaultAddOnMeta) Version() string {
+	if val, contains := meta.headers[AddOnVersionMetaTagName].(string); contains {
+		return strings.TrimSpace(val)
+	}
+	return ""
+}
+
+// Variables returns the variables declared by the add-on using 'Var' headers.
+func (meta *DefaultAddOnMeta) Variables() ([]*Variable, error) {
+	return parseVariables(meta.headers)
+}
+
+// parseVariables parses the variable declarations of the headers. A single declaration is stored as string, multiple
+// declarations as slice of strings.
+func parseVariables(headers map[string]interface{}) ([]*Variable, error) {
+	var declarations []string
+	switch v := headers[VariableMetaTagName].(type) {
+	case string:
+		declarations = []string{v}
+	case []string:
+		declarations = v
+	}
+
+	variables := make([]*Variable, 0, len(declarations))
+	declared := make(map[string]bool)
+	for _, declaration := range declarations {
+		variable, err := ParseVariable(declaration)
+		if err != nil {
+			return nil, err
+		}
+		if declared[variable.Name] {
+			return nil, errors.New(fmt.Sprintf("Variable '%s' is declared more than once", variable.Name))
+		}
+		declared[variable.Name] = true
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+// parseDependencies parses a comma separated list of add-on names, each optionally followed by a version range,
+// eg 'foo >= 1.2, < 2.0, bar'. Items starting with a version or comparison operator continue the version range of
+// the previous add-on.
+func parseDependencies(value string) ([]*Dependency, error) {
+	var names, ranges []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, errors.New(fmt.Sprintf("Empty dependency in '%s'", value))
+		}
+
+		if strings.ContainsAny(item[:1], "<>=!0123456789") {
+			if len(names) == 0 {
+				return nil, errors.New(fmt.Sprintf("Version constraint '%s' does not follow an add-on name", item))
+			}
+			last := len(ranges) - 1
+			if ranges[last] == "" {
+				ranges[last] = item
+			} else {
+				ranges[last] = ranges[last] + ", " + item
+			}
+			continue
+		}
+
+		fields := strings.Fields(item)
+		if !dependencyNameRegexp.MatchString(fields[0]) {
+			return nil, errors.New(fmt.Sprintf("'%s' is not a valid add-on name", fields[0]))
+		}
+		names = append(names, fields[0])
+		ranges = append(ranges, strings.TrimSpace(strings.TrimPrefix(item, fields[0])))
+	}
+
+	dependencies := make([]*Dependency, 0, len(names))
+	for i, name := range names {
+		dependency := &Dependency{Name: name}
+		if ranges[i] != "" {
+			versionRange, err := ParseVersionRange(ranges[i])
+			if err != nil {
+				return nil, err
+			}
+			dependency.VersionRange = versionRange
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, nil
 }
 
 func checkDependencySemantic(headers map[string]interface{}) bool {
-	// Comma seperated list of dependencies
+	// Comma seperated list of dependencies with optional version ranges
 	if headers[dependsOn] != nil {
-		dependencies := headers[dependsOn].(string)
-		match, _ := regexp.MatchString("^(([a-zA-Z][-[a-zA-Z]*)([,][ ]?[a-zA-Z][-[a-zA-Z]*)*)$", dependencies)
-		return match
+		_, err := parseDependencies(headers[dependsOn].(string))
+		return err == nil
 	}
 	return true
 }
 
 func checkVersionSemantic(version string) bool {
-	// Strict match for <major> or <major>.<minor> or <major>.<minor>.<patch>
-	// (>=|>|<|<=)3.6.0, (>=|>|<|<=)3.6.0
-	match, _ := regexp.MatchString("^(|>|>=|<|<=)[0-9]+(.[0-9]+){0,2}(|\\s*,\\s*(|>|>=|<|<=)[0-9]+(.[0-9]+){0,2})$", version)
-	return match
+	// <major> or <major>.<minor> or <major>.<minor>.<patch> with optional comparison operators,
+	// eg 3.6.0, >=3.6, <3.10 or 3.6 || >=3.9
+	_, err := ParseVersionRange(version)
+	return err == nil
 }
 
 func requiredMetaTagsCheck(headers map[string]interface{}) error {
@@ -217,6 +358,16 @@ func requiredMinishiftVersionCheck(headers map[string]interface{}) error {
 	return nil
 }
 
+func addOnVersionCheck(headers map[string]interface{}) error {
+	if val, contains := headers[AddOnVersionMetaTagName].(string); contains {
+		if _, err := ParseVersion(val); err != nil {
+			return errors.New(fmt.Sprintf("Add-on version '%s' is not a valid semantic version (eg. 1.2.0)", val))
+		}
+	}
+
+	return nil
+}
+
 func varDefaultsCheck(headers map[string]interface{}) error {
 	if val, contains := headers[varDefaults].(string); contains {
 		items, err := minishiftStrings.SplitAndTrim(val, ",")
@@ -189,9 +189,57 @@ func Test_check_openshift_version_sematic(t *testing.T) {
 		{">=3.5.0, <=3.7", true},
 		{"v3", false},
 		{"p3.6.0", false},
+		{">=3.5, <3.7, !=3.6.1", true},
+		{">= 3.5 < 3.7", true},
+		{"3.6 || >=3.9", true},
+		{"3.6 ||", false},
+		{">=", false},
 	}
 
 	for _, versionTest := range versionTestData {
 		assert.Equal(t, versionTest.expectedResult, checkVersionSemantic(versionTest.OpenshiftVersion))
 	}
 }
+
+func Test_dependencies_with_version_ranges(t *testing.T) {
+	testMap := map[string]interface{}{"Name": "foo", "Description": []string{"bar"}, "Depends-On": "anyuid >= 1.2, < 2, admin-user, xpaas 1.0 || >=1.4"}
+	addOnMeta := getAddOnMeta(testMap, t)
+
+	dependencies, err := addOnMeta.Dependencies()
+	assert.NoError(t, err)
+	assert.Len(t, dependencies, 3)
+	assert.Equal(t, "anyuid >= 1.2, < 2", dependencies[0].String())
+	assert.Nil(t, dependencies[1].VersionRange)
+	assert.Equal(t, "admin-user", dependencies[1].String())
+	assert.Equal(t, "xpaas 1.0 || >=1.4", dependencies[2].String())
+
+	names, err := addOnMeta.Dependency()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"anyuid", "admin-user", "xpaas"}, names)
+}
+
+func Test_invalid_dependencies_return_error(t *testing.T) {
+	for _, dependsOn := range []string{">= 1.2, anyuid", "anyuid >= v1", "anyuid,,admin-user", "any#uid"} {
+		testMap := map[string]interface{}{"Name": "foo", "Description": []string{"bar"}, "Depends-On": dependsOn}
+		_, err := NewAddOnMeta(testMap)
+		assert.Error(t, err, "Expected error for dependencies '%s'", dependsOn)
+	}
+}
+
+func Test_addon_version(t *testing.T) {
+	testMap := map[string]interface{}{"Name": "foo", "Description": []string{"bar"}}
+	assert.Equal(t, "", getAddOnMeta(testMap, t).Version())
+
+	testMap["Addon-Version"] = "1.2.0"
+	assert.Equal(t, "1.2.0", getAddOnMeta(testMap, t).Version())
+
+	testMap["Addon-Version"] = "latest"
+	_, err := NewAddOnMeta(testMap)
+	assert.EqualError(t, err, "Add-on version 'latest' is not a valid semantic version (eg. 1.2.0)")
+}
+
+func Test_duplicate_variable_declaration_returns_error(t *testing.T) {
+	testMap := map[string]interface{}{"Name": "foo", "Description": []string{"bar"}, "Var": []string{"FOO", "FOO; type=int"}}
+	_, err := NewAddOnMeta(testMap)
+	assert.EqualError(t, err, "Variable 'FOO' is declared more than once")
+}
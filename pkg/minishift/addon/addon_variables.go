@@ -0,0 +1,172 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+const (
+	VariableMetaTagName = "Var"
+
+	StringVariable = "string"
+	IntVariable    = "int"
+	PortVariable   = "port"
+	BoolVariable   = "bool"
+	EnumVariable   = "enum"
+
+	variableAttributeSeparator = ";"
+	enumValueSeparator         = "|"
+)
+
+var (
+	variableTypes        = []string{StringVariable, IntVariable, PortVariable, BoolVariable, EnumVariable}
+	variableNameRegexp   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+	booleanVariableValue = map[string]bool{"true": true, "false": true, "yes": true, "no": true, "1": true, "0": true}
+)
+
+// Variable is a variable declared by an add-on using the 'Var' header, eg
+//
+//	# Var: DB_PORT; type=port; default=5432; description=Port of the database
+//
+// Besides the name, a declaration can contain the attributes type, description, default, values (the '|' separated
+// allowed values of an enum), pattern (a regular expression the value needs to match) and the flag secret.
+// A variable without default value is required.
+type Variable struct {
+	Name        string
+	Type        string
+	Description string
+	Default     string
+	HasDefault  bool
+	Values      []string
+	Pattern     *regexp.Regexp
+	Secret      bool
+}
+
+// IsRequired returns true if a value for the variable needs to be provided, ie if it does not declare a default value.
+func (v *Variable) IsRequired() bool {
+	return !v.HasDefault
+}
+
+// Validate returns an error if the specified value does not match the type, allowed values or pattern of the variable.
+func (v *Variable) Validate(value string) error {
+	switch v.Type {
+	case IntVariable:
+		if _, err := strconv.Atoi(value); err != nil {
+			return v.invalidValueError(value, "an integer")
+		}
+	case PortVariable:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return v.invalidValueError(value, "a port between 1 and 65535")
+		}
+	case BoolVariable:
+		if !booleanVariableValue[strings.ToLower(value)] {
+			return v.invalidValueError(value, "one of true, false, yes, no, 1 or 0")
+		}
+	case EnumVariable:
+		if !minishiftStrings.Contains(v.Values, value) {
+			return v.invalidValueError(value, fmt.Sprintf("one of %s", strings.Join(v.Values, ", ")))
+		}
+	}
+
+	if v.Pattern != nil && !v.Pattern.MatchString(value) {
+		return v.invalidValueError(value, fmt.Sprintf("matching '%s'", v.Pattern))
+	}
+	return nil
+}
+
+func (v *Variable) invalidValueError(value string, expected string) error {
+	if v.Secret {
+		value = "********"
+	}
+	return errors.New(fmt.Sprintf("Invalid value '%s' for variable '%s', expected %s", value, v.Name, expected))
+}
+
+// ParseVariable parses a variable declaration of the form 'NAME; key=value; ...; flag'.
+func ParseVariable(declaration string) (*Variable, error) {
+	items := strings.Split(declaration, variableAttributeSeparator)
+	variable := &Variable{Name: strings.TrimSpace(items[0]), Type: StringVariable}
+	if !variableNameRegexp.MatchString(variable.Name) {
+		return nil, errors.New(fmt.Sprintf("'%s' is not a valid variable name", variable.Name))
+	}
+
+	var pattern string
+	for _, item := range items[1:] {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if item == "secret" {
+			variable.Secret = true
+			continue
+		}
+
+		attribute := strings.SplitN(item, "=", 2)
+		if len(attribute) != 2 {
+			return nil, errors.New(fmt.Sprintf("Unknown attribute '%s' in declaration of variable '%s'", item, variable.Name))
+		}
+		key, value := strings.TrimSpace(attribute[0]), strings.TrimSpace(attribute[1])
+		switch key {
+		case "type":
+			variable.Type = strings.ToLower(value)
+		case "description":
+			variable.Description = value
+		case "default":
+			variable.Default = value
+			variable.HasDefault = true
+		case "values":
+			for _, allowed := range strings.Split(value, enumValueSeparator) {
+				variable.Values = append(variable.Values, strings.TrimSpace(allowed))
+			}
+		case "pattern":
+			pattern = value
+		default:
+			return nil, errors.New(fmt.Sprintf("Unknown attribute '%s' in declaration of variable '%s'", key, variable.Name))
+		}
+	}
+
+	if !minishiftStrings.Contains(variableTypes, variable.Type) {
+		return nil, errors.New(fmt.Sprintf("Unknown type '%s' of variable '%s'. Supported types are %s", variable.Type, variable.Name, strings.Join(variableTypes, ", ")))
+	}
+	if variable.Type == EnumVariable && len(variable.Values) == 0 {
+		return nil, errors.New(fmt.Sprintf("Variable '%s' of type enum needs to specify its allowed values", variable.Name))
+	}
+	if variable.Type != EnumVariable && len(variable.Values) > 0 {
+		return nil, errors.New(fmt.Sprintf("Allowed values can only be specified for variables of type enum, but variable '%s' is of type %s", variable.Name, variable.Type))
+	}
+	if pattern != "" {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid pattern '%s' of variable '%s': %s", pattern, variable.Name, err.Error()))
+		}
+		variable.Pattern = regex
+	}
+	if variable.HasDefault {
+		if err := variable.Validate(variable.Default); err != nil {
+			return nil, err
+		}
+	}
+
+	return variable, nil
+}
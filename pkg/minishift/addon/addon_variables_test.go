@@ -0,0 +1,96 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parse_variable_declaration(t *testing.T) {
+	variable, err := ParseVariable("MODE; type=enum; values=dev | prod; default=dev; description=Deployment mode")
+	assert.NoError(t, err)
+	assert.Equal(t, "MODE", variable.Name)
+	assert.Equal(t, EnumVariable, variable.Type)
+	assert.Equal(t, []string{"dev", "prod"}, variable.Values)
+	assert.Equal(t, "dev", variable.Default)
+	assert.Equal(t, "Deployment mode", variable.Description)
+	assert.False(t, variable.IsRequired())
+	assert.False(t, variable.Secret)
+
+	variable, err = ParseVariable("TOKEN; secret; pattern=^[a-f0-9]+$")
+	assert.NoError(t, err)
+	assert.Equal(t, StringVariable, variable.Type)
+	assert.True(t, variable.Secret)
+	assert.True(t, variable.IsRequired())
+	assert.NotNil(t, variable.Pattern)
+}
+
+func Test_invalid_variable_declarations_return_error(t *testing.T) {
+	var testCases = []struct {
+		declaration string
+		expectedErr string
+	}{
+		{"1FOO", "'1FOO' is not a valid variable name"},
+		{"FOO; type=float", "Unknown type 'float' of variable 'FOO'. Supported types are string, int, port, bool, enum"},
+		{"FOO; type=enum", "Variable 'FOO' of type enum needs to specify its allowed values"},
+		{"FOO; values=a|b", "Allowed values can only be specified for variables of type enum, but variable 'FOO' is of type string"},
+		{"FOO; color=red", "Unknown attribute 'color' in declaration of variable 'FOO'"},
+		{"FOO; hidden", "Unknown attribute 'hidden' in declaration of variable 'FOO'"},
+		{"FOO; pattern=[", "Invalid pattern '[' of variable 'FOO': error parsing regexp: missing closing ]: `[`"},
+		{"FOO; type=port; default=70000", "Invalid value '70000' for variable 'FOO', expected a port between 1 and 65535"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseVariable(testCase.declaration)
+		assert.EqualError(t, err, testCase.expectedErr)
+	}
+}
+
+func Test_validate_variable_value(t *testing.T) {
+	var testCases = []struct {
+		declaration string
+		value       string
+		valid       bool
+	}{
+		{"FOO", "anything", true},
+		{"FOO; type=int", "-12", true},
+		{"FOO; type=int", "twelve", false},
+		{"FOO; type=port", "8080", true},
+		{"FOO; type=port", "0", false},
+		{"FOO; type=bool", "Yes", true},
+		{"FOO; type=bool", "maybe", false},
+		{"FOO; type=enum; values=dev|prod", "prod", true},
+		{"FOO; type=enum; values=dev|prod", "test", false},
+		{"FOO; pattern=^v[0-9]+$", "v3", true},
+		{"FOO; pattern=^v[0-9]+$", "3", false},
+	}
+
+	for _, testCase := range testCases {
+		variable, err := ParseVariable(testCase.declaration)
+		assert.NoError(t, err)
+		err = variable.Validate(testCase.value)
+		assert.Equal(t, testCase.valid, err == nil, "Unexpected validation result for '%s' of '%s'", testCase.value, testCase.declaration)
+	}
+}
+
+func Test_invalid_secret_value_is_masked(t *testing.T) {
+	variable, err := ParseVariable("PIN; type=int; secret")
+	assert.NoError(t, err)
+	assert.EqualError(t, variable.Validate("abcd"), "Invalid value '********' for variable 'PIN', expected an integer")
+}
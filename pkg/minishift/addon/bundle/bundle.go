@@ -0,0 +1,255 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bundle creates and verifies add-on bundles. A bundle is a gzip compressed tar archive containing a single
+// add-on directory together with a manifest, which records the name and version of the add-on as well as the SHA-256
+// digest of each file. A bundle can be accompanied by a detached signature, stored next to the bundle with the suffix
+// '.sig'.
+package bundle
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/parser"
+	"github.com/minishift/minishift/pkg/util"
+	"github.com/minishift/minishift/pkg/util/archive"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManifestFileName is the name of the manifest in the root of a bundle
+	ManifestFileName = "addon-manifest.json"
+	// SignatureSuffix is the suffix of the detached signature of a bundle
+	SignatureSuffix = ".sig"
+	// Extension is the file extension of a bundle
+	Extension = ".tar.gz"
+
+	formatVersion = 1
+)
+
+// files of an add-on directory which are not bundled
+var excludedFiles = map[string]bool{".addon-origin.json": true, ".git": true}
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	FormatVersion int           `json:"formatVersion"`
+	Name          string        `json:"name"`
+	Version       string        `json:"version"`
+	Files         []*FileDigest `json:"files"`
+}
+
+// FileDigest records the SHA-256 digest of a file of the bundle. The path is relative to the add-on directory.
+type FileDigest struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Create packages the add-on in addOnDir into a bundle named <name>-<version>.tar.gz within outputDir. If signingKey
+// is not nil, a detached signature of the bundle is created as well. The path of the bundle is returned.
+func Create(addOnDir string, outputDir string, signingKey crypto.Signer) (string, error) {
+	addOn, err := parser.NewAddOnParser().Parse(addOnDir)
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to parse specified addon")
+	}
+	name := addOn.MetaData().Name()
+	version := addOn.MetaData().Version()
+	if version == "" {
+		return "", errors.New(fmt.Sprintf("Add-on '%s' needs to specify an Addon-Version to be packaged", name))
+	}
+
+	manifest := &Manifest{FormatVersion: formatVersion, Name: name, Version: version}
+	entries := []archive.Entry{}
+	err = filepath.Walk(addOnDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if excludedFiles[info.Name()] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return errors.New(fmt.Sprintf("Unable to package '%s'. Only regular files can be bundled", path))
+		}
+
+		relativePath, err := filepath.Rel(addOnDir, path)
+		if err != nil {
+			return err
+		}
+		digest, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		manifest.Files = append(manifest.Files, &FileDigest{Path: relativePath, SHA256: digest})
+		entries = append(entries, archive.Entry{Name: name + "/" + relativePath, Path: path})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	stagingDir, err := ioutil.TempDir("", "minishift-addon-bundle-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	manifestPath := filepath.Join(stagingDir, ManifestFileName)
+	if err := writeManifest(manifestPath, manifest); err != nil {
+		return "", err
+	}
+	entries = append([]archive.Entry{{Name: ManifestFileName, Path: manifestPath}}, entries...)
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+	bundlePath := filepath.Join(outputDir, fmt.Sprintf("%s-%s%s", name, version, Extension))
+	if err := archive.TarGz(bundlePath, entries); err != nil {
+		return "", errors.Wrapf(err, "Unable to create bundle '%s'", bundlePath)
+	}
+
+	if signingKey != nil {
+		if err := signBundle(bundlePath, signingKey); err != nil {
+			os.Remove(bundlePath)
+			return "", err
+		}
+	}
+	return bundlePath, nil
+}
+
+// IsBundle returns true if the extracted archive in extractDir is a bundle, ie contains a manifest.
+func IsBundle(extractDir string) bool {
+	return filehelper.Exists(filepath.Join(extractDir, ManifestFileName))
+}
+
+// VerifyDigests verifies that the extracted bundle in extractDir contains exactly the files listed in its manifest
+// and that their content matches the recorded digests. The manifest is returned. The add-on itself is located in the
+// sub directory of extractDir named after the add-on.
+func VerifyDigests(extractDir string) (*Manifest, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(extractDir, ManifestFileName))
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read the bundle manifest")
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(raw, manifest); err != nil {
+		return nil, errors.Wrap(err, "Unable to parse the bundle manifest")
+	}
+	if manifest.FormatVersion != formatVersion {
+		return nil, errors.New(fmt.Sprintf("Unsupported bundle format version %d", manifest.FormatVersion))
+	}
+
+	if manifest.Name == "" || filepath.Base(manifest.Name) != manifest.Name {
+		return nil, errors.New(fmt.Sprintf("Invalid add-on name '%s' in the bundle manifest", manifest.Name))
+	}
+	topLevel, err := ioutil.ReadDir(extractDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range topLevel {
+		if entry.Name() != ManifestFileName && entry.Name() != manifest.Name {
+			return nil, errors.New(fmt.Sprintf("Bundle '%s' contains files which are not listed in its manifest: %s", manifest.Name, entry.Name()))
+		}
+	}
+
+	addOnDir := filepath.Join(extractDir, manifest.Name)
+	expected := make(map[string]string)
+	for _, file := range manifest.Files {
+		expected[file.Path] = file.SHA256
+	}
+
+	var unexpected []string
+	err = filepath.Walk(addOnDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(addOnDir, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		digest, listed := expected[relativePath]
+		if !listed {
+			unexpected = append(unexpected, relativePath)
+			return nil
+		}
+		delete(expected, relativePath)
+
+		actual, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+		if actual != digest {
+			return errors.New(fmt.Sprintf("Digest mismatch for file '%s' of bundle '%s'. Expected %s, got %s", relativePath, manifest.Name, digest, actual))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(unexpected) > 0 {
+		sort.Strings(unexpected)
+		return nil, errors.New(fmt.Sprintf("Bundle '%s' contains files which are not listed in its manifest: %s", manifest.Name, strings.Join(unexpected, ", ")))
+	}
+	if len(expected) > 0 {
+		var missing []string
+		for path := range expected {
+			missing = append(missing, path)
+		}
+		sort.Strings(missing)
+		return nil, errors.New(fmt.Sprintf("Bundle '%s' is missing files listed in its manifest: %s", manifest.Name, strings.Join(missing, ", ")))
+	}
+
+	return manifest, nil
+}
+
+func writeManifest(path string, manifest *Manifest) error {
+	jsonData, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, jsonData, 0644)
+}
+
+func fileDigest(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	checksum, err := util.ChecksumFor(crypto.SHA256, content)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(checksum), nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/util/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+var anyuid = `# Name: anyuid
+# Addon-Version: 1.2.0
+# Description: Allows authenticated users to run images to run with USER as per Dockerfile
+
+oc adm policy add-scc-to-group anyuid system:authenticated
+`
+
+func Test_create_and_verify_bundle(t *testing.T) {
+	testDir := createTestDir(t)
+	defer os.RemoveAll(testDir)
+
+	bundlePath, err := Create(writeAddOn(t, testDir), filepath.Join(testDir, "out"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(testDir, "out", "anyuid-1.2.0.tar.gz"), bundlePath)
+	assert.False(t, fileExists(bundlePath+SignatureSuffix))
+
+	extractDir := extract(t, bundlePath)
+	assert.True(t, IsBundle(extractDir))
+	manifest, err := VerifyDigests(extractDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "anyuid", manifest.Name)
+	assert.Equal(t, "1.2.0", manifest.Version)
+	assert.Len(t, manifest.Files, 2)
+	assert.Equal(t, "anyuid.addon", manifest.Files[0].Path)
+	assert.Equal(t, "templates/scc.yml", manifest.Files[1].Path)
+}
+
+func Test_packaging_requires_addon_version(t *testing.T) {
+	testDir := createTestDir(t)
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "foo")
+	os.MkdirAll(addOnDir, 0755)
+	err := ioutil.WriteFile(filepath.Join(addOnDir, "foo.addon"), []byte("# Name: foo\n# Description: bar\n\necho foo\n"), 0644)
+	assert.NoError(t, err)
+
+	_, err = Create(addOnDir, testDir, nil)
+	assert.EqualError(t, err, "Add-on 'foo' needs to specify an Addon-Version to be packaged")
+}
+
+func Test_modified_bundle_fails_verification(t *testing.T) {
+	testDir := createTestDir(t)
+	defer os.RemoveAll(testDir)
+
+	bundlePath, err := Create(writeAddOn(t, testDir), testDir, nil)
+	assert.NoError(t, err)
+
+	extractDir := extract(t, bundlePath)
+	err = ioutil.WriteFile(filepath.Join(extractDir, "anyuid", "anyuid.addon"), []byte("ssh sudo rm -rf /\n"), 0644)
+	assert.NoError(t, err)
+	_, err = VerifyDigests(extractDir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Digest mismatch for file 'anyuid.addon' of bundle 'anyuid'")
+
+	extractDir = extract(t, bundlePath)
+	err = ioutil.WriteFile(filepath.Join(extractDir, "anyuid", "extra.sh"), []byte("echo extra\n"), 0644)
+	assert.NoError(t, err)
+	_, err = VerifyDigests(extractDir)
+	assert.EqualError(t, err, "Bundle 'anyuid' contains files which are not listed in its manifest: extra.sh")
+
+	extractDir = extract(t, bundlePath)
+	err = os.Remove(filepath.Join(extractDir, "anyuid", "templates", "scc.yml"))
+	assert.NoError(t, err)
+	_, err = VerifyDigests(extractDir)
+	assert.EqualError(t, err, "Bundle 'anyuid' is missing files listed in its manifest: templates/scc.yml")
+}
+
+func Test_signed_bundle_is_verified_against_trusted_key(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rsaPrivateDER := x509.MarshalPKCS1PrivateKey(rsaKey)
+	ecdsaPrivateDER, err := x509.MarshalECPrivateKey(ecdsaKey)
+	assert.NoError(t, err)
+
+	var testCases = []struct {
+		name       string
+		privateDER []byte
+		publicKey  interface{}
+		otherKey   interface{}
+	}{
+		{"rsa", rsaPrivateDER, &rsaKey.PublicKey, &ecdsaKey.PublicKey},
+		{"ecdsa", ecdsaPrivateDER, &ecdsaKey.PublicKey, &rsaKey.PublicKey},
+	}
+
+	for _, testCase := range testCases {
+		testDir := createTestDir(t)
+		defer os.RemoveAll(testDir)
+
+		privateKeyPath := filepath.Join(testDir, testCase.name+".pem")
+		writePEM(t, privateKeyPath, "PRIVATE KEY", testCase.privateDER)
+		publicDER, err := x509.MarshalPKIXPublicKey(testCase.publicKey)
+		assert.NoError(t, err)
+		publicKeyPath := filepath.Join(testDir, testCase.name+".pub")
+		writePEM(t, publicKeyPath, "PUBLIC KEY", publicDER)
+
+		signingKey, err := ReadPrivateKey(privateKeyPath)
+		assert.NoError(t, err)
+		trustedKey, err := ReadPublicKey(publicKeyPath)
+		assert.NoError(t, err)
+
+		bundlePath, err := Create(writeAddOn(t, testDir), testDir, signingKey)
+		assert.NoError(t, err)
+		signature, err := ioutil.ReadFile(bundlePath + SignatureSuffix)
+		assert.NoError(t, err)
+
+		assert.NoError(t, VerifySignature(bundlePath, signature, trustedKey))
+		assert.EqualError(t, VerifySignature(bundlePath, signature, testCase.otherKey),
+			"The signature of bundle '"+bundlePath+"' does not match the trusted key")
+	}
+}
+
+func createTestDir(t *testing.T) string {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-bundle-")
+	assert.NoError(t, err, "Error creating temp directory")
+	return testDir
+}
+
+// writeAddOn writes the anyuid add-on together with a template and its origin file into dir and returns the add-on directory
+func writeAddOn(t *testing.T, dir string) string {
+	addOnDir := filepath.Join(dir, "anyuid")
+	err := os.MkdirAll(filepath.Join(addOnDir, "templates"), 0755)
+	assert.NoError(t, err, "Error creating add-on directory")
+	err = ioutil.WriteFile(filepath.Join(addOnDir, "anyuid.addon"), []byte(anyuid), 0644)
+	assert.NoError(t, err, "Error writing add-on file")
+	err = ioutil.WriteFile(filepath.Join(addOnDir, "templates", "scc.yml"), []byte("kind: SecurityContextConstraints\n"), 0644)
+	assert.NoError(t, err, "Error writing template file")
+	err = ioutil.WriteFile(filepath.Join(addOnDir, ".addon-origin.json"), []byte("{}"), 0644)
+	assert.NoError(t, err, "Error writing origin file")
+	return addOnDir
+}
+
+func extract(t *testing.T, bundlePath string) string {
+	extractDir, err := ioutil.TempDir(filepath.Dir(bundlePath), "extract-")
+	assert.NoError(t, err)
+	tarPath := filepath.Join(extractDir, "..", filepath.Base(extractDir)+".tar")
+	assert.NoError(t, archive.Ungzip(bundlePath, tarPath))
+	assert.NoError(t, archive.Untar(tarPath, extractDir))
+	return extractDir
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	assert.NoError(t, err)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
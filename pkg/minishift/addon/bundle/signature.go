@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundle
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// ecdsaSignature is the ASN.1 structure of an ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ReadPrivateKey reads a PEM encoded RSA or ECDSA private key in PKCS#1, SEC 1 or PKCS#8 format.
+func ReadPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to parse private key '%s'. Supported are RSA and ECDSA keys", path))
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unsupported private key '%s'. Supported are RSA and ECDSA keys", path))
+}
+
+// ReadPublicKey reads a PEM encoded RSA or ECDSA public key in PKIX format.
+func ReadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to parse public key '%s'. Supported are RSA and ECDSA keys", path))
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Unsupported public key '%s'. Supported are RSA and ECDSA keys", path))
+}
+
+// VerifySignature verifies the base64 encoded detached signature of the bundle against the trusted public key.
+func VerifySignature(bundlePath string, signature []byte, trustedKey crypto.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid signature of bundle '%s'", bundlePath))
+	}
+	digest, err := bundleDigest(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	switch key := trustedKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, decoded) == nil
+	case *ecdsa.PublicKey:
+		parsed := &ecdsaSignature{}
+		if _, err := asn1.Unmarshal(decoded, parsed); err == nil {
+			valid = ecdsa.Verify(key, digest, parsed.R, parsed.S)
+		}
+	}
+
+	if !valid {
+		return errors.New(fmt.Sprintf("The signature of bundle '%s' does not match the trusted key", bundlePath))
+	}
+	return nil
+}
+
+// signBundle creates the detached signature of the bundle.
+func signBundle(bundlePath string, signingKey crypto.Signer) error {
+	digest, err := bundleDigest(bundlePath)
+	if err != nil {
+		return err
+	}
+	signature, err := signingKey.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		return errors.Wrapf(err, "Unable to sign bundle '%s'", bundlePath)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(signature) + "\n"
+	return ioutil.WriteFile(bundlePath+SignatureSuffix, []byte(encoded), 0644)
+}
+
+func bundleDigest(bundlePath string) ([]byte, error) {
+	content, err := ioutil.ReadFile(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	return util.ChecksumFor(crypto.SHA256, content)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to read key '%s'", path)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New(fmt.Sprintf("Key '%s' is not PEM encoded", path))
+	}
+	return block, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pkgTesting "github.com/minishift/minishift/pkg/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingCommand fails the specified number of times before it succeeds
+type failingCommand struct {
+	*defaultCommand
+
+	failures int
+	attempts int
+}
+
+func newFailingCommand(failures int) *failingCommand {
+	defaultCommand := &defaultCommand{rawCommand: "fail"}
+	c := &failingCommand{defaultCommand: defaultCommand, failures: failures}
+	defaultCommand.fn = func(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+		c.attempts++
+		if c.attempts <= c.failures {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	return c
+}
+
+func Test_if_command_executes_matching_block(t *testing.T) {
+	testCases := []struct {
+		version        string
+		expectedOutput string
+	}{
+		{"v3.11.0", "\nnew"},
+		{"v3.9.0", "\nold"},
+	}
+
+	for _, testCase := range testCases {
+		context, _ := NewExecutionContext(nil, nil)
+		context.AddToContext("openshift-version", testCase.version)
+
+		ifCommand := NewIfCommand("if #{openshift-version} >= 3.10", "#{openshift-version} >= 3.10",
+			[]Command{NewEchoCommand("echo new", false)},
+			[]Command{NewEchoCommand("echo old", false)})
+
+		tee, err := pkgTesting.NewTee(true)
+		assert.NoError(t, err, "Error getting instance of Tee")
+		err = ifCommand.Execute(context)
+		tee.Close()
+
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expectedOutput, tee.StdoutBuffer.String())
+	}
+}
+
+func Test_foreach_command_iterates_over_items(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	context.AddToContext("projects", "foo, bar")
+	context.AddToContext("project", "snafu")
+
+	forEachCommand := NewForEachCommand("foreach project in #{projects},baz", "project", "#{projects},baz",
+		[]Command{NewEchoCommand("echo #{project}", false)})
+
+	tee, err := pkgTesting.NewTee(true)
+	assert.NoError(t, err, "Error getting instance of Tee")
+	err = forEachCommand.Execute(context)
+	tee.Close()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "\nfoo\nbar\nbaz", tee.StdoutBuffer.String())
+	assert.Equal(t, "snafu", context.Interpolate("#{project}"), "Shadowed variable should have been restored")
+}
+
+func Test_until_command_retries_until_success(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	failing := newFailingCommand(2)
+	untilCommand := NewUntilCommand("until 10 every 0", 10*time.Second, 0, []Command{failing})
+
+	err := untilCommand.Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, failing.attempts)
+}
+
+func Test_until_command_times_out(t *testing.T) {
+	context, _ := NewExecutionContext(nil, nil)
+	failing := newFailingCommand(1000)
+	untilCommand := NewUntilCommand("until 0", 0, 10*time.Millisecond, []Command{failing})
+
+	err := untilCommand.Execute(context)
+	assert.EqualError(t, err, "Timed out after 0s waiting for 'until 0' to succeed: failed")
+	assert.Equal(t, 1, failing.attempts)
+}
@@ -55,3 +55,7 @@ func (c *defaultCommand) String() string {
 func (c *defaultCommand) IgnoreError() bool {
 	return c.ignoreError
 }
+
+func (c *defaultCommand) OutputVariable() string {
+	return c.outputVariable
+}
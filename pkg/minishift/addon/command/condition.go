@@ -0,0 +1,115 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+const (
+	unresolvedConditionError  = "Unable to evaluate condition '%s'. It contains undefined variables"
+	invalidComparisonError    = "Unable to evaluate condition '%s'. Operator '%s' requires numeric or version operands"
+	unresolvedPlaceholderChar = "#{"
+)
+
+var conditionRegexp = regexp.MustCompile(`^(.*?)\s*(==|!=|>=|<=|>|<)\s*(.*)$`)
+
+// EvaluateCondition evaluates the already interpolated condition of an if statement. A condition is either a single
+// operand, which is true unless it is empty, 'false', 'no' or '0', or a comparison of two operands using one of
+// ==, !=, >=, <=, > and <. Operands are compared as versions if both are versions, as numbers if both are numbers
+// and as strings otherwise, in which case only == and != are supported.
+func EvaluateCondition(condition string) (bool, error) {
+	if strings.Contains(condition, unresolvedPlaceholderChar) {
+		return false, errors.New(fmt.Sprintf(unresolvedConditionError, condition))
+	}
+
+	match := conditionRegexp.FindStringSubmatch(strings.TrimSpace(condition))
+	if match == nil {
+		return isTrue(unquote(condition)), nil
+	}
+
+	lhs, operator, rhs := unquote(match[1]), match[2], unquote(match[3])
+	compared, ok := compare(lhs, rhs)
+	switch operator {
+	case "==":
+		return lhs == rhs || (ok && compared == 0), nil
+	case "!=":
+		return lhs != rhs && (!ok || compared != 0), nil
+	}
+
+	if !ok {
+		return false, errors.New(fmt.Sprintf(invalidComparisonError, condition, operator))
+	}
+
+	switch operator {
+	case ">=":
+		return compared >= 0, nil
+	case "<=":
+		return compared <= 0, nil
+	case ">":
+		return compared > 0, nil
+	default:
+		return compared < 0, nil
+	}
+}
+
+// compare compares the two operands as versions or numbers. false is returned if the operands are not comparable.
+func compare(lhs, rhs string) (int, bool) {
+	lhsVersion, lhsErr := semver.ParseTolerant(lhs)
+	rhsVersion, rhsErr := semver.ParseTolerant(rhs)
+	if lhsErr == nil && rhsErr == nil {
+		return lhsVersion.Compare(rhsVersion), true
+	}
+
+	lhsNumber, lhsErr := strconv.ParseFloat(lhs, 64)
+	rhsNumber, rhsErr := strconv.ParseFloat(rhs, 64)
+	if lhsErr == nil && rhsErr == nil {
+		switch {
+		case lhsNumber < rhsNumber:
+			return -1, true
+		case lhsNumber > rhsNumber:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	return 0, false
+}
+
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "", "false", "no", "0":
+		return false
+	default:
+		return true
+	}
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_evaluate_condition(t *testing.T) {
+	testCases := []struct {
+		condition string
+		expected  bool
+	}{
+		{"3.11.0 >= 3.10", true},
+		{"v3.9.0 >= 3.10", false},
+		{"v3.10.0 == 3.10", true},
+		{"3.10 > 3.9", true},
+		{"3.10 < 3.9", false},
+		{"5 <= 5", true},
+		{"1.5 != 1.5.0", false},
+		{"-1.5 < 2.5", true},
+		{"foo == foo", true},
+		{"'foo bar' == \"foo bar\"", true},
+		{"foo != bar", true},
+		{"true", true},
+		{"foo", true},
+		{"false", false},
+		{"0", false},
+		{"", false},
+		{"''", false},
+	}
+
+	for _, testCase := range testCases {
+		result, err := EvaluateCondition(testCase.condition)
+		assert.NoError(t, err, "Unexpected error evaluating '%s'", testCase.condition)
+		assert.Equal(t, testCase.expected, result, "Unexpected result evaluating '%s'", testCase.condition)
+	}
+}
+
+func Test_evaluate_condition_fails_for_invalid_conditions(t *testing.T) {
+	_, err := EvaluateCondition("#{foo} == bar")
+	assert.EqualError(t, err, "Unable to evaluate condition '#{foo} == bar'. It contains undefined variables")
+
+	_, err = EvaluateCondition("foo >= bar")
+	assert.EqualError(t, err, "Unable to evaluate condition 'foo >= bar'. Operator '>=' requires numeric or version operands")
+}
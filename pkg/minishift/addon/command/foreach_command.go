@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"strings"
+
+	utilStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+const forEachItemSeparator = ","
+
+// ForEachCommand executes a block of commands once for each item of a comma separated list. The current item is
+// available to the commands of the block via the loop variable.
+type ForEachCommand struct {
+	*defaultCommand
+
+	variable string
+	items    string
+	body     []Command
+}
+
+func NewForEachCommand(command string, variable string, items string, body []Command) *ForEachCommand {
+	defaultCommand := &defaultCommand{rawCommand: command}
+	forEachCommand := &ForEachCommand{defaultCommand: defaultCommand, variable: variable, items: items, body: body}
+	defaultCommand.fn = forEachCommand.doExecute
+	return forEachCommand
+}
+
+// Variable returns the name of the loop variable
+func (c *ForEachCommand) Variable() string {
+	return c.variable
+}
+
+// Items returns the not yet interpolated list of items to iterate over
+func (c *ForEachCommand) Items() []string {
+	return splitItems(c.items)
+}
+
+// Body returns the commands executed for each item
+func (c *ForEachCommand) Body() []Command {
+	return c.body
+}
+
+func (c *ForEachCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	// the loop variable might shadow an existing variable, which needs to be restored afterwards
+	shadowed := utilStrings.Contains(ec.Vars(), c.variable)
+	previousValue := ec.Interpolate("#{" + c.variable + "}")
+	defer func() {
+		if shadowed {
+			ec.AddToContext(c.variable, previousValue)
+		} else {
+			ec.RemoveFromContext(c.variable)
+		}
+	}()
+
+	for _, item := range splitItems(ec.Interpolate(c.items)) {
+		ec.AddToContext(c.variable, item)
+		if err := executeBlock(c.body, ec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitItems(items string) []string {
+	var result []string
+	for _, item := range strings.Split(items, forEachItemSeparator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
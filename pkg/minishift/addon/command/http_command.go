@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const invalidHttpCommandError = "Unable to parse http command: '%s'. Expected 'http GET|POST <url> [expect <status>]'"
+
+var (
+	httpRegexp   = regexp.MustCompile(`^http\s+(GET|POST)\s+(\S+)(?:\s+expect\s+(\d{3}))?\s*$`)
+	statusRegexp = regexp.MustCompile(`^\d{3}$`)
+
+	// The routes of the cluster are usually secured by the self-signed certificate of the router, hence certificates
+	// are not verified
+	httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+)
+
+// HttpCommand sends a GET or POST request to the specified url. The command fails if the response status does not
+// match the expected status, by default any 2xx status. The response body can be stored in an output variable.
+type HttpCommand struct {
+	*defaultCommand
+}
+
+func NewHttpCommand(command string, ignoreError bool, outputVariable string) *HttpCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError, outputVariable: outputVariable}
+	httpCommand := &HttpCommand{defaultCommand}
+	defaultCommand.fn = httpCommand.doExecute
+	return httpCommand
+}
+
+func (c *HttpCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	method, url, expectedStatus, err := c.parse(ec.Interpolate(c.rawCommand))
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(".")
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+
+	if !statusMatches(response.StatusCode, expectedStatus) {
+		expected := "2xx"
+		if expectedStatus != 0 {
+			expected = strconv.Itoa(expectedStatus)
+		}
+		return errors.New(fmt.Sprintf("Error executing command '%s'. Expected status %s, but got %d.", c.String(), expected, response.StatusCode))
+	}
+
+	if outputVariable != "" {
+		ec.AddToContext(outputVariable, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Validate checks the syntax of the command before it gets executed. Arguments containing variables are only checked
+// once the command gets executed.
+func (c *HttpCommand) Validate() error {
+	fields := strings.Fields(c.rawCommand)
+	valid := (len(fields) == 3 || len(fields) == 5) && fields[0] == "http"
+	if valid {
+		valid = fields[1] == "GET" || fields[1] == "POST" || containsPlaceholder(fields[1])
+	}
+	if valid && len(fields) == 5 {
+		valid = fields[3] == "expect" && (statusRegexp.MatchString(fields[4]) || containsPlaceholder(fields[4]))
+	}
+	if !valid {
+		return errors.New(fmt.Sprintf(invalidHttpCommandError, c.rawCommand))
+	}
+	return nil
+}
+
+// parse returns the method, url and expected status of the command. The expected status is 0 if any 2xx status is
+// accepted.
+func (c *HttpCommand) parse(command string) (string, string, int, error) {
+	match := httpRegexp.FindStringSubmatch(command)
+	if match == nil {
+		return "", "", 0, errors.New(fmt.Sprintf(invalidHttpCommandError, c.rawCommand))
+	}
+
+	expectedStatus := 0
+	if match[3] != "" {
+		expectedStatus, _ = strconv.Atoi(match[3])
+	}
+	return match[1], match[2], expectedStatus, nil
+}
+
+func statusMatches(status int, expectedStatus int) bool {
+	if expectedStatus == 0 {
+		return status >= 200 && status < 300
+	}
+	return status == expectedStatus
+}
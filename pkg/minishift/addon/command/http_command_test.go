@@ -0,0 +1,94 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_http_command_parsing(t *testing.T) {
+	var testCases = []struct {
+		command        string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{"http GET http://foo.nip.io", "GET", "http://foo.nip.io", 0},
+		{"http POST https://foo.nip.io/api expect 201", "POST", "https://foo.nip.io/api", 201},
+	}
+
+	for _, testCase := range testCases {
+		method, url, expectedStatus, err := NewHttpCommand(testCase.command, false, "").parse(testCase.command)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.method, method)
+		assert.Equal(t, testCase.url, url)
+		assert.Equal(t, testCase.expectedStatus, expectedStatus)
+	}
+
+	for _, command := range []string{"http", "http PUT http://foo.nip.io", "http GET http://foo.nip.io expect ok", "http GET"} {
+		_, _, _, err := NewHttpCommand(command, false, "").parse(command)
+		assert.EqualError(t, err, fmt.Sprintf(invalidHttpCommandError, command))
+	}
+}
+
+func Test_http_command_validation(t *testing.T) {
+	for _, command := range []string{"http GET http://foo.nip.io", "http POST #{url} expect 201", "http #{method} http://foo.nip.io expect #{status}"} {
+		assert.NoError(t, NewHttpCommand(command, false, "").Validate())
+	}
+
+	for _, command := range []string{"http", "http FOO http://foo.nip.io", "http GET http://foo.nip.io expect ok", "http GET", "http GET #{url} accept 200"} {
+		assert.EqualError(t, NewHttpCommand(command, false, "").Validate(), fmt.Sprintf(invalidHttpCommandError, command))
+	}
+}
+
+func Test_http_command_checks_status_and_stores_body(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+		}
+		fmt.Fprint(w, "healthy\n")
+	}))
+	defer server.Close()
+
+	context := &ExecutionContext{interpolationContext: NewInterpolationContext()}
+	context.AddToContext("url", server.URL)
+
+	err := NewHttpCommand("http GET #{url}/health", false, "health").Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "healthy", context.Interpolate("#{health}"))
+
+	err = NewHttpCommand("http POST #{url}/api expect 201", false, "").Execute(context)
+	assert.NoError(t, err)
+
+	err = NewHttpCommand("http POST #{url}/api expect 200", false, "").Execute(context)
+	assert.EqualError(t, err, "Error executing command 'http POST #{url}/api expect 200'. Expected status 200, but got 201.")
+
+	err = NewHttpCommand("http GET #{url}/missing", false, "").Execute(context)
+	assert.EqualError(t, err, "Error executing command 'http GET #{url}/missing'. Expected status 2xx, but got 404.")
+
+	err = NewHttpCommand("http GET #{url}/missing", true, "").Execute(context)
+	assert.NoError(t, err)
+}
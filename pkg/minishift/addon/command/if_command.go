@@ -0,0 +1,70 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+// IfCommand executes one of two blocks of commands depending on the outcome of a condition.
+type IfCommand struct {
+	*defaultCommand
+
+	condition    string
+	thenCommands []Command
+	elseCommands []Command
+}
+
+func NewIfCommand(command string, condition string, thenCommands []Command, elseCommands []Command) *IfCommand {
+	defaultCommand := &defaultCommand{rawCommand: command}
+	ifCommand := &IfCommand{defaultCommand: defaultCommand, condition: condition, thenCommands: thenCommands, elseCommands: elseCommands}
+	defaultCommand.fn = ifCommand.doExecute
+	return ifCommand
+}
+
+// Condition returns the not yet interpolated condition of this command
+func (c *IfCommand) Condition() string {
+	return c.condition
+}
+
+// Then returns the commands executed in case the condition is true
+func (c *IfCommand) Then() []Command {
+	return c.thenCommands
+}
+
+// Else returns the commands executed in case the condition is false
+func (c *IfCommand) Else() []Command {
+	return c.elseCommands
+}
+
+func (c *IfCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	result, err := EvaluateCondition(ec.Interpolate(c.condition))
+	if err != nil {
+		return err
+	}
+
+	if result {
+		return executeBlock(c.thenCommands, ec)
+	}
+	return executeBlock(c.elseCommands, ec)
+}
+
+// executeBlock executes the specified commands in order, stopping at the first error
+func executeBlock(commands []Command, ec *ExecutionContext) error {
+	for _, command := range commands {
+		if err := command.Execute(ec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -22,6 +22,14 @@ import (
 	"regexp"
 )
 
+// placeholderRegexp matches the variables of a command, which are only known once the command gets executed
+var placeholderRegexp = regexp.MustCompile(`#\{[^}]*\}`)
+
+// containsPlaceholder returns true if the specified command argument contains a variable
+func containsPlaceholder(argument string) bool {
+	return placeholderRegexp.MatchString(argument)
+}
+
 // InterpolationContext allows to interpolate variables within commands
 type InterpolationContext interface {
 	// AddToContext adds the specified value under the specified key for command interpolation
@@ -0,0 +1,76 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const invalidTemplateCommandError = "Unable to parse template command: '%s'. Expected 'template <source> <destination>'"
+
+// TemplateCommand renders the source file with the interpolation context into the destination file, eg to create a
+// resource definition which gets applied via 'oc apply -f'. Relative paths are resolved against the add-on directory.
+type TemplateCommand struct {
+	*defaultCommand
+}
+
+func NewTemplateCommand(command string, ignoreError bool) *TemplateCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError}
+	templateCommand := &TemplateCommand{defaultCommand}
+	defaultCommand.fn = templateCommand.doExecute
+	return templateCommand
+}
+
+func (c *TemplateCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	source, destination, err := c.parse(ec.Interpolate(c.rawCommand))
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(".")
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+	if err := ioutil.WriteFile(destination, []byte(ec.Interpolate(string(content))), 0600); err != nil {
+		return errors.New(fmt.Sprintf("Error executing command '%s': %s", c.String(), err.Error()))
+	}
+	return nil
+}
+
+// Validate checks the syntax of the command before it gets executed.
+func (c *TemplateCommand) Validate() error {
+	_, _, err := c.parse(c.rawCommand)
+	return err
+}
+
+func (c *TemplateCommand) parse(command string) (string, string, error) {
+	fields := strings.Fields(command)
+	if len(fields) != 3 {
+		return "", "", errors.New(fmt.Sprintf(invalidTemplateCommandError, c.rawCommand))
+	}
+	return fields[1], fields[2], nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_template_command_renders_interpolated_file(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-template-command-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	source := filepath.Join(testDir, "route.yml.tpl")
+	err = ioutil.WriteFile(source, []byte("host: app.#{routing-suffix}\nport: #{port}\n"), 0644)
+	assert.NoError(t, err)
+
+	context := &ExecutionContext{interpolationContext: NewInterpolationContext()}
+	context.AddToContext("routing-suffix", "192.168.99.100.nip.io")
+	context.AddToContext("port", "8080")
+	context.AddToContext("dir", testDir)
+
+	err = NewTemplateCommand("template #{dir}/route.yml.tpl #{dir}/out/route.yml", false).Execute(context)
+	assert.NoError(t, err)
+
+	rendered, err := ioutil.ReadFile(filepath.Join(testDir, "out", "route.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "host: app.192.168.99.100.nip.io\nport: 8080\n", string(rendered))
+}
+
+func Test_template_command_errors(t *testing.T) {
+	context := &ExecutionContext{interpolationContext: NewInterpolationContext()}
+
+	err := NewTemplateCommand("template source.tpl", false).Execute(context)
+	assert.EqualError(t, err, "Unable to parse template command: 'template source.tpl'. Expected 'template <source> <destination>'")
+
+	err = NewTemplateCommand("template does-not-exist.tpl out.yml", false).Execute(context)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error executing command 'template does-not-exist.tpl out.yml'")
+}
@@ -0,0 +1,46 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+// UndoableCommand is a command annotated with the command reverting its effect. The undo command is only executed
+// when a failing add-on gets rolled back.
+type UndoableCommand struct {
+	command Command
+	undo    Command
+}
+
+func NewUndoableCommand(command Command, undo Command) *UndoableCommand {
+	return &UndoableCommand{command: command, undo: undo}
+}
+
+// Command returns the annotated command
+func (c *UndoableCommand) Command() Command {
+	return c.command
+}
+
+// Undo returns the command reverting the annotated command
+func (c *UndoableCommand) Undo() Command {
+	return c.undo
+}
+
+func (c *UndoableCommand) Execute(ec *ExecutionContext) error {
+	return c.command.Execute(ec)
+}
+
+func (c *UndoableCommand) String() string {
+	return c.command.String()
+}
@@ -0,0 +1,72 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const untilTimeoutError = "Timed out after %s waiting for '%s' to succeed: %s"
+
+// UntilCommand repeatedly executes a block of commands until all of them succeed or the timeout is reached.
+type UntilCommand struct {
+	*defaultCommand
+
+	timeout  time.Duration
+	interval time.Duration
+	body     []Command
+}
+
+func NewUntilCommand(command string, timeout time.Duration, interval time.Duration, body []Command) *UntilCommand {
+	defaultCommand := &defaultCommand{rawCommand: command}
+	untilCommand := &UntilCommand{defaultCommand: defaultCommand, timeout: timeout, interval: interval, body: body}
+	defaultCommand.fn = untilCommand.doExecute
+	return untilCommand
+}
+
+// Timeout returns the maximum time to wait for the block to succeed
+func (c *UntilCommand) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Interval returns the time to wait between two attempts
+func (c *UntilCommand) Interval() time.Duration {
+	return c.interval
+}
+
+// Body returns the commands executed on each attempt
+func (c *UntilCommand) Body() []Command {
+	return c.body
+}
+
+func (c *UntilCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	deadline := time.Now().Add(c.timeout)
+	for {
+		err := executeBlock(c.body, ec)
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(c.interval).After(deadline) {
+			return errors.New(fmt.Sprintf(untilTimeoutError, c.timeout, c.String(), err.Error()))
+		}
+		fmt.Print(".")
+		time.Sleep(c.interval)
+	}
+}
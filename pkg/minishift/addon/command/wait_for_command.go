@@ -0,0 +1,158 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	invalidWaitForCommandError = "Unable to parse wait-for command: '%s'. Expected 'wait-for <resource> rollout|ready|exists|condition=<type> [<timeout>]'"
+
+	RolloutCondition   = "rollout"
+	ReadyCondition     = "ready"
+	ExistsCondition    = "exists"
+	conditionPrefix    = "condition="
+	defaultWaitTimeout = 300
+)
+
+// waitForInterval is the time between two checks of the condition
+var waitForInterval = 2 * time.Second
+
+// WaitForCommand waits until the specified resource satisfies a condition, eg until a deployment is rolled out or a
+// pod is ready. The command fails if the condition is not satisfied within the timeout in seconds.
+type WaitForCommand struct {
+	*defaultCommand
+}
+
+func NewWaitForCommand(command string, ignoreError bool) *WaitForCommand {
+	defaultCommand := &defaultCommand{rawCommand: command, ignoreError: ignoreError}
+	waitForCommand := &WaitForCommand{defaultCommand}
+	defaultCommand.fn = waitForCommand.doExecute
+	return waitForCommand
+}
+
+func (c *WaitForCommand) doExecute(ec *ExecutionContext, ignoreError bool, outputVariable string) error {
+	resource, condition, timeout, err := c.parse(ec.Interpolate(c.rawCommand))
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		fmt.Print(".")
+		if checkCondition(ec, resource, condition) {
+			return nil
+		}
+		if time.Now().Add(waitForInterval).After(deadline) {
+			return errors.New(fmt.Sprintf("Error executing command '%s'. Condition '%s' of '%s' not satisfied within %s.", c.String(), condition, resource, timeout))
+		}
+		time.Sleep(waitForInterval)
+	}
+}
+
+// Validate checks the syntax of the command before it gets executed. Arguments containing variables are only checked
+// once the command gets executed.
+func (c *WaitForCommand) Validate() error {
+	fields := strings.Fields(c.rawCommand)
+	valid := len(fields) >= 3 && len(fields) <= 4 && (isValidCondition(fields[2]) || containsPlaceholder(fields[2]))
+	if valid && len(fields) == 4 {
+		_, err := parseTimeout(fields[3])
+		valid = err == nil || containsPlaceholder(fields[3])
+	}
+	if !valid {
+		return errors.New(fmt.Sprintf(invalidWaitForCommandError, c.rawCommand))
+	}
+	return nil
+}
+
+// parse returns the resource, condition and timeout of the command.
+func (c *WaitForCommand) parse(command string) (string, string, time.Duration, error) {
+	fields := strings.Fields(command)
+	if len(fields) < 3 || len(fields) > 4 {
+		return "", "", 0, errors.New(fmt.Sprintf(invalidWaitForCommandError, c.rawCommand))
+	}
+
+	condition := fields[2]
+	if !isValidCondition(condition) {
+		return "", "", 0, errors.New(fmt.Sprintf(invalidWaitForCommandError, c.rawCommand))
+	}
+
+	timeout := defaultWaitTimeout
+	if len(fields) == 4 {
+		var err error
+		if timeout, err = parseTimeout(fields[3]); err != nil {
+			return "", "", 0, errors.New(fmt.Sprintf(invalidWaitForCommandError, c.rawCommand))
+		}
+	}
+	return fields[1], condition, time.Duration(timeout) * time.Second, nil
+}
+
+func isValidCondition(condition string) bool {
+	switch {
+	case condition == RolloutCondition, condition == ReadyCondition, condition == ExistsCondition:
+		return true
+	default:
+		return strings.HasPrefix(condition, conditionPrefix) && len(condition) > len(conditionPrefix)
+	}
+}
+
+// parseTimeout returns the timeout in seconds, which needs to be positive
+func parseTimeout(timeout string) (int, error) {
+	seconds, err := strconv.Atoi(timeout)
+	if err != nil {
+		return 0, err
+	}
+	if seconds <= 0 {
+		return 0, errors.New("the timeout needs to be positive")
+	}
+	return seconds, nil
+}
+
+// checkCondition returns true if the resource satisfies the condition.
+func checkCondition(ec *ExecutionContext, resource string, condition string) bool {
+	switch condition {
+	case RolloutCondition:
+		output, ok := runOc(ec, fmt.Sprintf("rollout status %s --watch=false", resource))
+		return ok && strings.Contains(output, "successfully rolled out")
+	case ExistsCondition:
+		_, ok := runOc(ec, fmt.Sprintf("get %s", resource))
+		return ok
+	case ReadyCondition:
+		return conditionStatus(ec, resource, "Ready") == "True"
+	default:
+		return conditionStatus(ec, resource, strings.TrimPrefix(condition, conditionPrefix)) == "True"
+	}
+}
+
+// conditionStatus returns the status of the condition of the specified type of all matching resources.
+func conditionStatus(ec *ExecutionContext, resource string, conditionType string) string {
+	output, _ := runOc(ec, fmt.Sprintf(`get %s -o jsonpath={.status.conditions[?(@.type=="%s")].status}`, resource, conditionType))
+	return output
+}
+
+func runOc(ec *ExecutionContext, command string) (string, bool) {
+	output := new(bytes.Buffer)
+	exitStatus := ec.GetOcCommander().Run(command, output, ioutil.Discard)
+	return strings.TrimSpace(output.String()), exitStatus == 0
+}
@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minishift/oc"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_wait_for_command_parsing(t *testing.T) {
+	var testCases = []struct {
+		command   string
+		resource  string
+		condition string
+		timeout   time.Duration
+	}{
+		{"wait-for dc/app rollout", "dc/app", RolloutCondition, 300 * time.Second},
+		{"wait-for pod/app-1-abcde ready 60", "pod/app-1-abcde", ReadyCondition, 60 * time.Second},
+		{"wait-for deployment/app condition=Available 10", "deployment/app", "condition=Available", 10 * time.Second},
+	}
+
+	for _, testCase := range testCases {
+		resource, condition, timeout, err := NewWaitForCommand(testCase.command, false).parse(testCase.command)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.resource, resource)
+		assert.Equal(t, testCase.condition, condition)
+		assert.Equal(t, testCase.timeout, timeout)
+	}
+
+	for _, command := range []string{"wait-for dc/app", "wait-for dc/app started", "wait-for dc/app ready soon", "wait-for dc/app condition=", "wait-for dc/app ready 0"} {
+		_, _, _, err := NewWaitForCommand(command, false).parse(command)
+		assert.EqualError(t, err, fmt.Sprintf(invalidWaitForCommandError, command))
+	}
+}
+
+func Test_wait_for_command_validation(t *testing.T) {
+	for _, command := range []string{"wait-for dc/app rollout", "wait-for #{resource} #{condition} #{timeout}", "wait-for dc/#{name} ready 60"} {
+		assert.NoError(t, NewWaitForCommand(command, false).Validate())
+	}
+
+	for _, command := range []string{"wait-for x", "wait-for dc/app started", "wait-for dc/app ready soon", "wait-for dc/app ready 60 now"} {
+		assert.EqualError(t, NewWaitForCommand(command, false).Validate(), fmt.Sprintf(invalidWaitForCommandError, command))
+	}
+}
+
+func Test_wait_for_command_polls_until_condition_is_satisfied(t *testing.T) {
+	defer stubWaitForInterval()()
+
+	runner := &scriptedOcRunner{outputs: []string{"", "False", "True"}}
+	context := newWaitForContext(runner)
+
+	err := NewWaitForCommand("wait-for pod/app ready 5", false).Execute(context)
+	assert.NoError(t, err)
+	assert.Len(t, runner.commands, 3)
+	assert.Equal(t, `get pod/app -o jsonpath={.status.conditions[?(@.type=="Ready")].status}`, runner.commands[0])
+}
+
+func Test_wait_for_rollout(t *testing.T) {
+	defer stubWaitForInterval()()
+
+	runner := &scriptedOcRunner{outputs: []string{"Waiting for rollout to finish", `replication controller "app-1" successfully rolled out`}}
+	context := newWaitForContext(runner)
+
+	err := NewWaitForCommand("wait-for dc/app rollout 5", false).Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rollout status dc/app --watch=false", "rollout status dc/app --watch=false"}, runner.commands)
+}
+
+func Test_wait_for_command_times_out(t *testing.T) {
+	defer stubWaitForInterval()()
+
+	runner := &scriptedOcRunner{outputs: []string{"False"}}
+	context := newWaitForContext(runner)
+
+	err := NewWaitForCommand("wait-for deployment/app condition=Available 1", false).Execute(context)
+	assert.EqualError(t, err, "Error executing command 'wait-for deployment/app condition=Available 1'. Condition 'condition=Available' of 'deployment/app' not satisfied within 1s.")
+}
+
+// scriptedOcRunner returns the scripted outputs in order, repeating the last one
+type scriptedOcRunner struct {
+	outputs  []string
+	commands []string
+}
+
+func (r *scriptedOcRunner) Run(stdOut io.Writer, stdErr io.Writer, commandPath string, args ...string) int {
+	r.commands = append(r.commands, strings.Join(args[1:], " "))
+	index := len(r.commands) - 1
+	if index >= len(r.outputs) {
+		index = len(r.outputs) - 1
+	}
+	fmt.Fprint(stdOut, r.outputs[index])
+	return 0
+}
+
+func (r *scriptedOcRunner) Output(command string, args ...string) ([]byte, error) {
+	return nil, nil
+}
+
+func newWaitForContext(runner *scriptedOcRunner) *ExecutionContext {
+	ocRunner := &oc.OcRunner{OcPath: "oc", KubeConfigPath: "/kube/config", Runner: runner}
+	return &ExecutionContext{ocRunner: ocRunner, interpolationContext: NewInterpolationContext()}
+}
+
+func stubWaitForInterval() func() {
+	original := waitForInterval
+	waitForInterval = 10 * time.Millisecond
+	return func() {
+		waitForInterval = original
+	}
+}
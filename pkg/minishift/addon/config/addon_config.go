@@ -16,8 +16,42 @@ limitations under the License.
 
 package config
 
+import "time"
+
 type AddOnConfig struct {
 	Name     string
 	Enabled  bool
 	Priority float64
 }
+
+const (
+	// ApplyAction denotes a record of applying an addon
+	ApplyAction = "apply"
+	// RemoveAction denotes a record of removing an addon
+	RemoveAction = "remove"
+	// RollbackAction denotes a record of rolling back a failed application of an addon
+	RollbackAction = "rollback"
+
+	// SuccessOutcome denotes that all commands of an addon executed successfully
+	SuccessOutcome = "success"
+	// FailureOutcome denotes that an addon could not be executed or one of its commands failed
+	FailureOutcome = "failure"
+)
+
+// AddOnApplyRecord records a single application or removal of an addon against the current instance.
+type AddOnApplyRecord struct {
+	Timestamp        time.Time
+	Action           string
+	Outcome          string
+	FailedCommand    int               // index of the failing command, -1 if no command failed
+	Command          string            // the failing command
+	Error            string            // error message in case of a failure
+	Variables        map[string]string // variable values, secrets are masked
+	MinishiftVersion string
+	OpenShiftVersion string
+}
+
+// IsSuccess returns true if the recorded action completed successfully.
+func (r *AddOnApplyRecord) IsSuccess() bool {
+	return r.Outcome == SuccessOutcome
+}
@@ -0,0 +1,444 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lint checks add-on definitions for problems which would otherwise only show up when the add-on is applied
+// or removed, eg references to undeclared variables or to files missing from the add-on directory.
+package lint
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/parser"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Rules reported in Diagnostic.Rule
+const (
+	RuleMissingFile        = "missing-file"
+	RuleParseError         = "parse-error"
+	RuleNameMismatch       = "name-mismatch"
+	RuleUnknownHeader      = "unknown-header"
+	RuleUndeclaredVariable = "undeclared-variable"
+	RuleUnusedVariable     = "unused-variable"
+	RuleMissingRemoveFile  = "missing-remove-file"
+)
+
+const (
+	addOnFileSuffix  = ".addon"
+	removeFileSuffix = ".addon.remove"
+	commentChar      = "#"
+	ignoreErrorChar  = "!"
+	evaluationChar   = ":="
+)
+
+var (
+	// builtInVariables are the variables Minishift adds to the interpolation context of every add-on
+	builtInVariables = []string{"ip", "routing-suffix", "user", "addon-name", "openshift-version"}
+
+	headerRegexp      = regexp.MustCompile(`^# ?([a-zA-Z-]*):(.*)`)
+	placeholderRegexp = regexp.MustCompile(`#{([^}]*)}`)
+	forEachRegexp     = regexp.MustCompile(`^foreach\s+([a-zA-Z0-9_.-]+)\s+in\s+`)
+	fileFlagRegexp    = regexp.MustCompile(`(?:^|\s)(?:-f|--filename)(?:=|\s+)(\S+)`)
+
+	// readOnlySubCommands are the oc and openshift sub commands which do not change the state of the cluster
+	readOnlySubCommands = map[string]bool{
+		"get": true, "describe": true, "status": true, "logs": true, "whoami": true, "version": true,
+		"explain": true, "project": true, "projects": true, "wait": true, "api-versions": true,
+	}
+)
+
+// Diagnostic is a single problem found in an add-on. Line is 0 if the problem does not relate to a specific line.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+func (d *Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location = fmt.Sprintf("%s:%d", d.File, d.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, d.Severity, d.Message, d.Rule)
+}
+
+// Report is the result of linting a single add-on directory.
+type Report struct {
+	AddOn       string        `json:"addon"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// HasErrors returns true if the report contains at least one diagnostic of severity error.
+func (r *Report) HasErrors() bool {
+	for _, diagnostic := range r.Diagnostics {
+		if diagnostic.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(severity Severity, file string, line int, rule string, message string) {
+	r.Diagnostics = append(r.Diagnostics, &Diagnostic{Severity: severity, File: file, Line: line, Rule: rule, Message: message})
+}
+
+// addOnFile holds the content of a single add-on or add-on remove file together with the results of parsing it
+type addOnFile struct {
+	name  string
+	lines []string
+	meta  addon.AddOnMeta
+}
+
+// Lint checks the add-on in the specified directory. An error is only returned if the directory cannot be read,
+// problems with the add-on itself are reported as diagnostics of the returned report.
+func Lint(addOnDir string) (*Report, error) {
+	entries, err := ioutil.ReadDir(addOnDir)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read add-on directory '%s': %s", addOnDir, err.Error()))
+	}
+
+	report := &Report{AddOn: filepath.Base(addOnDir), Diagnostics: []*Diagnostic{}}
+
+	var addOnFiles, removeFiles []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(entry.Name(), removeFileSuffix):
+			removeFiles = append(removeFiles, entry.Name())
+		case strings.HasSuffix(entry.Name(), addOnFileSuffix):
+			addOnFiles = append(addOnFiles, entry.Name())
+		}
+	}
+
+	switch len(addOnFiles) {
+	case 0:
+		report.add(Error, report.AddOn, 0, RuleMissingFile, fmt.Sprintf("No add-on file with extension '%s' found", addOnFileSuffix))
+		return report, nil
+	case 1:
+	default:
+		report.add(Error, report.AddOn, 0, RuleParseError, fmt.Sprintf("Multiple add-on files found: %s", strings.Join(addOnFiles, ", ")))
+		return report, nil
+	}
+	if len(removeFiles) > 1 {
+		report.add(Error, report.AddOn, 0, RuleParseError, fmt.Sprintf("Multiple add-on remove files found: %s", strings.Join(removeFiles, ", ")))
+		return report, nil
+	}
+
+	main, err := readAddOnFile(addOnDir, addOnFiles[0], report)
+	if err != nil {
+		return nil, err
+	}
+	files := []*addOnFile{main}
+
+	var remove *addOnFile
+	if len(removeFiles) == 1 {
+		remove, err = readAddOnFile(addOnDir, removeFiles[0], report)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, remove)
+	}
+
+	if main.meta != nil && main.meta.Name() != report.AddOn {
+		report.add(Error, main.name, 0, RuleNameMismatch,
+			fmt.Sprintf("Add-on name '%s' does not match the add-on directory name '%s'", main.meta.Name(), report.AddOn))
+	}
+
+	declared := declaredVariables(files)
+	checkVariableReferences(addOnDir, files, declared, report)
+	checkFileReferences(addOnDir, files, report)
+	if remove == nil {
+		checkMissingRemoveFile(main, report)
+	}
+
+	// order diagnostics by file, starting with the add-on file, and line
+	rank := func(d *Diagnostic) string {
+		switch {
+		case d.File == main.name:
+			return "0"
+		case remove != nil && d.File == remove.name:
+			return "1"
+		}
+		return "2" + d.File
+	}
+	sort.SliceStable(report.Diagnostics, func(i, j int) bool {
+		if rank(report.Diagnostics[i]) != rank(report.Diagnostics[j]) {
+			return rank(report.Diagnostics[i]) < rank(report.Diagnostics[j])
+		}
+		return report.Diagnostics[i].Line < report.Diagnostics[j].Line
+	})
+
+	return report, nil
+}
+
+func readAddOnFile(addOnDir string, name string, report *Report) (*addOnFile, error) {
+	content, err := ioutil.ReadFile(filepath.Join(addOnDir, name))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unable to read add-on file '%s': %s", name, err.Error()))
+	}
+
+	file := &addOnFile{name: name}
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		file.lines = append(file.lines, scanner.Text())
+	}
+
+	meta, _, err := parser.NewAddOnParser().ParseContent(strings.NewReader(string(content)))
+	if err != nil {
+		line := 0
+		if parseError, ok := err.(parser.ParseError); ok {
+			line = parseError.Line()
+		}
+		report.add(Error, name, line, RuleParseError, err.Error())
+	}
+	file.meta = meta
+
+	for i, line := range file.lines {
+		if !strings.HasPrefix(line, commentChar) {
+			break
+		}
+		if match := headerRegexp.FindStringSubmatch(line); match != nil {
+			if key := strings.TrimSpace(match[1]); !addon.IsKnownMetaTag(key) {
+				report.add(Warning, name, i+1, RuleUnknownHeader, fmt.Sprintf("Unknown header '%s'", key))
+			}
+		}
+	}
+
+	return file, nil
+}
+
+// commandLines calls fn for each non blank and non comment line following the header of the specified file. The
+// line passed to fn is trimmed and stripped from the ignore error prefix and the output variable assignment.
+func (f *addOnFile) commandLines(fn func(lineNumber int, outputVariable string, line string)) {
+	inHeader := true
+	for i, line := range f.lines {
+		if inHeader && strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		inHeader = false
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimPrefix(line, ignoreErrorChar)
+
+		var outputVariable string
+		if index := strings.Index(line, evaluationChar); index >= 0 {
+			outputVariable = strings.TrimSpace(line[:index])
+			line = strings.TrimSpace(line[index+len(evaluationChar):])
+		}
+		fn(i+1, outputVariable, line)
+	}
+}
+
+// declaredVariables returns the names of all variables declared in the headers of the specified files, mapped to
+// the file declaring them
+func declaredVariables(files []*addOnFile) map[string]string {
+	declared := make(map[string]string)
+	for _, file := range files {
+		if file.meta == nil {
+			continue
+		}
+		if requiredVars, err := file.meta.RequiredVars(); err == nil {
+			for _, name := range requiredVars {
+				declared[name] = file.name
+			}
+		}
+		if varDefaults, err := file.meta.VarDefaults(); err == nil {
+			for _, varDefault := range varDefaults {
+				declared[varDefault.Key] = file.name
+			}
+		}
+		if variables, err := file.meta.Variables(); err == nil {
+			for _, variable := range variables {
+				declared[variable.Name] = file.name
+			}
+		}
+	}
+	return declared
+}
+
+// checkVariableReferences verifies that all variables used by the add-on files and by the sources of their template
+// commands are known and reports declared variables which are never used
+func checkVariableReferences(addOnDir string, files []*addOnFile, declared map[string]string, report *Report) {
+	referenced := make(map[string]bool)
+	checkPlaceholders := func(fileName string, lineNumber int, line string, defined map[string]bool) {
+		for _, match := range placeholderRegexp.FindAllStringSubmatch(line, -1) {
+			name := match[1]
+			referenced[name] = true
+			if _, ok := declared[name]; ok || defined[name] || isBuiltIn(name) {
+				continue
+			}
+			report.add(Error, fileName, lineNumber, RuleUndeclaredVariable,
+				fmt.Sprintf("Variable '%s' is neither declared via Required-Vars, Var-Defaults or Var nor defined by the add-on", name))
+		}
+	}
+
+	for _, file := range files {
+		// variables defined by the add-on itself via output variables or foreach loops
+		defined := make(map[string]bool)
+		file.commandLines(func(lineNumber int, outputVariable string, line string) {
+			if outputVariable != "" {
+				defined[outputVariable] = true
+			}
+			if match := forEachRegexp.FindStringSubmatch(line); match != nil {
+				defined[match[1]] = true
+			}
+		})
+
+		file.commandLines(func(lineNumber int, outputVariable string, line string) {
+			checkPlaceholders(file.name, lineNumber, line, defined)
+
+			fields := strings.Fields(line)
+			if len(fields) != 3 || fields[0] != "template" || !isLocalFile(fields[1]) {
+				return
+			}
+			content, err := ioutil.ReadFile(filepath.Join(addOnDir, fields[1]))
+			if err != nil {
+				// missing template sources are reported by checkFileReferences
+				return
+			}
+			for i, templateLine := range strings.Split(string(content), "\n") {
+				checkPlaceholders(fields[1], i+1, templateLine, defined)
+			}
+		})
+	}
+
+	var names []string
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !referenced[name] {
+			report.add(Warning, declared[name], 0, RuleUnusedVariable, fmt.Sprintf("Variable '%s' is declared but never used", name))
+		}
+	}
+}
+
+// checkFileReferences verifies that template sources and files passed to 'oc -f' exist in the add-on directory.
+// References containing variables, absolute paths, URLs and files created by template commands are not checked.
+func checkFileReferences(addOnDir string, files []*addOnFile, report *Report) {
+	generated := make(map[string]bool)
+	for _, file := range files {
+		file.commandLines(func(lineNumber int, outputVariable string, line string) {
+			if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "template" {
+				generated[filepath.Clean(fields[2])] = true
+			}
+		})
+	}
+
+	for _, file := range files {
+		file.commandLines(func(lineNumber int, outputVariable string, line string) {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				return
+			}
+			line = strings.TrimSpace(strings.TrimPrefix(line, "undo "))
+
+			var references []string
+			switch {
+			case fields[0] == "template" && len(fields) == 3:
+				references = append(references, fields[1])
+			case strings.HasPrefix(line, "oc "):
+				for _, match := range fileFlagRegexp.FindAllStringSubmatch(line, -1) {
+					references = append(references, match[1])
+				}
+			}
+
+			for _, reference := range references {
+				if !isLocalFile(reference) || generated[filepath.Clean(reference)] {
+					continue
+				}
+				if _, err := os.Stat(filepath.Join(addOnDir, reference)); os.IsNotExist(err) {
+					report.add(Error, file.name, lineNumber, RuleMissingFile,
+						fmt.Sprintf("Referenced file '%s' does not exist in the add-on directory", reference))
+				}
+			}
+		})
+	}
+}
+
+// checkMissingRemoveFile warns about add-ons without remove file which change the state of the cluster
+func checkMissingRemoveFile(main *addOnFile, report *Report) {
+	reported := false
+	main.commandLines(func(lineNumber int, outputVariable string, line string) {
+		if reported || !isMutating(line) {
+			return
+		}
+		reported = true
+		report.add(Warning, main.name, lineNumber, RuleMissingRemoveFile,
+			fmt.Sprintf("The add-on has no '%s' file, but changes the cluster state via '%s'", removeFileSuffix, line))
+	})
+}
+
+func isMutating(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return false
+	}
+	switch fields[0] {
+	case "oc", "openshift":
+		if fields[1] == "ex" || fields[1] == "adm" || fields[1] == "rollout" {
+			// eg 'oc rollout status'
+			return len(fields) < 3 || !readOnlySubCommands[fields[2]]
+		}
+		return !readOnlySubCommands[fields[1]]
+	case "docker":
+		switch fields[1] {
+		case "ps", "images", "inspect", "logs", "version", "info", "pull":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+func isLocalFile(reference string) bool {
+	return reference != "-" &&
+		!strings.Contains(reference, "#{") &&
+		!strings.Contains(reference, "://") &&
+		!filepath.IsAbs(reference)
+}
+
+func isBuiltIn(name string) bool {
+	for _, builtIn := range builtInVariables {
+		if builtIn == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validAddOn = `# Name: acme
+# Description: Deploys acme
+# Required-Vars: NAMESPACE
+# Var-Defaults: REPLICAS=1
+# Var: COLOR; type=enum; values=red|blue; default=red
+
+oc new-project #{NAMESPACE}
+template acme.yaml.tmpl acme.yaml
+oc apply -f acme.yaml -n #{NAMESPACE}
+foreach i in 1,2
+  echo #{i}
+end
+token := oc whoami -t
+echo #{token} #{ip} #{routing-suffix}
+`
+
+const validRemoveFile = `# Name: acme
+# Description: Removes acme
+
+oc delete project #{NAMESPACE}
+`
+
+const validTemplate = `replicas: #{REPLICAS}
+color: #{COLOR}
+`
+
+func Test_valid_addon_has_no_diagnostics(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{
+		"acme.addon":        validAddOn,
+		"acme.addon.remove": validRemoveFile,
+		"acme.yaml.tmpl":    validTemplate,
+	})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Diagnostics)
+	assert.False(t, report.HasErrors())
+}
+
+func Test_missing_addon_file_is_reported(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{"README.md": "acme"})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assertRules(t, report, RuleMissingFile)
+	assert.True(t, report.HasErrors())
+}
+
+func Test_parse_errors_are_reported_with_line(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{
+		"acme.addon": `# Name: acme
+# Description: Deploys acme
+
+if #{ip} == 127.0.0.1
+  echo local
+`,
+	})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assertRules(t, report, RuleParseError)
+	assert.Equal(t, "acme.addon", report.Diagnostics[0].File)
+	assert.Equal(t, 4, report.Diagnostics[0].Line)
+}
+
+func Test_name_mismatch_and_unknown_headers_are_reported(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{
+		"acme.addon": `# Name: other
+# Description: Deploys acme
+# Requires-Vars: NAMESPACE
+
+echo foo
+`,
+	})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assertRules(t, report, RuleNameMismatch, RuleUnknownHeader)
+	assert.Equal(t, 3, report.Diagnostics[1].Line)
+	assert.Equal(t, Warning, report.Diagnostics[1].Severity)
+}
+
+func Test_undeclared_and_unused_variables_are_reported(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{
+		"acme.addon": `# Name: acme
+# Description: Deploys acme
+# Required-Vars: NAMESPACE
+
+echo #{NAME_SPACE}
+template acme.yaml.tmpl /tmp/acme.yaml
+`,
+		"acme.addon.remove": `# Name: acme
+# Description: Removes acme
+
+echo #{project}
+`,
+		"acme.yaml.tmpl": "replicas: #{REPLICAS}\n",
+	})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assertRules(t, report, RuleUnusedVariable, RuleUndeclaredVariable, RuleUndeclaredVariable, RuleUndeclaredVariable)
+
+	assert.Equal(t, "acme.addon", report.Diagnostics[0].File)
+	assert.Equal(t, 0, report.Diagnostics[0].Line)
+	assert.Contains(t, report.Diagnostics[0].Message, "NAMESPACE")
+	assert.Equal(t, 5, report.Diagnostics[1].Line)
+	assert.Contains(t, report.Diagnostics[1].Message, "NAME_SPACE")
+	assert.Equal(t, "acme.addon.remove", report.Diagnostics[2].File)
+	assert.Contains(t, report.Diagnostics[2].Message, "project")
+	assert.Equal(t, "acme.yaml.tmpl", report.Diagnostics[3].File)
+	assert.Equal(t, 1, report.Diagnostics[3].Line)
+	assert.Contains(t, report.Diagnostics[3].Message, "REPLICAS")
+}
+
+func Test_missing_referenced_files_are_reported(t *testing.T) {
+	addOnDir := createAddOn(t, map[string]string{
+		"acme.addon": `# Name: acme
+# Description: Deploys acme
+
+template missing.tmpl acme.yaml
+oc apply -f acme.yaml
+oc apply -f resources/missing.yaml
+oc create --filename=/tmp/absolute.yaml
+oc apply -f https://example.com/acme.yaml
+`,
+		"acme.addon.remove": `# Name: acme
+# Description: Removes acme
+`,
+	})
+	defer os.RemoveAll(filepath.Dir(addOnDir))
+
+	report, err := Lint(addOnDir)
+	assert.NoError(t, err)
+	assertRules(t, report, RuleMissingFile, RuleMissingFile)
+	assert.Equal(t, 4, report.Diagnostics[0].Line)
+	assert.Equal(t, 6, report.Diagnostics[1].Line)
+}
+
+func Test_missing_remove_file_is_reported_for_changing_commands(t *testing.T) {
+	var testCases = []struct {
+		command  string
+		expected bool
+	}{
+		{"oc get pods", false},
+		{"oc rollout status dc/acme", false},
+		{"docker ps", false},
+		{"echo foo", false},
+		{"oc new-app acme", true},
+		{"oc adm policy add-cluster-role-to-user cluster-admin admin", true},
+		{"docker run acme", true},
+		{"!out := openshift start", true},
+	}
+
+	for _, testCase := range testCases {
+		addOnDir := createAddOn(t, map[string]string{
+			"acme.addon": "# Name: acme\n# Description: Deploys acme\n\n" + testCase.command + "\n",
+		})
+
+		report, err := Lint(addOnDir)
+		assert.NoError(t, err)
+		if testCase.expected {
+			assertRules(t, report, RuleMissingRemoveFile)
+			assert.Equal(t, 4, report.Diagnostics[0].Line)
+			assert.False(t, report.HasErrors())
+		} else {
+			assert.Empty(t, report.Diagnostics, "Unexpected diagnostics for '%s'", testCase.command)
+		}
+		os.RemoveAll(filepath.Dir(addOnDir))
+	}
+}
+
+func Test_lint_fails_for_missing_directory(t *testing.T) {
+	_, err := Lint("/this/does/not/exist")
+	assert.Error(t, err)
+}
+
+func createAddOn(t *testing.T, files map[string]string) string {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-lint-")
+	assert.NoError(t, err)
+
+	addOnDir := filepath.Join(testDir, "acme")
+	assert.NoError(t, os.Mkdir(addOnDir, 0755))
+	for name, content := range files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(addOnDir, name), []byte(content), 0644))
+	}
+	return addOnDir
+}
+
+func assertRules(t *testing.T, report *Report, expected ...string) {
+	var rules []string
+	for _, diagnostic := range report.Diagnostics {
+		rules = append(rules, diagnostic.Rule)
+	}
+	assert.Equal(t, expected, rules, "Unexpected diagnostics %v", report.Diagnostics)
+}
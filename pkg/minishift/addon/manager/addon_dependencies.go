@@ -0,0 +1,201 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/pkg/errors"
+)
+
+// versionConstraint is the version range an add-on requires for one of its dependencies
+type versionConstraint struct {
+	dependent  string
+	dependency *addon.Dependency
+}
+
+// ResolveDependencies returns the specified addons together with all addons they transitively depend on, in the
+// order in which they need to be applied. Each addon is ordered after its dependencies, addons not depending on each
+// other are ordered by priority and name. An error is returned if a dependency is not installed, if the installed
+// version of a dependency does not satisfy the version ranges required by its dependents or if the dependencies
+// form a cycle.
+func (m *AddOnManager) ResolveDependencies(addOns []addon.AddOn) ([]addon.AddOn, error) {
+	graph := make(map[string][]string)
+	constraints := make(map[string][]versionConstraint)
+
+	queue := make([]addon.AddOn, len(addOns))
+	copy(queue, addOns)
+	for len(queue) > 0 {
+		addOn := queue[0]
+		queue = queue[1:]
+		name := addOn.MetaData().Name()
+		if _, seen := graph[name]; seen {
+			continue
+		}
+
+		dependencies, err := addOn.MetaData().Dependencies()
+		if err != nil {
+			return nil, err
+		}
+
+		var missing []string
+		graph[name] = []string{}
+		for _, dependency := range dependencies {
+			if !m.IsInstalled(dependency.Name) {
+				missing = append(missing, dependency.Name)
+				continue
+			}
+			graph[name] = append(graph[name], dependency.Name)
+			constraints[dependency.Name] = append(constraints[dependency.Name], versionConstraint{dependent: name, dependency: dependency})
+			queue = append(queue, m.addOns[dependency.Name])
+		}
+		if len(missing) > 0 {
+			return nil, errors.New(fmt.Sprintf("Dependent add-ons [%s] of add-on '%s' not found for this instance. Please install them first.", strings.Join(missing, ", "), name))
+		}
+	}
+
+	if err := m.verifyVersionConstraints(constraints); err != nil {
+		return nil, err
+	}
+
+	return m.sortTopologically(graph)
+}
+
+// verifyVersionConstraints verifies that the installed version of each dependency satisfies the version ranges
+// required by all its dependents.
+func (m *AddOnManager) verifyVersionConstraints(constraints map[string][]versionConstraint) error {
+	names := make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var failed []string
+		for _, constraint := range constraints[name] {
+			if err := m.verifyDependencyVersion(constraint.dependent, constraint.dependency); err != nil {
+				if len(constraints[name]) == 1 {
+					return err
+				}
+				failed = append(failed, fmt.Sprintf("'%s' requires '%s'", constraint.dependent, constraint.dependency))
+			}
+		}
+		if len(failed) == 0 {
+			continue
+		}
+
+		var all []string
+		for _, constraint := range constraints[name] {
+			all = append(all, fmt.Sprintf("'%s' requires '%s'", constraint.dependent, constraint.dependency))
+		}
+		return errors.New(fmt.Sprintf("Conflicting requirements for add-on '%s' in version '%s': %s", name,
+			m.addOns[name].MetaData().Version(), strings.Join(all, ", ")))
+	}
+	return nil
+}
+
+// verifyDependencyVersion verifies that the installed version of the dependency satisfies the version range required by
+// the dependent addon.
+func (m *AddOnManager) verifyDependencyVersion(dependent string, dependency *addon.Dependency) error {
+	if dependency.VersionRange == nil {
+		return nil
+	}
+
+	installedVersion := m.addOns[dependency.Name].MetaData().Version()
+	if installedVersion == "" {
+		return errors.New(fmt.Sprintf("Add-on '%s' requires '%s', but add-on '%s' does not specify an Addon-Version", dependent, dependency, dependency.Name))
+	}
+
+	version, err := addon.ParseVersion(installedVersion)
+	if err != nil {
+		return err
+	}
+	if !dependency.VersionRange.Contains(version) {
+		return errors.New(fmt.Sprintf("Add-on '%s' requires '%s', but version '%s' of add-on '%s' is installed", dependent, dependency, installedVersion, dependency.Name))
+	}
+	return nil
+}
+
+// sortTopologically orders the addons of the dependency graph so that each addon comes after its dependencies.
+// Among the addons whose dependencies are satisfied, the one with the lowest priority and name comes first.
+func (m *AddOnManager) sortTopologically(graph map[string][]string) ([]addon.AddOn, error) {
+	pending := make(map[string]int)
+	dependents := make(map[string][]string)
+	for name, dependencies := range graph {
+		pending[name] = len(dependencies)
+		for _, dependency := range dependencies {
+			dependents[dependency] = append(dependents[dependency], name)
+		}
+	}
+
+	var ready, sorted []addon.AddOn
+	for name, count := range pending {
+		if count == 0 {
+			ready = append(ready, m.addOns[name])
+		}
+	}
+
+	for len(ready) > 0 {
+		sort.Sort(addon.ByPriorityThenName(ready))
+		next := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, next)
+
+		for _, dependent := range dependents[next.MetaData().Name()] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, m.addOns[dependent])
+			}
+		}
+	}
+
+	if len(sorted) < len(graph) {
+		return nil, errors.New(fmt.Sprintf("Cyclic add-on dependency: %s", strings.Join(findCycle(graph, pending), " -> ")))
+	}
+	return sorted, nil
+}
+
+// findCycle returns a dependency cycle among the addons which could not be sorted, ie which still have pending dependencies.
+func findCycle(graph map[string][]string, pending map[string]int) []string {
+	var start string
+	for name, count := range pending {
+		if count > 0 && (start == "" || name < start) {
+			start = name
+		}
+	}
+
+	path := []string{start}
+	visited := map[string]int{start: 0}
+	for current := start; ; {
+		dependencies := append([]string{}, graph[current]...)
+		sort.Strings(dependencies)
+		for _, dependency := range dependencies {
+			if pending[dependency] > 0 {
+				current = dependency
+				break
+			}
+		}
+		if index, seen := visited[current]; seen {
+			return append(path[index:], current)
+		}
+		visited[current] = len(path)
+		path = append(path, current)
+	}
+}
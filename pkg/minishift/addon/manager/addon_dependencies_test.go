@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dependencies_are_ordered_before_dependents(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{
+		"app":    "base >= 1.0, db",
+		"db":     "base 1.2.0 || >= 1.3",
+		"base":   "",
+		"single": "",
+	}, map[string]string{"base": "1.2.0"}, map[string]float64{"single": 1, "app": 0})
+	defer cleanup()
+
+	addOns, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("single"), manager.Get("app")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"base", "db", "app", "single"}, addOnNames(addOns))
+}
+
+func Test_dependencies_are_ordered_by_priority(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{
+		"app": "b, a",
+		"a":   "",
+		"b":   "",
+	}, nil, map[string]float64{"a": 2, "b": 1})
+	defer cleanup()
+
+	addOns, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("app")})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "a", "app"}, addOnNames(addOns))
+}
+
+func Test_missing_dependency_returns_error(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{"app": "base, db"}, nil, nil)
+	defer cleanup()
+
+	_, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("app")})
+	assert.EqualError(t, err, "Dependent add-ons [base, db] of add-on 'app' not found for this instance. Please install them first.")
+}
+
+func Test_cyclic_dependencies_return_error(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{
+		"app": "a",
+		"a":   "b",
+		"b":   "a",
+	}, nil, nil)
+	defer cleanup()
+
+	_, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("app")})
+	assert.EqualError(t, err, "Cyclic add-on dependency: a -> b -> a")
+}
+
+func Test_unsatisfied_dependency_version_returns_error(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{
+		"app":  "base >= 2.0",
+		"base": "",
+		"db":   "unversioned >= 1.0",
+
+		"unversioned": "",
+	}, map[string]string{"base": "1.2.0"}, nil)
+	defer cleanup()
+
+	_, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("app")})
+	assert.EqualError(t, err, "Add-on 'app' requires 'base >= 2.0', but version '1.2.0' of add-on 'base' is installed")
+
+	_, err = manager.ResolveDependencies([]addon.AddOn{manager.Get("db")})
+	assert.EqualError(t, err, "Add-on 'db' requires 'unversioned >= 1.0', but add-on 'unversioned' does not specify an Addon-Version")
+}
+
+func Test_conflicting_dependency_versions_return_error(t *testing.T) {
+	manager, cleanup := createDependencyTestManager(t, map[string]string{
+		"a":    "base >= 1.0",
+		"b":    "base < 1.2",
+		"base": "",
+	}, map[string]string{"base": "1.2.0"}, nil)
+	defer cleanup()
+
+	_, err := manager.ResolveDependencies([]addon.AddOn{manager.Get("a"), manager.Get("b")})
+	assert.EqualError(t, err, "Conflicting requirements for add-on 'base' in version '1.2.0': 'a' requires 'base >= 1.0', 'b' requires 'base < 1.2'")
+}
+
+func createDependencyTestManager(t *testing.T, dependencies map[string]string, versions map[string]string, priorities map[string]float64) (*AddOnManager, func()) {
+	addOnFiles := make(map[string]string)
+	addOnConfigs := make(map[string]*config.AddOnConfig)
+	for name, dependsOn := range dependencies {
+		content := fmt.Sprintf("# Name: %s\n# Description: Add-on used to test dependency resolution\n", name)
+		if dependsOn != "" {
+			content += fmt.Sprintf("# Depends-On: %s\n", dependsOn)
+		}
+		if version, ok := versions[name]; ok {
+			content += fmt.Sprintf("# Addon-Version: %s\n", version)
+		}
+		content += "\necho " + name + "\n"
+
+		addOnFiles[filepath.Join(name, name+".addon")] = content
+		addOnConfigs[name] = &config.AddOnConfig{Name: name, Enabled: true, Priority: priorities[name]}
+	}
+
+	return createTestAddOnManager(t, addOnFiles, addOnConfigs)
+}
+
+func addOnNames(addOns []addon.AddOn) []string {
+	var names []string
+	for _, addOn := range addOns {
+		names = append(names, addOn.MetaData().Name())
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	instanceState "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/version"
+)
+
+const (
+	// maxHistoryRecords is the number of apply records kept per addon
+	maxHistoryRecords = 10
+	maskedValue       = "********"
+	noFailedCommand   = -1
+)
+
+var secretVariableRegexp = regexp.MustCompile(`(?i)(pass|secret|token|key|credential)`)
+
+// History returns the apply records of the addon with the specified name against the current instance, oldest first.
+func (m *AddOnManager) History(addonName string) []*config.AddOnApplyRecord {
+	if instanceState.InstanceStateConfig == nil {
+		return nil
+	}
+	return instanceState.InstanceStateConfig.AddOnHistory[addonName]
+}
+
+// LastRecord returns the latest apply record of the addon with the specified name. nil is returned if the addon
+// was never applied or removed against the current instance.
+func (m *AddOnManager) LastRecord(addonName string) *config.AddOnApplyRecord {
+	history := m.History(addonName)
+	if len(history) == 0 {
+		return nil
+	}
+	return history[len(history)-1]
+}
+
+// IsApplied returns true if the latest action recorded for the addon with the specified name is a successful
+// apply against the OpenShift version of the current instance.
+func (m *AddOnManager) IsApplied(addonName string) bool {
+	record := m.LastRecord(addonName)
+	if record == nil || record.Action != config.ApplyAction || !record.IsSuccess() {
+		return false
+	}
+	return record.OpenShiftVersion == currentOpenShiftVersion()
+}
+
+// recordAction adds a record for the specified action to the history of the addon and persists it in the instance state.
+// failedCommand is the index in commands of the failing command or -1 if the failure occurred prior to command execution.
+func recordAction(addOn addon.AddOn, action string, variables map[string]string, commands []command.Command, failedCommand int, err error) {
+	if instanceState.InstanceStateConfig == nil {
+		return
+	}
+
+	record := &config.AddOnApplyRecord{
+		Timestamp:        time.Now(),
+		Action:           action,
+		Outcome:          config.SuccessOutcome,
+		FailedCommand:    noFailedCommand,
+		Variables:        variables,
+		MinishiftVersion: version.GetMinishiftVersion(),
+		OpenShiftVersion: currentOpenShiftVersion(),
+	}
+	if err != nil {
+		record.Outcome = config.FailureOutcome
+		record.FailedCommand = failedCommand
+		if failedCommand >= 0 && failedCommand < len(commands) {
+			record.Command = commands[failedCommand].String()
+		}
+		record.Error = strings.TrimSpace(err.Error())
+	}
+
+	state := instanceState.InstanceStateConfig
+	if state.AddOnHistory == nil {
+		state.AddOnHistory = make(map[string][]*config.AddOnApplyRecord)
+	}
+	name := addOn.MetaData().Name()
+	history := append(state.AddOnHistory[name], record)
+	if len(history) > maxHistoryRecords {
+		history = history[len(history)-maxHistoryRecords:]
+	}
+	state.AddOnHistory[name] = history
+
+	if writeErr := state.Write(); writeErr != nil {
+		fmt.Println(fmt.Sprintf("Unable to record the state of addon '%s': %s", name, writeErr.Error()))
+	}
+}
+
+// contextVariables returns the values of all variables of the context. The values of variables which are declared as
+// secret or which look like they hold secrets are masked.
+func contextVariables(context *command.ExecutionContext, secrets map[string]bool) map[string]string {
+	variables := make(map[string]string)
+	for _, name := range context.Vars() {
+		if secrets[name] || secretVariableRegexp.MatchString(name) {
+			variables[name] = maskedValue
+			continue
+		}
+		variables[name] = context.Interpolate(fmt.Sprintf("#{%s}", name))
+	}
+	return variables
+}
+
+func currentOpenShiftVersion() string {
+	if instanceState.InstanceStateConfig == nil {
+		return ""
+	}
+	return strings.TrimPrefix(instanceState.InstanceStateConfig.OpenshiftVersion, constants.VersionPrefix)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	instanceState "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var recorded = `# Name: recorded
+# Description: Add-on used to test the apply history
+# Var-Defaults: DB_PASSWORD=secret,PROJECT=myproject
+
+echo Applying #{PROJECT}
+`
+
+var recordedRemove = `# Name: recorded
+# Description: Add-on used to test the apply history
+
+echo Removing #{PROJECT}
+`
+
+var failing = `# Name: failing
+# Description: Add-on failing in its second command
+
+echo first
+if #{UNDEFINED} == 1
+  echo never
+end
+`
+
+var historyAddOnFiles = map[string]string{
+	"recorded/recorded.addon":        recorded,
+	"recorded/recorded.addon.remove": recordedRemove,
+	"failing/failing.addon":          failing,
+}
+
+func Test_successful_apply_is_recorded(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, historyAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	err := manager.ApplyAddOn(manager.Get("recorded"), context)
+	assert.NoError(t, err)
+
+	record := manager.LastRecord("recorded")
+	assert.NotNil(t, record)
+	assert.Equal(t, config.ApplyAction, record.Action)
+	assert.Equal(t, config.SuccessOutcome, record.Outcome)
+	assert.Equal(t, -1, record.FailedCommand)
+	assert.Equal(t, "3.9.0", record.OpenShiftVersion)
+	assert.NotEmpty(t, record.MinishiftVersion)
+	assert.Equal(t, "myproject", record.Variables["PROJECT"])
+	assert.Equal(t, "********", record.Variables["DB_PASSWORD"])
+	assert.True(t, manager.IsApplied("recorded"))
+
+	// the history is persisted in the instance state
+	state, err := instanceState.NewInstanceStateConfig(instanceState.InstanceStateConfig.FilePath)
+	assert.NoError(t, err)
+	assert.Len(t, state.AddOnHistory["recorded"], 1)
+}
+
+func Test_failing_command_is_recorded(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, historyAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	err := manager.ApplyAddOn(manager.Get("failing"), context)
+	assert.Error(t, err)
+
+	record := manager.LastRecord("failing")
+	assert.Equal(t, config.FailureOutcome, record.Outcome)
+	assert.Equal(t, 1, record.FailedCommand)
+	assert.Equal(t, "if #{UNDEFINED} == 1", record.Command)
+	assert.Equal(t, err.Error(), record.Error)
+	assert.False(t, manager.IsApplied("failing"))
+}
+
+func Test_addon_is_no_longer_applied_after_removal_or_openshift_version_change(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, historyAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	manager.ApplyAddOn(manager.Get("recorded"), context)
+	assert.True(t, manager.IsApplied("recorded"))
+
+	instanceState.InstanceStateConfig.OpenshiftVersion = "v3.10.0"
+	assert.False(t, manager.IsApplied("recorded"))
+
+	manager.ApplyAddOn(manager.Get("recorded"), context)
+	assert.True(t, manager.IsApplied("recorded"))
+
+	manager.RemoveAddOn(manager.Get("recorded"), context)
+	assert.Equal(t, config.RemoveAction, manager.LastRecord("recorded").Action)
+	assert.False(t, manager.IsApplied("recorded"))
+}
+
+func Test_apply_skips_already_applied_addons_unless_forced(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, historyAddOnFiles, nil)
+	defer cleanup()
+	manager.Enable("recorded", 0)
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	assert.NoError(t, manager.Apply(context, false))
+	assert.Len(t, manager.History("recorded"), 1)
+
+	assert.NoError(t, manager.Apply(context, false))
+	assert.Len(t, manager.History("recorded"), 1)
+
+	assert.NoError(t, manager.Apply(context, true))
+	assert.Len(t, manager.History("recorded"), 2)
+}
+
+func Test_history_is_capped(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, historyAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	for i := 0; i < maxHistoryRecords+2; i++ {
+		manager.ApplyAddOn(manager.Get("recorded"), context)
+	}
+	assert.Len(t, manager.History("recorded"), maxHistoryRecords)
+}
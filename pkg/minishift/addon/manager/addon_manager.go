@@ -17,11 +17,11 @@ limitations under the License.
 package manager
 
 import (
+	"crypto"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"sort"
 
 	"strings"
 
@@ -31,6 +31,7 @@ import (
 	"github.com/minishift/minishift/pkg/minishift/addon/command"
 	"github.com/minishift/minishift/pkg/minishift/addon/config"
 	"github.com/minishift/minishift/pkg/minishift/addon/parser"
+	addOnSource "github.com/minishift/minishift/pkg/minishift/addon/source"
 	instanceState "github.com/minishift/minishift/pkg/minishift/config"
 	"github.com/minishift/minishift/pkg/util/filehelper"
 	utilStrings "github.com/minishift/minishift/pkg/util/strings"
@@ -38,13 +39,17 @@ import (
 	"github.com/pkg/errors"
 )
 
-const versionRangeSeparator = ","
+const (
+	addOnNameKey        = "addon-name"
+	openShiftVersionKey = "openshift-version"
+)
 
 // AddOnManager is the central point for all operations around managing addons. An addon
 // manager is created for the base directory of a addon collection.
 type AddOnManager struct {
-	baseDir string
-	addOns  map[string]addon.AddOn
+	baseDir    string
+	addOns     map[string]addon.AddOn
+	trustedKey crypto.PublicKey
 }
 
 // NewAddOnManager creates a new addon manager for the specified addon directory.
@@ -94,21 +99,29 @@ func (m *AddOnManager) List() []addon.AddOn {
 	return m.mapToSlice()
 }
 
-// Install installs the addon provided via the source directory into the addon directory managed by this addon manager.
+// Install installs the addon provided via source into the addon directory managed by this addon manager. The source
+// can be a local directory or archive, a file:// or http(s):// URL pointing to an archive or a git repository in the form
+// git+<repository-url>[#<ref>[:<subdir>]]. The origin of the addon is recorded, so that it can be updated later on.
 // It returns the name of the installed addon. In case an error occurs the empty string and an error are returned.
 func (m *AddOnManager) Install(source string, force bool) (string, error) {
-	// For now we are assuming that we are dealing with a file path. This can be extended to support URLs, etc as well (HF)
-	if !filehelper.IsDirectory(source) {
-		return "", errors.New(fmt.Sprintf("The source of a addon needs to be a directory. '%s' is not", source))
+	stagingDir, err := ioutil.TempDir("", "minishift-addon-")
+	if err != nil {
+		return "", errors.Wrap(err, "Unable to create staging directory for addon")
+	}
+	defer os.RemoveAll(stagingDir)
+
+	addOnDir, origin, err := addOnSource.FetchVerified(source, stagingDir, m.trustedKey)
+	if err != nil {
+		return "", err
 	}
 
 	p := parser.NewAddOnParser()
-	addOn, err := p.Parse(source)
+	addOn, err := p.Parse(addOnDir)
 	if err != nil {
 		return "", errors.Wrap(err, "Unable to parse specified addon")
 	}
 
-	targetPath := filepath.Join(m.baseDir, filepath.Base(source))
+	targetPath := filepath.Join(m.baseDir, addOn.MetaData().Name())
 	if filehelper.IsDirectory(targetPath) {
 		if force {
 			os.RemoveAll(targetPath)
@@ -117,23 +130,70 @@ func (m *AddOnManager) Install(source string, force bool) (string, error) {
 		}
 	}
 
-	filehelper.CopyDir(source, targetPath)
+	if err := filehelper.CopyDir(addOnDir, targetPath); err != nil {
+		return "", errors.Wrapf(err, "Unable to copy addon to target directory '%s'", targetPath)
+	}
+	if err := addOnSource.WriteOrigin(targetPath, origin); err != nil {
+		return "", errors.Wrap(err, "Unable to record the origin of the addon")
+	}
+
 	return addOn.MetaData().Name(), nil
 }
 
+// SetTrustedKey configures the public key which signatures of add-on bundles are verified against. Once a trusted key
+// is set, only signed bundles can be installed.
+func (m *AddOnManager) SetTrustedKey(key crypto.PublicKey) {
+	m.trustedKey = key
+}
+
+// Update re-installs the addon with the specified name from the source it was originally installed from.
+// It returns the origin of the updated addon.
+func (m *AddOnManager) Update(addonName string) (*addOnSource.Origin, error) {
+	origin, err := m.Origin(addonName)
+	if err != nil {
+		return nil, err
+	}
+	if origin == nil {
+		return nil, errors.New(fmt.Sprintf("Unable to update addon '%s'. The source of the addon is unknown", addonName))
+	}
+
+	name, err := m.Install(origin.Source, true)
+	if err != nil {
+		return nil, err
+	}
+	if name != addonName {
+		return nil, errors.New(fmt.Sprintf("The source '%s' now provides addon '%s' instead of '%s'", origin.Source, name, addonName))
+	}
+
+	return m.Origin(addonName)
+}
+
+// Origin returns the recorded origin of the addon with the specified name. nil is returned if the origin is unknown.
+func (m *AddOnManager) Origin(addonName string) (*addOnSource.Origin, error) {
+	if !m.IsInstalled(addonName) {
+		return nil, errors.New(fmt.Sprintf("Unable to find addon '%s' in addon directory '%s'", addonName, m.baseDir))
+	}
+
+	return addOnSource.ReadOrigin(filepath.Join(m.baseDir, addonName))
+}
+
 // Get returns the addon with the specified name. nil is returned if there is no addon with this name.
 func (m *AddOnManager) Get(name string) addon.AddOn {
 	return m.addOns[name]
 }
 
 // Enable enables the addon specified via addonName to be run during startup. The priority determines when the addon is run in relation
-// the other addons.
+// the other addons, unless the addons depend on each other. An error is returned if the dependencies of the addon cannot be resolved.
 func (m *AddOnManager) Enable(addonName string, priority int) (*config.AddOnConfig, error) {
 	addOn := m.addOns[addonName]
 	if addOn == nil {
 		return nil, errors.New(fmt.Sprintf("Unable to find addon '%s' in addon directory '%s'", addonName, m.baseDir))
 	}
 
+	if _, err := m.ResolveDependencies([]addon.AddOn{addOn}); err != nil {
+		return nil, err
+	}
+
 	addOn.SetEnabled(true)
 	addOn.SetPriority(priority)
 
@@ -169,17 +229,33 @@ func (m *AddOnManager) Disable(addonName string) (*config.AddOnConfig, error) {
 	return &config.AddOnConfig{addonName, false, float64(addOn.GetPriority())}, nil
 }
 
-// Apply executes all enabled addons.
-func (m *AddOnManager) Apply(context *command.ExecutionContext) error {
-	addOns := m.mapToSlice()
-	sort.Sort(addon.ByPriority(addOns))
+// Apply executes all enabled addons together with the addons they depend on. Each addon is applied after its
+// dependencies, otherwise the priority determines the order. Unless force is set, addons which are already applied
+// successfully against the OpenShift version of the current instance are skipped.
+func (m *AddOnManager) Apply(context *command.ExecutionContext, force bool) error {
+	var enabled []addon.AddOn
+	for _, addOn := range m.mapToSlice() {
+		if addOn.IsEnabled() {
+			enabled = append(enabled, addOn)
+		}
+	}
+
+	addOns, err := m.ResolveDependencies(enabled)
+	if err != nil {
+		return err
+	}
 
 	for _, addOn := range addOns {
-		if addOn.IsEnabled() {
-			err := m.ApplyAddOn(addOn, context)
-			if err != nil {
-				return err
-			}
+		if !force && m.IsApplied(addOn.MetaData().Name()) {
+			fmt.Println(fmt.Sprintf("-- Skipping addon '%s': Already applied", addOn.MetaData().Name()))
+			continue
+		}
+		if !addOn.IsEnabled() {
+			fmt.Println(fmt.Sprintf("-- Addon '%s' is not enabled, but required by an enabled addon", addOn.MetaData().Name()))
+		}
+		err := m.ApplyAddOn(addOn, context)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -194,76 +270,123 @@ func (m *AddOnManager) String() string {
 	return fmt.Sprintf("%#v", m)
 }
 
+// ApplyAddOn executes the commands of the specified addon. The outcome is recorded in the apply history of the addon.
 func (m *AddOnManager) ApplyAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
+	_, err := m.applyAddOnWithRollback(addOn, context, false)
+	return err
+}
+
+// ApplyAddOnWithRollback executes the commands of the specified addon like ApplyAddOn. If one of the commands fails,
+// the already executed commands are rolled back, either via their undo annotations or, if the addon does not
+// contain any, via the remove commands of the addon. The returned report describes the rollback. It is nil if
+// the addon got applied successfully or failed before any of its commands got executed.
+func (m *AddOnManager) ApplyAddOnWithRollback(addOn addon.AddOn, context *command.ExecutionContext) (*RollbackReport, error) {
+	return m.applyAddOnWithRollback(addOn, context, true)
+}
+
+func (m *AddOnManager) applyAddOnWithRollback(addOn addon.AddOn, context *command.ExecutionContext, rollback bool) (*RollbackReport, error) {
 	fmt.Print(fmt.Sprintf("-- Applying addon '%s':", addOn.MetaData().Name()))
-	context.AddToContext("addon-name", addOn.MetaData().Name())
-	defer context.RemoveFromContext("addon-name")
+	context.AddToContext(addOnNameKey, addOn.MetaData().Name())
+	defer context.RemoveFromContext(addOnNameKey)
+	addOpenShiftVersionToContext(context)
+	defer context.RemoveFromContext(openShiftVersionKey)
+
+	failedCommand, err := m.applyAddOn(addOn, context)
+	recordAction(addOn, config.ApplyAction, contextVariables(context, secretVariables(addOn)), addOn.Commands(), failedCommand, err)
+	if err == nil || !rollback || failedCommand == noFailedCommand {
+		return nil, err
+	}
 
+	return m.rollback(addOn, context, failedCommand), err
+}
+
+func (m *AddOnManager) applyAddOn(addOn addon.AddOn, context *command.ExecutionContext) (int, error) {
 	if err := addVarDefaultsToContext(addOn, context); err != nil {
-		return err
+		return noFailedCommand, err
 	}
 
 	addonMetadata := addOn.MetaData()
 	if err := verifyRequiredOpenshiftVersion(addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
 	}
 	if err := verifyRequiredMinishiftVersion(addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
+	}
+	if err := promptForMissingVariables(context, addonMetadata); err != nil {
+		return noFailedCommand, err
 	}
 	if err := verifyRequiredVariablesInContext(context, addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
+	}
+	if err := verifyDeclaredVariablesInContext(context, addonMetadata); err != nil {
+		return noFailedCommand, err
 	}
 	if err := m.verifyRequiredAddons(addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
 	}
 
 	oldDir, err := os.Getwd()
 	if err != nil {
-		return errors.Wrap(err, "Unable to apply addon due to failing IO operation")
+		return noFailedCommand, errors.Wrap(err, "Unable to apply addon due to failing IO operation")
 	}
 	defer os.Chdir(oldDir)
 
 	os.Chdir(addOn.InstallPath())
-	if err := addonCmdExecution(addOn.Commands(), context); err != nil {
-		return err
+	if failedCommand, err := addonCmdExecution(addOn.Commands(), context); err != nil {
+		return failedCommand, err
 	}
 
 	fmt.Print("\n")
-	return nil
+	return noFailedCommand, nil
 }
 
+// RemoveAddOn executes the remove commands of the specified addon. The outcome is recorded in the apply history of the addon.
 func (m *AddOnManager) RemoveAddOn(addOn addon.AddOn, context *command.ExecutionContext) error {
 	fmt.Print(fmt.Sprintf("-- Removing addon '%s':", addOn.MetaData().Name()))
-	context.AddToContext("addon-name", addOn.MetaData().Name())
-	defer context.RemoveFromContext("addon-name")
+	context.AddToContext(addOnNameKey, addOn.MetaData().Name())
+	defer context.RemoveFromContext(addOnNameKey)
+	addOpenShiftVersionToContext(context)
+	defer context.RemoveFromContext(openShiftVersionKey)
 
+	failedCommand, err := m.removeAddOn(addOn, context)
+	recordAction(addOn, config.RemoveAction, contextVariables(context, secretVariables(addOn)), addOn.RemoveCommands(), failedCommand, err)
+	return err
+}
+
+func (m *AddOnManager) removeAddOn(addOn addon.AddOn, context *command.ExecutionContext) (int, error) {
 	if err := addVarDefaultsToContext(addOn, context); err != nil {
-		return err
+		return noFailedCommand, err
 	}
 
 	addonMetadata := addOn.MetaDataForAddonRemove()
 	if err := verifyRequiredOpenshiftVersion(addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
 	}
 	if err := verifyRequiredMinishiftVersion(addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
+	}
+	if err := promptForMissingVariables(context, addonMetadata); err != nil {
+		return noFailedCommand, err
 	}
 	if err := verifyRequiredVariablesInContext(context, addonMetadata); err != nil {
-		return err
+		return noFailedCommand, err
+	}
+	if err := verifyDeclaredVariablesInContext(context, addonMetadata); err != nil {
+		return noFailedCommand, err
 	}
 
 	oldDir, err := os.Getwd()
 	if err != nil {
-		return errors.Wrap(err, "Unable to remove addon due to failing IO operation")
+		return noFailedCommand, errors.Wrap(err, "Unable to remove addon due to failing IO operation")
 	}
 	defer os.Chdir(oldDir)
 
 	os.Chdir(addOn.InstallPath())
-	if err := addonCmdExecution(addOn.RemoveCommands(), context); err != nil {
-		return err
+	if failedCommand, err := addonCmdExecution(addOn.RemoveCommands(), context); err != nil {
+		return failedCommand, err
 	}
 	fmt.Print("\n")
-	return nil
+	return noFailedCommand, nil
 }
 
 func (m *AddOnManager) mapToSlice() []addon.AddOn {
@@ -304,47 +427,39 @@ func verifyRequiredVariablesInContext(context *command.ExecutionContext, meta ad
 
 func verifyRequiredOpenshiftVersion(meta addon.AddOnMeta) error {
 	openShiftVersion := strings.TrimPrefix(instanceState.InstanceStateConfig.OpenshiftVersion, constants.VersionPrefix)
-	requiredOpenshiftVersions := strings.TrimSpace(meta.OpenShiftVersion())
-	if requiredOpenshiftVersions != "" {
-		for _, requiredOpenshiftVersion := range strings.Split(requiredOpenshiftVersions, versionRangeSeparator) {
-			if err := compareComponentVersions(openShiftVersion, strings.TrimSpace(requiredOpenshiftVersion), "OpenShift", meta.OpenShiftVersion()); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return verifyComponentVersion(openShiftVersion, meta.OpenShiftVersion(), "OpenShift")
 }
 
 func verifyRequiredMinishiftVersion(meta addon.AddOnMeta) error {
 	minishiftVersionWithHash := strings.TrimPrefix(version.GetMinishiftVersion(), constants.VersionPrefix)
 	minishiftVersion := strings.Split(minishiftVersionWithHash, "+")[0]
-	requiredMinishiftVersions := strings.TrimSpace(meta.MinishiftVersion())
-	if requiredMinishiftVersions != "" {
-		for _, requiredMinishiftVersion := range strings.Split(requiredMinishiftVersions, versionRangeSeparator) {
-			if err := compareComponentVersions(minishiftVersion, strings.TrimSpace(requiredMinishiftVersion), "Minishift", meta.MinishiftVersion()); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return verifyComponentVersion(minishiftVersion, meta.MinishiftVersion(), "Minishift")
 }
 
+// verifyRequiredAddons verifies that the add-ons the specified add-on depends on are installed in a version
+// matching the required version range.
 func (m *AddOnManager) verifyRequiredAddons(metadata addon.AddOnMeta) error {
+	dependencies, err := metadata.Dependencies()
+	if err != nil {
+		return err
+	}
+
 	var depsNotInstalled []string
-	dependencies, err := metadata.Dependency()
-	if err == nil && len(dependencies) > 0 {
-		for _, dep := range dependencies {
-			if m.IsInstalled(dep) {
-				continue
-			}
-			depsNotInstalled = append(depsNotInstalled, dep)
+	for _, dep := range dependencies {
+		if !m.IsInstalled(dep.Name) {
+			depsNotInstalled = append(depsNotInstalled, dep.Name)
 		}
-		if len(depsNotInstalled) != 0 {
-			return errors.New(fmt.Sprintf("Dependent add-ons [%s] not found for this instance. Please install and apply them before running this add-on.", strings.Join(depsNotInstalled, ", ")))
+	}
+	if len(depsNotInstalled) != 0 {
+		return errors.New(fmt.Sprintf("Dependent add-ons [%s] not found for this instance. Please install and apply them before running this add-on.", strings.Join(depsNotInstalled, ", ")))
+	}
+
+	for _, dep := range dependencies {
+		if err := m.verifyDependencyVersion(metadata.Name(), dep); err != nil {
+			return err
 		}
 	}
-	return err
+	return nil
 }
 
 func setStateAndPriority(addOn addon.AddOn, configMap map[string]*config.AddOnConfig) {
@@ -356,70 +471,24 @@ func setStateAndPriority(addOn addon.AddOn, configMap map[string]*config.AddOnCo
 	addOn.SetPriority(int(addOnConfig.Priority))
 }
 
-func compareComponentVersions(currentVersion, requiredVersion string, componentName string, versionRange string) error {
-	if strings.HasPrefix(requiredVersion, ">=") {
-		currentVersion, err := semver.Make(currentVersion)
-		if err != nil {
-			return err
-		}
-		requiredVersion, err := semver.Make(strings.TrimPrefix(requiredVersion, ">="))
-		if err != nil {
-			return err
-		}
-		if currentVersion.GE(requiredVersion) != true {
-			return fmt.Errorf("\nAdd-on does not support %s version %s. "+
-				"You need to use a version %s", componentName, currentVersion, versionRange)
-		}
-		return err
-	}
-	if strings.HasPrefix(requiredVersion, ">") {
-		currentVersion, err := semver.Make(currentVersion)
-		if err != nil {
-			return err
-		}
-		requiredVersion, err := semver.Make(strings.TrimPrefix(requiredVersion, ">"))
-		if err != nil {
-			return err
-		}
-		if currentVersion.GT(requiredVersion) != true {
-			return fmt.Errorf("\nAdd-on does not support %s version %s. "+
-				"You need to use a version %s", componentName, currentVersion, versionRange)
-		}
-		return err
+// verifyComponentVersion verifies that currentVersion lies within the specified version range. An empty version
+// range matches any version.
+func verifyComponentVersion(currentVersion string, versionRange string, componentName string) error {
+	if strings.TrimSpace(versionRange) == "" {
+		return nil
 	}
-	if strings.HasPrefix(requiredVersion, "<=") {
-		currentVersion, err := semver.Make(currentVersion)
-		if err != nil {
-			return err
-		}
-		requiredVersion, err := semver.Make(strings.TrimPrefix(requiredVersion, "<="))
-		if err != nil {
-			return err
-		}
-		if currentVersion.LE(requiredVersion) != true {
-			return fmt.Errorf("\nAdd-on does not support %s version %s. "+
-				"You need to use a version %s", componentName, currentVersion, versionRange)
-		}
+
+	requiredVersions, err := addon.ParseVersionRange(versionRange)
+	if err != nil {
 		return err
 	}
-	if strings.HasPrefix(requiredVersion, "<") {
-		currentVersion, err := semver.Make(currentVersion)
-		if err != nil {
-			return err
-		}
-		requiredVersion, err := semver.Make(strings.TrimPrefix(requiredVersion, "<"))
-		if err != nil {
-			return err
-		}
-		if currentVersion.LT(requiredVersion) != true {
-			return fmt.Errorf("\nAdd-on does not support %s version %s. "+
-				"You need to use a version %s", componentName, currentVersion, versionRange)
-		}
+	current, err := semver.ParseTolerant(currentVersion)
+	if err != nil {
 		return err
 	}
-	if currentVersion != requiredVersion {
-		return fmt.Errorf("\nAddon does not support %s version %s. "+
-			"You need to use a version %s", componentName, currentVersion, requiredVersion)
+	if !requiredVersions.Contains(current) {
+		return fmt.Errorf("\nAdd-on does not support %s version %s. "+
+			"You need to use a version %s", componentName, current, versionRange)
 	}
 
 	return nil
@@ -438,15 +507,36 @@ func addVarDefaultsToContext(addOn addon.AddOn, context *command.ExecutionContex
 		}
 	}
 
+	variables, err := addOn.MetaData().Variables()
+	if err != nil {
+		return err
+	}
+	for _, variable := range variables {
+		if variable.HasDefault && !utilStrings.Contains(context.Vars(), variable.Name) {
+			context.AddToContext(variable.Name, variable.Default)
+		}
+	}
+
 	return nil
 }
 
-func addonCmdExecution(commands []command.Command, context *command.ExecutionContext) error {
-	for _, c := range commands {
+// addOpenShiftVersionToContext makes the version of the running OpenShift cluster available as variable, eg to be used
+// in the conditions of if statements
+func addOpenShiftVersionToContext(context *command.ExecutionContext) {
+	if instanceState.InstanceStateConfig == nil || instanceState.InstanceStateConfig.OpenshiftVersion == "" {
+		return
+	}
+	context.AddToContext(openShiftVersionKey, instanceState.InstanceStateConfig.OpenshiftVersion)
+}
+
+// addonCmdExecution executes the specified commands in order. In case of an error the index of the failing
+// command is returned.
+func addonCmdExecution(commands []command.Command, context *command.ExecutionContext) (int, error) {
+	for i, c := range commands {
 		if err := c.Execute(context); err != nil {
-			return err
+			return i, err
 		}
 	}
 
-	return nil
+	return noFailedCommand, nil
 }
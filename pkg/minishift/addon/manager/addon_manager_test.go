@@ -109,6 +109,41 @@ func Test_invalid_addons_get_skipped(t *testing.T) {
 	assert.Len(t, addOns, expectedNumberOfAddOns)
 }
 
+func Test_update_reinstalls_addon_from_its_origin(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-manager-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	sourceDir := filepath.Join(testDir, "source", "anyuid")
+	err = os.MkdirAll(sourceDir, 0777)
+	assert.NoError(t, err, "Error in creating directory for addon")
+	err = ioutil.WriteFile(filepath.Join(sourceDir, "anyuid.addon"), []byte(anyuid), 0777)
+	assert.NoError(t, err, "Error in writing to addon file")
+
+	addOnDir := filepath.Join(testDir, "addons")
+	err = os.Mkdir(addOnDir, 0777)
+	assert.NoError(t, err, "Error in creating addon directory")
+
+	manager, err := NewAddOnManager(addOnDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error in getting addon manager")
+	name, err := manager.Install(sourceDir, false)
+	assert.NoError(t, err, "Error installing addon")
+	assert.Equal(t, "anyuid", name)
+
+	// change the source and update the installed addon from it
+	err = ioutil.WriteFile(filepath.Join(sourceDir, "README.md"), []byte("anyuid"), 0777)
+	assert.NoError(t, err, "Error in writing to addon source")
+
+	manager, err = NewAddOnManager(addOnDir, make(map[string]*config.AddOnConfig))
+	assert.NoError(t, err, "Error in getting addon manager")
+	origin, err := manager.Update("anyuid")
+	assert.NoError(t, err, "Error updating addon")
+	assert.Equal(t, sourceDir, origin.Source)
+
+	_, err = os.Stat(filepath.Join(addOnDir, "anyuid", "README.md"))
+	assert.NoError(t, err, "Updated addon should contain the changed source")
+}
+
 func TestAddVarDefaultsToContext(t *testing.T) {
 	context, _ := command.NewExecutionContext(nil, nil)
 	expectedVarName := "FOO"
@@ -278,3 +313,36 @@ func getTestAddonMap(name, description, requireVar, varDefault, openshiftVersion
 	}
 	return testAddonMap
 }
+
+// createTestAddOnManager writes the given add-on files, keyed by their path relative to the add-ons directory, to a
+// temporary directory and creates an add-on manager for them. The instance state is replaced by a fresh one for an
+// OpenShift v3.9.0 cluster. The returned function removes the directory and restores the previous instance state.
+func createTestAddOnManager(t *testing.T, addOnFiles map[string]string, addOnConfigs map[string]*config.AddOnConfig) (*AddOnManager, func()) {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-manager-")
+	assert.NoError(t, err, "Error creating temp directory")
+
+	addOnsDir := filepath.Join(testDir, "addons")
+	for file, content := range addOnFiles {
+		path := filepath.Join(addOnsDir, file)
+		err = os.MkdirAll(filepath.Dir(path), 0777)
+		assert.NoError(t, err, "Error in creating directory for addon")
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		assert.NoError(t, err, "Error in writing addon file")
+	}
+
+	originalInstanceState := instanceState.InstanceStateConfig
+	instanceState.InstanceStateConfig, err = instanceState.NewInstanceStateConfig(filepath.Join(testDir, "state.json"))
+	assert.NoError(t, err, "Error creating instance state config")
+	instanceState.InstanceStateConfig.OpenshiftVersion = "v3.9.0"
+
+	if addOnConfigs == nil {
+		addOnConfigs = make(map[string]*config.AddOnConfig)
+	}
+	manager, err := NewAddOnManager(addOnsDir, addOnConfigs)
+	assert.NoError(t, err, "Error in getting addon manager")
+
+	return manager, func() {
+		instanceState.InstanceStateConfig = originalInstanceState
+		os.RemoveAll(testDir)
+	}
+}
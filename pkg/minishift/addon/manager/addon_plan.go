@@ -0,0 +1,261 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	instanceState "github.com/minishift/minishift/pkg/minishift/config"
+)
+
+const (
+	applyAction  = "applying"
+	removeAction = "removing"
+	planIndent   = "  "
+)
+
+var placeholderRegexp = regexp.MustCompile(`#\{([^}]+)\}`)
+
+// Plan describes the effect of applying or removing an addon without executing any of its commands.
+type Plan struct {
+	Name     string
+	Action   string
+	Steps    []string
+	Problems []string
+	Warnings []string
+}
+
+// HasProblems returns true if applying or removing the addon as planned is known to fail.
+func (p *Plan) HasProblems() bool {
+	return len(p.Problems) > 0
+}
+
+func (p *Plan) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("-- Plan for %s addon '%s':\n", p.Action, p.Name))
+	for _, step := range p.Steps {
+		buffer.WriteString(fmt.Sprintf("   %s\n", step))
+	}
+	for _, warning := range p.Warnings {
+		buffer.WriteString(fmt.Sprintf("   WARNING: %s\n", warning))
+	}
+	for _, problem := range p.Problems {
+		buffer.WriteString(fmt.Sprintf("   ERROR: %s\n", problem))
+	}
+	return buffer.String()
+}
+
+func (p *Plan) addProblem(problem string) {
+	problem = strings.TrimSpace(problem)
+	for _, existing := range p.Problems {
+		if existing == problem {
+			return
+		}
+	}
+	p.Problems = append(p.Problems, problem)
+}
+
+// PlanApply creates the plan for applying the specified addon. The commands of the addon are interpolated using
+// the specified context, but not executed.
+func (m *AddOnManager) PlanApply(addOn addon.AddOn, context *command.ExecutionContext) *Plan {
+	plan := &Plan{Name: addOn.MetaData().Name(), Action: applyAction}
+	m.planAddOn(plan, addOn, addOn.MetaData(), addOn.Commands(), context)
+
+	if err := m.verifyRequiredAddons(addOn.MetaData()); err != nil {
+		plan.addProblem(err.Error())
+	}
+	return plan
+}
+
+// PlanRemove creates the plan for removing the specified addon. The remove commands of the addon are interpolated using
+// the specified context, but not executed.
+func (m *AddOnManager) PlanRemove(addOn addon.AddOn, context *command.ExecutionContext) *Plan {
+	plan := &Plan{Name: addOn.MetaData().Name(), Action: removeAction}
+	if addOn.MetaDataForAddonRemove() == nil {
+		plan.addProblem(fmt.Sprintf("No %s.addon.remove file is found", plan.Name))
+		return plan
+	}
+
+	m.planAddOn(plan, addOn, addOn.MetaDataForAddonRemove(), addOn.RemoveCommands(), context)
+	return plan
+}
+
+// PlanEnabled creates the plans for all enabled addons and the addons they depend on in the order in which they get
+// applied during start-up.
+func (m *AddOnManager) PlanEnabled(context *command.ExecutionContext) []*Plan {
+	var enabled []addon.AddOn
+	for _, addOn := range m.mapToSlice() {
+		if addOn.IsEnabled() {
+			enabled = append(enabled, addOn)
+		}
+	}
+
+	addOns, resolveErr := m.ResolveDependencies(enabled)
+	if resolveErr != nil {
+		addOns = enabled
+		sort.Sort(addon.ByPriorityThenName(addOns))
+	}
+
+	var plans []*Plan
+	for _, addOn := range addOns {
+		plan := m.PlanApply(addOn, context)
+		if resolveErr != nil {
+			plan.addProblem(resolveErr.Error())
+		}
+		if !addOn.IsEnabled() {
+			plan.Warnings = append(plan.Warnings, "The addon is not enabled, but required by an enabled addon")
+		}
+		if m.IsApplied(addOn.MetaData().Name()) {
+			plan.Warnings = append(plan.Warnings, "The addon is already applied and gets skipped during start-up")
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+func (m *AddOnManager) planAddOn(plan *Plan, addOn addon.AddOn, meta addon.AddOnMeta, commands []command.Command, context *command.ExecutionContext) {
+	context.AddToContext(addOnNameKey, addOn.MetaData().Name())
+	defer context.RemoveFromContext(addOnNameKey)
+	addOpenShiftVersionToContext(context)
+	defer context.RemoveFromContext(openShiftVersionKey)
+
+	if err := addVarDefaultsToContext(addOn, context); err != nil {
+		plan.addProblem(err.Error())
+	}
+
+	if dependencies, err := meta.Dependencies(); err == nil && len(dependencies) > 0 {
+		var required []string
+		for _, dependency := range dependencies {
+			required = append(required, dependency.String())
+		}
+		plan.Steps = append(plan.Steps, fmt.Sprintf("# Depends on: %s", strings.Join(required, ", ")))
+	}
+
+	if meta.OpenShiftVersion() != "" && (instanceState.InstanceStateConfig == nil || instanceState.InstanceStateConfig.OpenshiftVersion == "") {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("The OpenShift version is unknown. Unable to verify the required OpenShift version %s", meta.OpenShiftVersion()))
+	} else if err := verifyRequiredOpenshiftVersion(meta); err != nil {
+		plan.addProblem(err.Error())
+	}
+	if err := verifyRequiredMinishiftVersion(meta); err != nil {
+		plan.addProblem(err.Error())
+	}
+	if err := verifyRequiredVariablesInContext(context, meta); err != nil {
+		plan.addProblem(err.Error())
+	}
+	if err := verifyDeclaredVariablesInContext(context, meta); err != nil {
+		plan.addProblem(err.Error())
+	}
+
+	planner := &commandPlanner{plan: plan, context: context, runtimeVars: make(map[string]bool)}
+	planner.walk(commands, 0)
+}
+
+// commandPlanner interpolates a list of commands into the steps of a plan. Variables which only get defined while
+// the addon gets applied, ie output and loop variables, are kept as placeholders.
+type commandPlanner struct {
+	plan        *Plan
+	context     *command.ExecutionContext
+	runtimeVars map[string]bool
+}
+
+func (p *commandPlanner) walk(commands []command.Command, depth int) {
+	indent := strings.Repeat(planIndent, depth)
+	for _, c := range commands {
+		switch blockCommand := c.(type) {
+		case *command.IfCommand:
+			condition := p.interpolate(blockCommand.Condition())
+			p.addStep(indent, fmt.Sprintf("if %s%s", condition, p.evaluate(condition)))
+			p.walk(blockCommand.Then(), depth+1)
+			if len(blockCommand.Else()) > 0 {
+				p.addStep(indent, "else")
+				p.walk(blockCommand.Else(), depth+1)
+			}
+			p.addStep(indent, "end")
+		case *command.ForEachCommand:
+			items := make([]string, 0, len(blockCommand.Items()))
+			for _, item := range blockCommand.Items() {
+				items = append(items, p.interpolate(item))
+			}
+			p.addStep(indent, fmt.Sprintf("foreach %s in %s", blockCommand.Variable(), strings.Join(items, ", ")))
+			shadowed := p.runtimeVars[blockCommand.Variable()]
+			p.runtimeVars[blockCommand.Variable()] = true
+			p.walk(blockCommand.Body(), depth+1)
+			p.runtimeVars[blockCommand.Variable()] = shadowed
+			p.addStep(indent, "end")
+		case *command.UndoableCommand:
+			p.addStep(indent, p.describe(blockCommand.Command()))
+			p.addStep(indent, fmt.Sprintf("undo %s", p.interpolate(blockCommand.Undo().String())))
+		case *command.UntilCommand:
+			p.addStep(indent, fmt.Sprintf("until %s (every %s)", blockCommand.Timeout(), blockCommand.Interval()))
+			p.walk(blockCommand.Body(), depth+1)
+			p.addStep(indent, "end")
+		default:
+			p.addStep(indent, p.describe(c))
+		}
+	}
+}
+
+// describe returns the interpolated representation of a single command including its modifiers
+func (p *commandPlanner) describe(c command.Command) string {
+	step := p.interpolate(c.String())
+	if withOutput, ok := c.(interface {
+		OutputVariable() string
+	}); ok && withOutput.OutputVariable() != "" {
+		step = fmt.Sprintf("%s := %s", withOutput.OutputVariable(), step)
+		p.runtimeVars[withOutput.OutputVariable()] = true
+	}
+	if withIgnore, ok := c.(interface {
+		IgnoreError() bool
+	}); ok && withIgnore.IgnoreError() {
+		step = "!" + step
+	}
+	return step
+}
+
+// interpolate interpolates s and reports all placeholders which cannot be resolved
+func (p *commandPlanner) interpolate(s string) string {
+	interpolated := p.context.Interpolate(s)
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(interpolated, -1) {
+		if !p.runtimeVars[match[1]] {
+			p.plan.addProblem(fmt.Sprintf("Unresolved placeholder '%s' in '%s'", match[0], s))
+		}
+	}
+	return interpolated
+}
+
+// evaluate returns a hint on the outcome of an interpolated condition
+func (p *commandPlanner) evaluate(condition string) string {
+	if placeholderRegexp.MatchString(condition) {
+		return " [evaluated at runtime]"
+	}
+	result, err := command.EvaluateCondition(condition)
+	if err != nil {
+		p.plan.addProblem(err.Error())
+		return ""
+	}
+	return fmt.Sprintf(" [%t]", result)
+}
+
+func (p *commandPlanner) addStep(indent string, step string) {
+	p.plan.Steps = append(p.plan.Steps, indent+step)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/stretchr/testify/assert"
+)
+
+var planned = `# Name: planned
+# Description: Add-on used to test planning
+# Required-Vars: PROJECT
+# Depends-On: anyuid
+
+token := oc sa get-token default
+if #{PROJECT} == myproject
+  foreach name in a, b
+    !oc create secret generic #{name} --from-literal=token=#{token} -n #{PROJECT}
+  end
+end
+oc expose svc/foo --hostname=foo.#{routing-suffix} #{unknown}
+`
+
+var plannedRemove = `# Name: planned
+# Description: Add-on used to test planning
+
+oc delete project #{PROJECT}
+`
+
+var plannedAddOnFiles = map[string]string{
+	"planned/planned.addon":        planned,
+	"planned/planned.addon.remove": plannedRemove,
+}
+
+func Test_plan_apply_interpolates_commands_without_executing_them(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, plannedAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, nil)
+	context.AddToContext("PROJECT", "myproject")
+	context.AddToContext("routing-suffix", "192.168.99.100.nip.io")
+
+	plan := manager.PlanApply(manager.Get("planned"), context)
+
+	expectedSteps := []string{
+		"# Depends on: anyuid",
+		"token := oc sa get-token default",
+		"if myproject == myproject [true]",
+		"  foreach name in a, b",
+		"    !oc create secret generic #{name} --from-literal=token=#{token} -n myproject",
+		"  end",
+		"end",
+		"oc expose svc/foo --hostname=foo.192.168.99.100.nip.io #{unknown}",
+	}
+	assert.Equal(t, expectedSteps, plan.Steps)
+
+	expectedProblems := []string{
+		"Unresolved placeholder '#{unknown}' in 'oc expose svc/foo --hostname=foo.#{routing-suffix} #{unknown}'",
+		"Dependent add-ons [anyuid] not found for this instance. Please install and apply them before running this add-on.",
+	}
+	assert.Equal(t, expectedProblems, plan.Problems)
+	assert.True(t, plan.HasProblems())
+}
+
+func Test_plan_apply_reports_missing_variables(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, plannedAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, nil)
+	plan := manager.PlanApply(manager.Get("planned"), context)
+
+	assert.Contains(t, plan.Problems, "The variable(s) 'PROJECT' are required by the add-on, but are not defined in the context")
+	assert.Contains(t, plan.Steps, "if #{PROJECT} == myproject [evaluated at runtime]")
+}
+
+func Test_plan_remove_uses_remove_commands(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, plannedAddOnFiles, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, nil)
+	context.AddToContext("PROJECT", "myproject")
+	plan := manager.PlanRemove(manager.Get("planned"), context)
+
+	assert.Equal(t, []string{"oc delete project myproject"}, plan.Steps)
+	assert.False(t, plan.HasProblems())
+	assert.Equal(t, "-- Plan for removing addon 'planned':\n   oc delete project myproject\n", plan.String())
+}
+
+func Test_plan_apply_lists_undo_annotations(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, map[string]string{"undoable/undoable.addon": undoable}, nil)
+	defer cleanup()
+
+	context, _ := command.NewExecutionContext(nil, nil)
+	plan := manager.PlanApply(manager.Get("undoable"), context)
+
+	assert.Equal(t, "ssh sudo touch /tmp/first", plan.Steps[0])
+	assert.Equal(t, "undo ssh sudo rm /tmp/first", plan.Steps[1])
+	assert.Equal(t, "ssh echo not reverted", plan.Steps[2])
+}
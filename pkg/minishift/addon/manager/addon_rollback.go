@@ -0,0 +1,136 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	// UndoStrategy denotes a rollback via the undo annotations of the executed commands
+	UndoStrategy = "undo"
+	// RemoveStrategy denotes a rollback via the remove commands of the addon
+	RemoveStrategy = "remove"
+)
+
+// RollbackReport describes the rollback of an addon which failed to apply.
+type RollbackReport struct {
+	Name        string
+	Strategy    string   // UndoStrategy, RemoveStrategy or empty if the addon cannot be rolled back
+	RolledBack  []string // successfully executed rollback commands
+	Failed      []string // failed rollback commands together with their error
+	NotReverted []string // executed commands without undo annotation
+}
+
+// HasFailures returns true if the addon could not be rolled back completely.
+func (r *RollbackReport) HasFailures() bool {
+	return r.Strategy == "" || len(r.Failed) > 0 || len(r.NotReverted) > 0
+}
+
+func (r *RollbackReport) String() string {
+	var buffer bytes.Buffer
+	switch r.Strategy {
+	case UndoStrategy:
+		buffer.WriteString(fmt.Sprintf("-- Rolled back addon '%s' using its undo commands:\n", r.Name))
+	case RemoveStrategy:
+		buffer.WriteString(fmt.Sprintf("-- Rolled back addon '%s' using its remove commands:\n", r.Name))
+	default:
+		buffer.WriteString(fmt.Sprintf("-- Unable to roll back addon '%s'. It neither contains undo commands nor a remove file\n", r.Name))
+	}
+	for _, rolledBack := range r.RolledBack {
+		buffer.WriteString(fmt.Sprintf("   OK: %s\n", rolledBack))
+	}
+	for _, failed := range r.Failed {
+		buffer.WriteString(fmt.Sprintf("   FAILED: %s\n", failed))
+	}
+	for _, notReverted := range r.NotReverted {
+		buffer.WriteString(fmt.Sprintf("   NOT REVERTED: %s\n", notReverted))
+	}
+	return buffer.String()
+}
+
+// rollback reverts the commands of the addon which got executed prior to the failing command with the index failedCommand.
+// If any of the addon commands has an undo annotation, the undo commands of the executed commands are run in reverse
+// order. Otherwise all remove commands of the addon are run. Rollback is best effort, failing commands do not stop it.
+func (m *AddOnManager) rollback(addOn addon.AddOn, context *command.ExecutionContext, failedCommand int) *RollbackReport {
+	report := &RollbackReport{Name: addOn.MetaData().Name()}
+
+	var rollbackCommands []command.Command
+	commands := addOn.Commands()
+	switch {
+	case hasUndoCommands(commands):
+		report.Strategy = UndoStrategy
+		for i := failedCommand - 1; i >= 0; i-- {
+			if undoable, ok := commands[i].(*command.UndoableCommand); ok {
+				rollbackCommands = append(rollbackCommands, undoable.Undo())
+			} else {
+				report.NotReverted = append(report.NotReverted, commands[i].String())
+			}
+		}
+	case len(addOn.RemoveCommands()) > 0:
+		report.Strategy = RemoveStrategy
+		rollbackCommands = addOn.RemoveCommands()
+	default:
+		fmt.Print("\n")
+		return report
+	}
+
+	fmt.Print(fmt.Sprintf("\n-- Rolling back addon '%s':", addOn.MetaData().Name()))
+	oldDir, err := os.Getwd()
+	if err == nil {
+		defer os.Chdir(oldDir)
+		os.Chdir(addOn.InstallPath())
+	}
+
+	failedRollbackCommand := noFailedCommand
+	for i, c := range rollbackCommands {
+		if err := c.Execute(context); err != nil {
+			if failedRollbackCommand == noFailedCommand {
+				failedRollbackCommand = i
+			}
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %s", c.String(), strings.TrimSpace(err.Error())))
+			continue
+		}
+		report.RolledBack = append(report.RolledBack, c.String())
+	}
+	fmt.Print("\n")
+
+	var rollbackErr error
+	if len(report.Failed) > 0 {
+		rollbackErr = errors.New(strings.Join(report.Failed, "; "))
+	}
+	recordAction(addOn, config.RollbackAction, contextVariables(context, secretVariables(addOn)), rollbackCommands, failedRollbackCommand, rollbackErr)
+
+	return report
+}
+
+func hasUndoCommands(commands []command.Command) bool {
+	for _, c := range commands {
+		if _, ok := c.(*command.UndoableCommand); ok {
+			return true
+		}
+	}
+	return false
+}
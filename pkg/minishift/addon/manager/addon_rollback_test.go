@@ -0,0 +1,183 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/provision"
+	"github.com/docker/machine/libmachine/ssh"
+	"github.com/minishift/minishift/pkg/minikube/tests"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var undoable = `# Name: undoable
+# Description: Add-on reverting its commands via undo annotations
+
+ssh sudo touch /tmp/first
+undo ssh sudo rm /tmp/first
+ssh echo not reverted
+ssh sudo touch /tmp/second
+undo ssh sudo rm /tmp/second
+ssh false
+ssh sudo touch /tmp/never
+undo ssh sudo rm /tmp/never
+`
+
+var removable = `# Name: removable
+# Description: Add-on reverted via its remove file
+
+ssh sudo touch /tmp/first
+ssh false
+`
+
+var removableRemove = `# Name: removable
+# Description: Add-on reverted via its remove file
+
+ssh sudo rm -f /tmp/first
+ssh sudo rm -f /tmp/second
+`
+
+var irreversible = `# Name: irreversible
+# Description: Add-on without undo annotations and remove file
+
+ssh sudo touch /tmp/first
+ssh false
+`
+
+func Test_rollback_runs_undo_commands_of_executed_commands_in_reverse_order(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, rollbackAddOnFiles, nil)
+	defer cleanup()
+	server := createRollbackTestServer(t)
+
+	report, err := manager.ApplyAddOnWithRollback(manager.Get("undoable"), createRollbackTestContext(t, server))
+	assert.Error(t, err)
+
+	expectedCommands := []string{
+		"sudo touch /tmp/first",
+		"echo not reverted",
+		"sudo touch /tmp/second",
+		"false",
+		"sudo rm /tmp/second",
+		"sudo rm /tmp/first",
+	}
+	assert.Equal(t, expectedCommands, server.CommandHistory())
+
+	assert.Equal(t, UndoStrategy, report.Strategy)
+	assert.Equal(t, []string{"ssh sudo rm /tmp/second", "ssh sudo rm /tmp/first"}, report.RolledBack)
+	assert.Equal(t, []string{"ssh echo not reverted"}, report.NotReverted)
+	assert.Empty(t, report.Failed)
+	assert.True(t, report.HasFailures())
+
+	history := manager.History("undoable")
+	assert.Len(t, history, 2)
+	assert.Equal(t, config.ApplyAction, history[0].Action)
+	assert.Equal(t, 3, history[0].FailedCommand)
+	assert.Equal(t, config.RollbackAction, history[1].Action)
+	assert.True(t, history[1].IsSuccess())
+	assert.False(t, manager.IsApplied("undoable"))
+}
+
+func Test_rollback_falls_back_to_remove_commands(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, rollbackAddOnFiles, nil)
+	defer cleanup()
+	server := createRollbackTestServer(t)
+	server.CommandToExitStatus["sudo rm -f /tmp/first"] = 1
+
+	report, err := manager.ApplyAddOnWithRollback(manager.Get("removable"), createRollbackTestContext(t, server))
+	assert.Error(t, err)
+
+	expectedCommands := []string{
+		"sudo touch /tmp/first",
+		"false",
+		"sudo rm -f /tmp/first",
+		"sudo rm -f /tmp/second",
+	}
+	assert.Equal(t, expectedCommands, server.CommandHistory())
+
+	assert.Equal(t, RemoveStrategy, report.Strategy)
+	assert.Equal(t, []string{"ssh sudo rm -f /tmp/second"}, report.RolledBack)
+	assert.Len(t, report.Failed, 1)
+	assert.Regexp(t, "^ssh sudo rm -f /tmp/first: ", report.Failed[0])
+	assert.True(t, report.HasFailures())
+
+	record := manager.LastRecord("removable")
+	assert.Equal(t, config.RollbackAction, record.Action)
+	assert.Equal(t, config.FailureOutcome, record.Outcome)
+	assert.Equal(t, 0, record.FailedCommand)
+}
+
+func Test_rollback_without_undo_commands_and_remove_file_is_reported(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, rollbackAddOnFiles, nil)
+	defer cleanup()
+	server := createRollbackTestServer(t)
+
+	report, err := manager.ApplyAddOnWithRollback(manager.Get("irreversible"), createRollbackTestContext(t, server))
+	assert.Error(t, err)
+	assert.Equal(t, []string{"sudo touch /tmp/first", "false"}, server.CommandHistory())
+	assert.Equal(t, "", report.Strategy)
+	assert.True(t, report.HasFailures())
+	assert.Regexp(t, "Unable to roll back addon 'irreversible'", report.String())
+}
+
+func Test_successful_apply_is_not_rolled_back(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, rollbackAddOnFiles, nil)
+	defer cleanup()
+	server := createRollbackTestServer(t)
+	server.CommandToExitStatus = map[string]uint32{}
+
+	report, err := manager.ApplyAddOnWithRollback(manager.Get("removable"), createRollbackTestContext(t, server))
+	assert.NoError(t, err)
+	assert.Nil(t, report)
+	assert.Equal(t, []string{"sudo touch /tmp/first", "false"}, server.CommandHistory())
+	assert.True(t, manager.IsApplied("removable"))
+}
+
+var rollbackAddOnFiles = map[string]string{
+	"undoable/undoable.addon":          undoable,
+	"removable/removable.addon":        removable,
+	"removable/removable.addon.remove": removableRemove,
+	"irreversible/irreversible.addon":  irreversible,
+}
+
+func createRollbackTestServer(t *testing.T) *tests.SSHServer {
+	server, err := tests.NewSSHServer()
+	assert.NoError(t, err, "Error creating ssh server")
+	server.CommandToExitStatus = map[string]uint32{"false": 1}
+	return server
+}
+
+func createRollbackTestContext(t *testing.T, server *tests.SSHServer) *command.ExecutionContext {
+	ssh.SetDefaultClient(ssh.Native)
+	port, err := server.Start()
+	assert.NoError(t, err, "Error starting ssh server")
+
+	driver := &tests.MockDriver{
+		Port: port,
+		BaseDriver: drivers.BaseDriver{
+			IPAddress:  "127.0.0.1",
+			SSHKeyPath: "",
+		},
+	}
+
+	context, err := command.NewExecutionContext(nil, provision.GenericSSHCommander{Driver: driver})
+	assert.NoError(t, err, "Error creating execution context")
+	return context
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/util"
+	utilStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+// PromptForVariable reads the value of the specified variable from the user. It returns false if no value could be
+// read, eg because no terminal is attached.
+var PromptForVariable = func(variable *addon.Variable) (string, bool) {
+	if !util.IsTtySupported() {
+		return "", false
+	}
+
+	label := variable.Name
+	if variable.Description != "" {
+		label = fmt.Sprintf("%s (%s)", variable.Name, variable.Description)
+	}
+	if variable.Type == addon.EnumVariable {
+		label = fmt.Sprintf("%s [%s]", label, strings.Join(variable.Values, ", "))
+	}
+	if variable.Secret {
+		return util.ReadPasswordFromStdin(label), true
+	}
+	return util.ReadInputFromStdin(label), true
+}
+
+// promptForMissingVariables prompts for the values of the required declared variables which are not defined in the
+// context. Prompting is skipped if no terminal is attached, in which case the missing variables are reported by
+// verifyDeclaredVariablesInContext.
+func promptForMissingVariables(context *command.ExecutionContext, meta addon.AddOnMeta) error {
+	variables, err := meta.Variables()
+	if err != nil {
+		return err
+	}
+
+	for _, variable := range variables {
+		if !variable.IsRequired() || utilStrings.Contains(context.Vars(), variable.Name) {
+			continue
+		}
+
+		value, ok := PromptForVariable(variable)
+		if !ok {
+			continue
+		}
+		if err := variable.Validate(value); err != nil {
+			return err
+		}
+		context.AddToContext(variable.Name, value)
+	}
+	return nil
+}
+
+// verifyDeclaredVariablesInContext verifies that all required declared variables are defined in the context and that
+// the values of all declared variables are valid.
+func verifyDeclaredVariablesInContext(context *command.ExecutionContext, meta addon.AddOnMeta) error {
+	variables, err := meta.Variables()
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, variable := range variables {
+		if !utilStrings.Contains(context.Vars(), variable.Name) {
+			if variable.IsRequired() {
+				missing = append(missing, variable.Name)
+			}
+			continue
+		}
+		if err := variable.Validate(context.Interpolate(fmt.Sprintf("#{%s}", variable.Name))); err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		return errors.New(fmt.Sprintf("The variable(s) '%s' are required by the add-on, but are not defined in the context", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// secretVariables returns the names of the variables the add-on declares as secret.
+func secretVariables(addOn addon.AddOn) map[string]bool {
+	secrets := make(map[string]bool)
+	variables, _ := addOn.MetaData().Variables()
+	for _, variable := range variables {
+		if variable.Secret {
+			secrets[variable.Name] = true
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon"
+	"github.com/minishift/minishift/pkg/minishift/addon/command"
+	"github.com/minishift/minishift/pkg/minishift/addon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var typed = `# Name: typed
+# Description: Add-on declaring typed variables
+# Var: PORT; type=port; description=Port of the service
+# Var: MODE; type=enum; values=dev|prod; default=dev
+# Var: PIN; type=int; secret; default=1234
+
+echo Exposing #{PORT} in #{MODE} mode
+`
+
+func Test_missing_required_variable_returns_error_without_terminal(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, map[string]string{"typed/typed.addon": typed}, nil)
+	defer cleanup()
+	defer stubPrompt(func(variable *addon.Variable) (string, bool) { return "", false })()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	err := manager.ApplyAddOn(manager.Get("typed"), context)
+	assert.EqualError(t, err, "The variable(s) 'PORT' are required by the add-on, but are not defined in the context")
+}
+
+func Test_missing_required_variable_is_prompted_for(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, map[string]string{"typed/typed.addon": typed}, nil)
+	defer cleanup()
+
+	var prompted []string
+	defer stubPrompt(func(variable *addon.Variable) (string, bool) {
+		prompted = append(prompted, variable.Name)
+		return "8080", true
+	})()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	err := manager.ApplyAddOn(manager.Get("typed"), context)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PORT"}, prompted)
+
+	record := manager.LastRecord("typed")
+	assert.Equal(t, "8080", record.Variables["PORT"])
+	assert.Equal(t, "dev", record.Variables["MODE"])
+	assert.Equal(t, "********", record.Variables["PIN"])
+}
+
+func Test_invalid_variable_value_returns_error(t *testing.T) {
+	manager, cleanup := createTestAddOnManager(t, map[string]string{"typed/typed.addon": typed}, nil)
+	defer cleanup()
+	defer stubPrompt(func(variable *addon.Variable) (string, bool) { return "", false })()
+
+	context, _ := command.NewExecutionContext(nil, &FakeSSHDockerCommander{})
+	context.AddToContext("PORT", "8080")
+	context.AddToContext("MODE", "test")
+	err := manager.ApplyAddOn(manager.Get("typed"), context)
+	assert.EqualError(t, err, "Invalid value 'test' for variable 'MODE', expected one of dev, prod")
+
+	record := manager.LastRecord("typed")
+	assert.Equal(t, config.FailureOutcome, record.Outcome)
+	assert.Equal(t, -1, record.FailedCommand)
+}
+
+func stubPrompt(prompt func(variable *addon.Variable) (string, bool)) func() {
+	original := PromptForVariable
+	PromptForVariable = prompt
+	return func() {
+		PromptForVariable = original
+	}
+}
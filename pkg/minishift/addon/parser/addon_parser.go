@@ -23,7 +23,9 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/minishift/minishift/pkg/minishift/addon"
 	"github.com/minishift/minishift/pkg/minishift/addon/command"
@@ -40,8 +42,52 @@ const (
 	multipleAddOnDefinitionsError       = "There can only be one addon file per addon directory. Found '%s'"
 	multipleAddOnRemoveDefinitionsError = "There can only be one addon.remove file per addon directory. Found '%s'"
 	regexToGetMetaTagInfo               = `^# ?([a-zA-Z-]*):(.*)`
+
+	ifKeyword      = "if"
+	elseKeyword    = "else"
+	endKeyword     = "end"
+	forEachKeyword = "foreach"
+	untilKeyword   = "until"
+	undoKeyword    = "undo"
+
+	defaultUntilInterval = 5 * time.Second
+
+	unexpectedKeywordError    = "'%s' without matching block statement"
+	unclosedBlockError        = "'%s' statement is not closed by 'end'"
+	keywordArgumentsError     = "'%s' does not take any arguments"
+	blockModifierError        = "'%s' statements cannot be combined with '!' or ':='"
+	missingConditionError     = "'if' statement requires a condition"
+	duplicateElseError        = "'else' can only be used once per 'if' statement"
+	invalidForEachError       = "Invalid statement '%s'. Expected 'foreach <variable> in <item>[,<item>...]'"
+	invalidUntilError         = "Invalid statement '%s'. Expected 'until <timeout-seconds> [every <interval-seconds>]'"
+	emptyUntilBlockError      = "'until' statement requires at least one command"
+	unexpectedElseInLoopError = "'else' is only allowed within 'if' statements"
+	missingUndoCommandError   = "'undo' requires the command reverting the previous command"
+	undoWithoutCommandError   = "'undo' needs to follow the command it reverts"
+	nestedUndoError           = "'undo' is only supported for commands outside of block statements"
+	duplicateUndoError        = "A command can only be followed by a single 'undo'"
+)
+
+var (
+	forEachRegexp = regexp.MustCompile(`^foreach\s+([a-zA-Z0-9_.-]+)\s+in\s+(.+)$`)
+	untilRegexp   = regexp.MustCompile(`^until\s+(\d+)(?:\s+every\s+(\d+))?$`)
 )
 
+// lineScanner is a bufio.Scanner keeping track of the number of the current line
+type lineScanner struct {
+	*bufio.Scanner
+
+	line int
+}
+
+func (s *lineScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		return false
+	}
+	s.line++
+	return true
+}
+
 // AddOnParser is responsible for loading an addon from file and converting it into an AddOn
 type AddOnParser struct {
 	handler CommandHandler
@@ -71,6 +117,15 @@ func NewAddOnParser() *AddOnParser {
 	catHandler := &CatCommandHandler{&defaultCommandHandler{}}
 	echoHandler.SetNext(catHandler)
 
+	httpHandler := &HttpCommandHandler{&defaultCommandHandler{}}
+	catHandler.SetNext(httpHandler)
+
+	waitForHandler := &WaitForCommandHandler{&defaultCommandHandler{}}
+	httpHandler.SetNext(waitForHandler)
+
+	templateHandler := &TemplateCommandHandler{&defaultCommandHandler{}}
+	waitForHandler.SetNext(templateHandler)
+
 	parser.handler = ocHandler
 
 	return &parser
@@ -94,6 +149,11 @@ func (parser *AddOnParser) getAddOnContent(addOnDir, fileSuffix string) (addon.A
 			name = meta.Name()
 		}
 		if err != nil {
+			if parseError, ok := err.(*DefaultParseError); ok {
+				parseError.addonName = name
+				parseError.addonDir = addOnDir
+				return nil, nil, parseError
+			}
 			return nil, nil, NewParseError(err.Error(), name, addOnDir)
 		}
 		if filepath.Base(addOnDir) != name {
@@ -160,8 +220,14 @@ func (parser *AddOnParser) getAddOnContentReader(addOnDir string, fileSuffix str
 	return bufio.NewReader(reader), nil
 }
 
+// ParseContent parses the content of a single add-on or add-on remove file without any checks against the add-on
+// directory. It returns the parsed meta data and commands.
+func (parser *AddOnParser) ParseContent(reader io.Reader) (addon.AddOnMeta, []command.Command, error) {
+	return parser.parseAddOnContent(reader)
+}
+
 func (parser *AddOnParser) parseAddOnContent(reader io.Reader) (addon.AddOnMeta, []command.Command, error) {
-	scanner := bufio.NewScanner(reader)
+	scanner := &lineScanner{Scanner: bufio.NewScanner(reader)}
 	meta, err := parser.parseHeader(scanner)
 	if err != nil {
 		return nil, nil, err
@@ -175,7 +241,7 @@ func (parser *AddOnParser) parseAddOnContent(reader io.Reader) (addon.AddOnMeta,
 	return meta, commands, nil
 }
 
-func (parser *AddOnParser) parseHeader(scanner *bufio.Scanner) (addon.AddOnMeta, error) {
+func (parser *AddOnParser) parseHeader(scanner *lineScanner) (addon.AddOnMeta, error) {
 	var header []string
 	var line string
 	for scanner.Scan() {
@@ -194,7 +260,22 @@ func (parser *AddOnParser) parseHeader(scanner *bufio.Scanner) (addon.AddOnMeta,
 	return headerMeta, nil
 }
 
-func (parser *AddOnParser) parseCommands(scanner *bufio.Scanner) ([]command.Command, error) {
+func (parser *AddOnParser) parseCommands(scanner *lineScanner) ([]command.Command, error) {
+	commands, terminator, err := parser.parseBlock(scanner, false)
+	if err != nil {
+		return nil, err
+	}
+	if terminator != "" {
+		return nil, NewLineParseError(fmt.Sprintf(unexpectedKeywordError, terminator), scanner.line)
+	}
+
+	return commands, nil
+}
+
+// parseBlock parses commands until either the input is exhausted or an 'else' or 'end' keyword is reached.
+// It returns the parsed commands together with the keyword which terminated the block, the empty string in case
+// the end of the input was reached. nested specifies whether the block is the body of a block statement.
+func (parser *AddOnParser) parseBlock(scanner *lineScanner, nested bool) ([]command.Command, string, error) {
 	var commands []command.Command
 	for scanner.Scan() {
 		var outputVariable string
@@ -213,19 +294,169 @@ func (parser *AddOnParser) parseCommands(scanner *bufio.Scanner) ([]command.Comm
 		if strings.Contains(line, evaluationChar) {
 			cmdToken, err := minishiftStrings.SplitAndTrim(line, evaluationChar)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			outputVariable, line = cmdToken[0], cmdToken[1]
 		}
-		newCommand, err := parser.handler.Handle(parser.handler, line, ignoreError, outputVariable)
+
+		var keyword string
+		if fields := strings.Fields(line); len(fields) > 0 {
+			keyword = fields[0]
+		}
+		switch keyword {
+		case elseKeyword, endKeyword, ifKeyword, forEachKeyword, untilKeyword, undoKeyword:
+			if ignoreError || outputVariable != "" {
+				return nil, "", NewLineParseError(fmt.Sprintf(blockModifierError, keyword), scanner.line)
+			}
+		}
+
+		var newCommand command.Command
+		var err error
+		switch keyword {
+		case elseKeyword, endKeyword:
+			if line != keyword {
+				return nil, "", NewLineParseError(fmt.Sprintf(keywordArgumentsError, keyword), scanner.line)
+			}
+			return commands, keyword, nil
+		case ifKeyword:
+			newCommand, err = parser.parseIf(scanner, line)
+		case forEachKeyword:
+			newCommand, err = parser.parseForEach(scanner, line)
+		case untilKeyword:
+			newCommand, err = parser.parseUntil(scanner, line)
+		case undoKeyword:
+			if err := parser.parseUndo(scanner, line, commands, nested); err != nil {
+				return nil, "", err
+			}
+			continue
+		default:
+			newCommand, err = parser.handler.Handle(parser.handler, line, ignoreError, outputVariable)
+			setErrorLine(err, scanner.line)
+		}
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		commands = append(commands, newCommand)
 	}
 
-	return commands, nil
+	return commands, "", nil
+}
+
+func (parser *AddOnParser) parseIf(scanner *lineScanner, line string) (command.Command, error) {
+	startLine := scanner.line
+	condition := strings.TrimSpace(strings.TrimPrefix(line, ifKeyword))
+	if condition == "" {
+		return nil, NewLineParseError(missingConditionError, startLine)
+	}
+
+	thenCommands, terminator, err := parser.parseBlock(scanner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var elseCommands []command.Command
+	if terminator == elseKeyword {
+		elseCommands, terminator, err = parser.parseBlock(scanner, true)
+		if err != nil {
+			return nil, err
+		}
+		if terminator == elseKeyword {
+			return nil, NewLineParseError(duplicateElseError, scanner.line)
+		}
+	}
+
+	if terminator != endKeyword {
+		return nil, NewLineParseError(fmt.Sprintf(unclosedBlockError, ifKeyword), startLine)
+	}
+
+	return command.NewIfCommand(line, condition, thenCommands, elseCommands), nil
+}
+
+// parseUndo parses an undo annotation and attaches it to the last of the already parsed commands.
+func (parser *AddOnParser) parseUndo(scanner *lineScanner, line string, commands []command.Command, nested bool) error {
+	if nested {
+		return NewLineParseError(nestedUndoError, scanner.line)
+	}
+	if len(commands) == 0 {
+		return NewLineParseError(undoWithoutCommandError, scanner.line)
+	}
+
+	undoLine := strings.TrimSpace(strings.TrimPrefix(line, undoKeyword))
+	if undoLine == "" {
+		return NewLineParseError(missingUndoCommandError, scanner.line)
+	}
+
+	last := len(commands) - 1
+	if _, ok := commands[last].(*command.UndoableCommand); ok {
+		return NewLineParseError(duplicateUndoError, scanner.line)
+	}
+
+	undo, err := parser.handler.Handle(parser.handler, undoLine, false, "")
+	if err != nil {
+		setErrorLine(err, scanner.line)
+		return err
+	}
+
+	commands[last] = command.NewUndoableCommand(commands[last], undo)
+	return nil
+}
+
+func (parser *AddOnParser) parseForEach(scanner *lineScanner, line string) (command.Command, error) {
+	startLine := scanner.line
+	match := forEachRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return nil, NewLineParseError(fmt.Sprintf(invalidForEachError, line), startLine)
+	}
+
+	body, err := parser.parseLoopBody(scanner, forEachKeyword, startLine)
+	if err != nil {
+		return nil, err
+	}
+
+	return command.NewForEachCommand(line, match[1], match[2], body), nil
+}
+
+func (parser *AddOnParser) parseUntil(scanner *lineScanner, line string) (command.Command, error) {
+	startLine := scanner.line
+	match := untilRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return nil, NewLineParseError(fmt.Sprintf(invalidUntilError, line), startLine)
+	}
+
+	timeout, _ := strconv.Atoi(match[1])
+	interval := defaultUntilInterval
+	if match[2] != "" {
+		seconds, _ := strconv.Atoi(match[2])
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	body, err := parser.parseLoopBody(scanner, untilKeyword, startLine)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, NewLineParseError(emptyUntilBlockError, startLine)
+	}
+
+	return command.NewUntilCommand(line, time.Duration(timeout)*time.Second, interval, body), nil
+}
+
+// parseLoopBody parses the body of a foreach or until statement, which needs to be terminated by 'end'
+func (parser *AddOnParser) parseLoopBody(scanner *lineScanner, keyword string, startLine int) ([]command.Command, error) {
+	body, terminator, err := parser.parseBlock(scanner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch terminator {
+	case endKeyword:
+		return body, nil
+	case elseKeyword:
+		return nil, NewLineParseError(unexpectedElseInLoopError, scanner.line)
+	default:
+		return nil, NewLineParseError(fmt.Sprintf(unclosedBlockError, keyword), startLine)
+	}
 }
 
 func createMetaData(header []string) (addon.AddOnMeta, error) {
@@ -247,6 +478,11 @@ func createMetaData(header []string) (addon.AddOnMeta, error) {
 				metaMap[key] = value
 				continue
 			}
+			if key == addon.VariableMetaTagName {
+				declarations, _ := metaMap[key].([]string)
+				metaMap[key] = append(declarations, strings.Trim(match[2], " "))
+				continue
+			}
 			metaMap[key] = strings.Trim(match[2], " ")
 		}
 	}
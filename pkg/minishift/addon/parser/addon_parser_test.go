@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/minishift/minishift/pkg/minishift/addon/command"
 	"github.com/stretchr/testify/assert"
@@ -97,6 +98,22 @@ oc foo
 snafu
 `
 
+var addOnWithBlocks string = `# Name: blocks
+# Description: Add-on using block statements
+
+if #{openshift-version} >= 3.10
+  foreach project in foo, bar
+    oc new-project #{project}
+  end
+else
+  echo OpenShift version not supported
+end
+
+until 60 every 2
+  oc rollout status dc/foo
+end
+`
+
 var testParser = NewAddOnParser()
 
 var (
@@ -170,6 +187,26 @@ func Test_multiple_lines_in_description_with_comment(t *testing.T) {
 	assert.Len(t, commands, expectedNumberOfCommands)
 }
 
+func Test_multiple_variable_declarations(t *testing.T) {
+	content := `# Name: foo
+# Description: Add-on declaring variables
+# Var: DB_PORT; type=port; default=5432; description=Port of the database
+# Var: DB_PASSWORD; secret
+
+echo #{DB_PORT}
+`
+	meta, _, err := testParser.parseAddOnContent(strings.NewReader(content))
+	assert.NoError(t, err, "Error in parsing addon content")
+
+	variables, err := meta.Variables()
+	assert.NoError(t, err)
+	assert.Len(t, variables, 2)
+	assert.Equal(t, "DB_PORT", variables[0].Name)
+	assert.Equal(t, "Port of the database", variables[0].Description)
+	assert.Equal(t, "DB_PASSWORD", variables[1].Name)
+	assert.True(t, variables[1].Secret)
+}
+
 func Test_addon_with_unknown_command_returns_error(t *testing.T) {
 	_, _, err := testParser.parseAddOnContent(strings.NewReader(addOnWithUnknownCommand))
 	assert.Error(t, err, "Error in parsing addon content")
@@ -221,6 +258,56 @@ func Test_all_command_types_recognized(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func Test_http_wait_for_and_template_commands_recognized(t *testing.T) {
+	content := `# Name: foo
+# Description: bar
+
+template app.yml.tpl /tmp/app.yml
+wait-for dc/app rollout 120
+body := http GET http://app.#{routing-suffix}/health expect 200
+`
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(content))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 3)
+
+	_, ok := commands[0].(*command.TemplateCommand)
+	assert.True(t, ok)
+
+	_, ok = commands[1].(*command.WaitForCommand)
+	assert.True(t, ok)
+
+	httpCommand, ok := commands[2].(*command.HttpCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "body", httpCommand.OutputVariable())
+}
+
+func Test_malformed_http_wait_for_and_template_commands_create_line_numbered_errors(t *testing.T) {
+	header := "# Name: foo\n# Description: bar\n\n"
+	testCases := []struct {
+		content       string
+		expectedError string
+		expectedLine  int
+	}{
+		{"oc foo\nhttp FOO http://app.nip.io\n", "Line 5: Unable to parse http command: 'http FOO http://app.nip.io'. Expected 'http GET|POST <url> [expect <status>]'", 5},
+		{"wait-for x\n", "Line 4: Unable to parse wait-for command: 'wait-for x'. Expected 'wait-for <resource> rollout|ready|exists|condition=<type> [<timeout>]'", 4},
+		{"template app.yml.tpl\n", "Line 4: Unable to parse template command: 'template app.yml.tpl'. Expected 'template <source> <destination>'", 4},
+		{"x := wait-for dc/app rollout\n", "Line 4: The output of 'wait-for' commands cannot be assigned to a variable", 4},
+		{"x := template app.yml.tpl /tmp/app.yml\n", "Line 4: The output of 'template' commands cannot be assigned to a variable", 4},
+		{"oc apply -f app.yml\nundo wait-for x\n", "Line 5: Unable to parse wait-for command: 'wait-for x'. Expected 'wait-for <resource> rollout|ready|exists|condition=<type> [<timeout>]'", 5},
+	}
+
+	for _, testCase := range testCases {
+		_, _, err := testParser.parseAddOnContent(strings.NewReader(header + testCase.content))
+		assert.EqualError(t, err, testCase.expectedError)
+
+		parseError, ok := err.(ParseError)
+		assert.True(t, ok)
+		if ok {
+			assert.Equal(t, testCase.expectedLine, parseError.Line())
+		}
+	}
+}
+
 func Test_metadata_creation_requires_name_attribute(t *testing.T) {
 	_, _, err := testParser.parseAddOnContent(strings.NewReader(noName))
 	assert.Error(t, err, "Error in parsing addon content")
@@ -280,3 +367,101 @@ func TestInvalidAddonDirAndName(t *testing.T) {
 	_, _, err := testParser.getAddOnContent(testAddonDir, ".addon")
 	assert.EqualError(t, err, "Add-on directory name should match to addon name")
 }
+
+func Test_block_statements_are_parsed(t *testing.T) {
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(addOnWithBlocks))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 2)
+
+	ifCommand, ok := commands[0].(*command.IfCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "#{openshift-version} >= 3.10", ifCommand.Condition())
+	assert.Len(t, ifCommand.Then(), 1)
+	assert.Len(t, ifCommand.Else(), 1)
+
+	forEachCommand, ok := ifCommand.Then()[0].(*command.ForEachCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "project", forEachCommand.Variable())
+	assert.Equal(t, []string{"foo", "bar"}, forEachCommand.Items())
+	_, ok = forEachCommand.Body()[0].(*command.OcCommand)
+	assert.True(t, ok)
+
+	untilCommand, ok := commands[1].(*command.UntilCommand)
+	assert.True(t, ok)
+	assert.Equal(t, 60*time.Second, untilCommand.Timeout())
+	assert.Equal(t, 2*time.Second, untilCommand.Interval())
+	assert.Len(t, untilCommand.Body(), 1)
+}
+
+func Test_malformed_block_statements_create_line_numbered_errors(t *testing.T) {
+	header := "# Name: blocks\n# Description: Add-on using block statements\n\n"
+	testCases := []struct {
+		content       string
+		expectedError string
+		expectedLine  int
+	}{
+		{"if #{foo}\noc foo\n", "Line 4: 'if' statement is not closed by 'end'", 4},
+		{"oc foo\nend\n", "Line 5: 'end' without matching block statement", 5},
+		{"oc foo\nelse\n", "Line 5: 'else' without matching block statement", 5},
+		{"if\nend\n", "Line 4: 'if' statement requires a condition", 4},
+		{"if #{foo}\nelse\nelse\nend\n", "Line 6: 'else' can only be used once per 'if' statement", 6},
+		{"foreach project foo,bar\nend\n", "Line 4: Invalid statement 'foreach project foo,bar'. Expected 'foreach <variable> in <item>[,<item>...]'", 4},
+		{"foreach project in foo\nelse\nend\n", "Line 5: 'else' is only allowed within 'if' statements", 5},
+		{"until soon\noc foo\nend\n", "Line 4: Invalid statement 'until soon'. Expected 'until <timeout-seconds> [every <interval-seconds>]'", 4},
+		{"until 10\nend\n", "Line 4: 'until' statement requires at least one command", 4},
+		{"\n\nuntil 10\n  if #{foo}\n    oc foo\nend\n", "Line 6: 'until' statement is not closed by 'end'", 6},
+		{"!if #{foo}\nend\n", "Line 4: 'if' statements cannot be combined with '!' or ':='", 4},
+		{"if #{foo}\nend now\n", "Line 5: 'end' does not take any arguments", 5},
+	}
+
+	for _, testCase := range testCases {
+		_, _, err := testParser.parseAddOnContent(strings.NewReader(header + testCase.content))
+		assert.EqualError(t, err, testCase.expectedError)
+
+		parseError, ok := err.(ParseError)
+		assert.True(t, ok)
+		assert.Equal(t, testCase.expectedLine, parseError.Line())
+	}
+}
+
+func Test_undo_annotations_are_attached_to_the_previous_command(t *testing.T) {
+	content := "# Name: undo\n# Description: Add-on using undo annotations\n\n" +
+		"oc new-project foo\n# comments are allowed in between\nundo oc delete project foo\nssh sudo touch /tmp/foo\n"
+	_, commands, err := testParser.parseAddOnContent(strings.NewReader(content))
+	assert.NoError(t, err, "Error in parsing addon content")
+	assert.Len(t, commands, 2)
+
+	undoable, ok := commands[0].(*command.UndoableCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "oc new-project foo", undoable.String())
+	_, ok = undoable.Command().(*command.OcCommand)
+	assert.True(t, ok)
+	assert.Equal(t, "oc delete project foo", undoable.Undo().String())
+
+	_, ok = commands[1].(*command.SSHCommand)
+	assert.True(t, ok)
+}
+
+func Test_malformed_undo_annotations_create_line_numbered_errors(t *testing.T) {
+	header := "# Name: undo\n# Description: Add-on using undo annotations\n\n"
+	testCases := []struct {
+		content       string
+		expectedError string
+		expectedLine  int
+	}{
+		{"undo oc delete project foo\n", "Line 4: 'undo' needs to follow the command it reverts", 4},
+		{"oc new-project foo\nundo\n", "Line 5: 'undo' requires the command reverting the previous command", 5},
+		{"oc new-project foo\nundo oc delete project foo\nundo oc delete project bar\n", "Line 6: A command can only be followed by a single 'undo'", 6},
+		{"if #{foo}\n  oc new-project foo\n  undo oc delete project foo\nend\n", "Line 6: 'undo' is only supported for commands outside of block statements", 6},
+		{"oc new-project foo\n!undo oc delete project foo\n", "Line 5: 'undo' statements cannot be combined with '!' or ':='", 5},
+	}
+
+	for _, testCase := range testCases {
+		_, _, err := testParser.parseAddOnContent(strings.NewReader(header + testCase.content))
+		assert.EqualError(t, err, testCase.expectedError)
+
+		parseError, ok := err.(ParseError)
+		assert.True(t, ok)
+		assert.Equal(t, testCase.expectedLine, parseError.Line())
+	}
+}
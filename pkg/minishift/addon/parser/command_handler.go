@@ -32,6 +32,11 @@ const (
 	sshCommand       = "ssh"
 	echoCommand      = "echo"
 	catCommand       = "cat"
+	httpCommand      = "http "
+	waitForCommand   = "wait-for "
+	templateCommand  = "template "
+
+	outputVariableError = "The output of '%s' commands cannot be assigned to a variable"
 )
 
 type CommandHandler interface {
@@ -41,8 +46,9 @@ type CommandHandler interface {
 	Handle(next CommandHandler, s string, ignoreError bool, outputVariable string) (command.Command, error)
 
 	// Parse attempts to parse the given string into a Command instance of its type. nil is returned in case
-	// s does not represent a command which can be handled by this Command instance.
-	Parse(s string, ignoreError bool, outputVariable string) command.Command
+	// s does not represent a command which can be handled by this Command instance. An error is returned in case
+	// s is a command of this type, but its arguments are invalid.
+	Parse(s string, ignoreError bool, outputVariable string) (command.Command, error)
 }
 
 type defaultCommandHandler struct {
@@ -56,7 +62,10 @@ func (dc *defaultCommandHandler) SetNext(next CommandHandler) {
 }
 
 func (dc *defaultCommandHandler) Handle(c CommandHandler, s string, ignoreError bool, outputVariable string) (command.Command, error) {
-	newCommand := c.Parse(s, ignoreError, outputVariable)
+	newCommand, err := c.Parse(s, ignoreError, outputVariable)
+	if err != nil {
+		return nil, err
+	}
 	if newCommand != nil {
 		return newCommand, nil
 	} else if dc.next != nil {
@@ -70,75 +79,126 @@ type DockerCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *DockerCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *DockerCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, dockerCommand) {
-		return command.NewDockerCommand(s, ignoreError, outputVariable)
+		return command.NewDockerCommand(s, ignoreError, outputVariable), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type OcCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *OcCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *OcCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, ocCommand) {
-		return command.NewOcCommand(s, ignoreError, outputVariable)
+		return command.NewOcCommand(s, ignoreError, outputVariable), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type OpenShiftCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *OpenShiftCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *OpenShiftCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, openShiftCommand) {
-		return command.NewOpenShiftCommand(s, ignoreError, outputVariable)
+		return command.NewOpenShiftCommand(s, ignoreError, outputVariable), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type SleepCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *SleepCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *SleepCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, sleepCommand) {
-		return command.NewSleepCommand(s, ignoreError)
+		return command.NewSleepCommand(s, ignoreError), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type SSHCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *SSHCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *SSHCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, sshCommand) {
-		return command.NewSshCommand(s, ignoreError, outputVariable)
+		return command.NewSshCommand(s, ignoreError, outputVariable), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type EchoCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *EchoCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *EchoCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, echoCommand) {
-		return command.NewEchoCommand(s, ignoreError)
+		return command.NewEchoCommand(s, ignoreError), nil
 	}
-	return nil
+	return nil, nil
 }
 
 type CatCommandHandler struct {
 	*defaultCommandHandler
 }
 
-func (c *CatCommandHandler) Parse(s string, ignoreError bool, outputVariable string) command.Command {
+func (c *CatCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
 	if strings.HasPrefix(s, catCommand) {
-		return command.NewCatCommand(s, ignoreError, outputVariable)
+		return command.NewCatCommand(s, ignoreError, outputVariable), nil
+	}
+	return nil, nil
+}
+
+type HttpCommandHandler struct {
+	*defaultCommandHandler
+}
+
+func (c *HttpCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
+	if !strings.HasPrefix(s, httpCommand) {
+		return nil, nil
+	}
+	newCommand := command.NewHttpCommand(s, ignoreError, outputVariable)
+	if err := newCommand.Validate(); err != nil {
+		return nil, NewLineParseError(err.Error(), 0)
+	}
+	return newCommand, nil
+}
+
+type WaitForCommandHandler struct {
+	*defaultCommandHandler
+}
+
+func (c *WaitForCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
+	if !strings.HasPrefix(s, waitForCommand) {
+		return nil, nil
+	}
+	if outputVariable != "" {
+		return nil, NewLineParseError(fmt.Sprintf(outputVariableError, strings.TrimSpace(waitForCommand)), 0)
+	}
+	newCommand := command.NewWaitForCommand(s, ignoreError)
+	if err := newCommand.Validate(); err != nil {
+		return nil, NewLineParseError(err.Error(), 0)
+	}
+	return newCommand, nil
+}
+
+type TemplateCommandHandler struct {
+	*defaultCommandHandler
+}
+
+func (c *TemplateCommandHandler) Parse(s string, ignoreError bool, outputVariable string) (command.Command, error) {
+	if !strings.HasPrefix(s, templateCommand) {
+		return nil, nil
+	}
+	if outputVariable != "" {
+		return nil, NewLineParseError(fmt.Sprintf(outputVariableError, strings.TrimSpace(templateCommand)), 0)
+	}
+	newCommand := command.NewTemplateCommand(s, ignoreError)
+	if err := newCommand.Validate(); err != nil {
+		return nil, NewLineParseError(err.Error(), 0)
 	}
-	return nil
+	return newCommand, nil
 }
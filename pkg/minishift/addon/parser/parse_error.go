@@ -16,16 +16,21 @@ limitations under the License.
 
 package parser
 
+import "fmt"
+
 type ParseError interface {
 	error
 	AddonName() string
 	AddonDir() string
+	// Line returns the line of the addon file the error occurred in, 0 if the error is not related to a specific line
+	Line() int
 }
 
 type DefaultParseError struct {
 	msg       string
 	addonDir  string
 	addonName string
+	line      int
 }
 
 func NewParseError(msg string, name string, dir string) *DefaultParseError {
@@ -36,7 +41,31 @@ func NewParseError(msg string, name string, dir string) *DefaultParseError {
 	}
 }
 
-func (e *DefaultParseError) Error() string { return e.msg }
+// NewLineParseError creates a parse error for the specified line of an addon file
+func NewLineParseError(msg string, line int) *DefaultParseError {
+	return &DefaultParseError{
+		msg:  msg,
+		line: line,
+	}
+}
+
+// setErrorLine sets the line of a parse error which was created without knowing the line, eg by a command handler
+func setErrorLine(err error, line int) {
+	if parseError, ok := err.(*DefaultParseError); ok && parseError.line == 0 {
+		parseError.line = line
+	}
+}
+
+func (e *DefaultParseError) Error() string {
+	if e.line > 0 {
+		return fmt.Sprintf("Line %d: %s", e.line, e.msg)
+	}
+	return e.msg
+}
+
+func (e *DefaultParseError) Line() int {
+	return e.line
+}
 
 func (e *DefaultParseError) AddonName() string {
 	if e.addonName != "" {
@@ -0,0 +1,190 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/bundle"
+	"github.com/minishift/minishift/pkg/util/archive"
+	"github.com/pkg/errors"
+)
+
+const (
+	tarGzExtension = ".tar.gz"
+	tgzExtension   = ".tgz"
+	tarExtension   = ".tar"
+	zipExtension   = ".zip"
+)
+
+func isArchive(name string) bool {
+	for _, ext := range []string{tarGzExtension, tgzExtension, tarExtension, zipExtension} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// verification describes how an add-on bundle gets verified. signature is the location of the detached signature,
+// either a path or a HTTP(S) URL.
+type verification struct {
+	trustedKey crypto.PublicKey
+	signature  string
+}
+
+// fetchRemoteArchive downloads the archive specified via the HTTP(S) URL source into stagingDir and unpacks it.
+func fetchRemoteArchive(source string, stagingDir string, trustedKey crypto.PublicKey) (string, *Origin, error) {
+	archiveName := path.Base(strings.SplitN(source, "?", 2)[0])
+	if !isArchive(archiveName) {
+		return "", nil, errors.New(fmt.Sprintf("Unsupported add-on archive '%s'. Supported formats are tar, tar.gz, tgz and zip", source))
+	}
+
+	resp, err := http.Get(source)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Cannot download add-on from '%s'", source)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, errors.New(fmt.Sprintf("Cannot download add-on from '%s': %s", source, resp.Status))
+	}
+
+	archivePath := filepath.Join(stagingDir, archiveName)
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return "", nil, err
+	}
+	_, err = io.Copy(out, resp.Body)
+	out.Close()
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Cannot download add-on from '%s'", source)
+	}
+
+	return unpackArchive(archivePath, source, stagingDir, &verification{trustedKey: trustedKey, signature: source + bundle.SignatureSuffix})
+}
+
+// unpackArchive extracts the archive archivePath into stagingDir and locates the contained add-on. The SHA-256
+// checksum of the archive is used as revision of the returned origin. Add-on bundles are verified as specified.
+func unpackArchive(archivePath string, source string, stagingDir string, verify *verification) (string, *Origin, error) {
+	checksum, err := sha256Sum(archivePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	extractDir := filepath.Join(stagingDir, fetchDirName)
+	if err := os.MkdirAll(extractDir, 0755); err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(archivePath, tarGzExtension), strings.HasSuffix(archivePath, tgzExtension):
+		tarPath := filepath.Join(stagingDir, filepath.Base(archivePath)+tarExtension)
+		if err = archive.Ungzip(archivePath, tarPath); err == nil {
+			err = archive.Untar(tarPath, extractDir)
+		}
+	case strings.HasSuffix(archivePath, tarExtension):
+		err = archive.Untar(archivePath, extractDir)
+	case strings.HasSuffix(archivePath, zipExtension):
+		err = archive.Unzip(archivePath, extractDir)
+	}
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "Unable to extract add-on archive '%s'", source)
+	}
+
+	if bundle.IsBundle(extractDir) {
+		return verifyBundle(archivePath, source, extractDir, checksum, verify)
+	}
+	if verify.trustedKey != nil {
+		return "", nil, errors.New(fmt.Sprintf(unsignedSourceError, source))
+	}
+
+	addOnDir, err := locateAddOn(extractDir, stagingDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return addOnDir, &Origin{Source: source, Type: Archive, Revision: checksum}, nil
+}
+
+// verifyBundle verifies the digests of the extracted bundle and, if a trusted key is specified, its signature.
+func verifyBundle(archivePath string, source string, extractDir string, checksum string, verify *verification) (string, *Origin, error) {
+	manifest, err := bundle.VerifyDigests(extractDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	origin := &Origin{Source: source, Type: Archive, Revision: checksum}
+	if verify.trustedKey != nil {
+		signature, err := readSignature(verify.signature)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := bundle.VerifySignature(archivePath, signature, verify.trustedKey); err != nil {
+			return "", nil, err
+		}
+		origin.Signed = true
+	}
+
+	return filepath.Join(extractDir, manifest.Name), origin, nil
+}
+
+// readSignature reads the detached signature of a bundle from a file or a HTTP(S) URL.
+func readSignature(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		signature, err := ioutil.ReadFile(location)
+		if os.IsNotExist(err) {
+			return nil, errors.New(fmt.Sprintf("Unable to find the signature '%s' of the bundle", location))
+		}
+		return signature, err
+	}
+
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot download the signature '%s' of the bundle", location)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Cannot download the signature '%s' of the bundle: %s", location, resp.Status))
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func sha256Sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
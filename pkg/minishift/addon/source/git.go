@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	gitBinary       = "git"
+	refSeparator    = "#"
+	subDirSeparator = ":"
+	cloneDirName    = "repository"
+)
+
+// fetchGit clones the repository specified via source into stagingDir. The source has the form
+// git+<repository-url>[#<ref>[:<subdir>]]. The commit the ref resolves to is used as revision of the returned origin.
+func fetchGit(source string, stagingDir string) (string, *Origin, error) {
+	repository, ref, subDir, err := parseGitSource(source)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if _, err := exec.LookPath(gitBinary); err != nil {
+		return "", nil, errors.New("Installing add-ons from git repositories requires git to be installed")
+	}
+
+	cloneDir := filepath.Join(stagingDir, fetchDirName, cloneDirName)
+	if _, err := runGit("", "clone", "--quiet", "--", repository, cloneDir); err != nil {
+		return "", nil, err
+	}
+
+	if ref != "" {
+		if _, err := runGit(cloneDir, "checkout", "--quiet", ref, "--"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	revision, err := runGit(cloneDir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := os.RemoveAll(filepath.Join(cloneDir, gitMetadataDir)); err != nil {
+		return "", nil, err
+	}
+
+	root := cloneDir
+	if subDir != "" {
+		root = filepath.Join(cloneDir, filepath.FromSlash(subDir))
+		if root != cloneDir && !strings.HasPrefix(root, cloneDir+string(filepath.Separator)) {
+			return "", nil, errors.New(fmt.Sprintf("Invalid sub directory '%s' in git add-on source '%s'", subDir, source))
+		}
+	}
+
+	addOnDir, err := locateAddOn(root, stagingDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return addOnDir, &Origin{Source: source, Type: Git, Revision: revision}, nil
+}
+
+// parseGitSource splits a git add-on source into repository URL, ref and sub directory. An error is returned if the
+// repository is missing, or if the repository or the ref start with a dash, since git would take them as option.
+func parseGitSource(source string) (string, string, string, error) {
+	var ref, subDir string
+	repository := strings.TrimPrefix(source, gitPrefix)
+
+	if i := strings.LastIndex(repository, refSeparator); i >= 0 {
+		ref = repository[i+1:]
+		repository = repository[:i]
+		if j := strings.Index(ref, subDirSeparator); j >= 0 {
+			subDir = strings.Trim(ref[j+1:], "/")
+			ref = ref[:j]
+		}
+	}
+
+	if repository == "" || strings.HasPrefix(repository, "-") || strings.HasPrefix(ref, "-") {
+		return "", "", "", errors.New(fmt.Sprintf("Invalid git add-on source '%s'", source))
+	}
+
+	return repository, ref, subDir, nil
+}
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command(gitBinary, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Error running 'git %s': %s", strings.Join(args, " "), strings.TrimSpace(string(out))))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/bundle"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+	"github.com/pkg/errors"
+)
+
+// Type describes the kind of location an add-on gets installed from.
+type Type string
+
+const (
+	// Directory denotes an add-on installed from a directory on the local file system
+	Directory Type = "dir"
+	// Archive denotes an add-on installed from a tar, tar.gz or zip archive, either local or via HTTP(S)
+	Archive Type = "archive"
+	// Git denotes an add-on installed from a git repository
+	Git Type = "git"
+)
+
+const (
+	// OriginFileName is the name of the file within an installed add-on directory recording where the add-on was installed from
+	OriginFileName = ".addon-origin.json"
+
+	gitPrefix      = "git+"
+	fileScheme     = "file"
+	addOnSuffix    = ".addon"
+	fetchDirName   = "fetch"
+	gitMetadataDir = ".git"
+
+	notADirectoryError  = "The source of a addon needs to be a directory. '%s' is not"
+	unsignedSourceError = "Only signed add-on bundles can be installed while a trusted key is configured. '%s' is not a signed bundle"
+)
+
+// Origin records the source an add-on got installed from, so that it can be re-fetched later on.
+type Origin struct {
+	Source   string `json:"source"`
+	Type     Type   `json:"type"`
+	Revision string `json:"revision,omitempty"`
+	Signed   bool   `json:"signed,omitempty"`
+}
+
+// Fetch retrieves the add-on specified via source and returns the directory containing the add-on together
+// with its origin. Supported sources are local directories and archives, file:// and http(s):// URLs pointing
+// to archives as well as git repositories in the form git+<repository-url>[#<ref>[:<subdir>]].
+// Remote content is downloaded and unpacked into stagingDir, which is owned and cleaned up by the caller. Add-on
+// registries, which would resolve an add-on name and version to an archive, are not supported.
+func Fetch(source string, stagingDir string) (string, *Origin, error) {
+	return FetchVerified(source, stagingDir, nil)
+}
+
+// FetchVerified retrieves the add-on like Fetch. If the source is an add-on bundle, the digests of its files are
+// verified. If trustedKey is not nil, only bundles with a detached signature matching the trusted key are accepted.
+func FetchVerified(source string, stagingDir string, trustedKey crypto.PublicKey) (string, *Origin, error) {
+	if strings.HasPrefix(source, gitPrefix) {
+		if trustedKey != nil {
+			return "", nil, errors.New(fmt.Sprintf(unsignedSourceError, source))
+		}
+		return fetchGit(source, stagingDir)
+	}
+
+	sourceURL, err := url.Parse(source)
+	if err == nil {
+		switch sourceURL.Scheme {
+		case "http", "https":
+			return fetchRemoteArchive(source, stagingDir, trustedKey)
+		case fileScheme:
+			return fetchLocal(source, filepath.FromSlash(sourceURL.Path), stagingDir, trustedKey)
+		}
+	}
+
+	return fetchLocal(source, source, stagingDir, trustedKey)
+}
+
+// ReadOrigin reads the origin of the add-on installed in addOnDir. nil is returned if no origin is recorded.
+func ReadOrigin(addOnDir string) (*Origin, error) {
+	originFile := filepath.Join(addOnDir, OriginFileName)
+	if !filehelper.Exists(originFile) {
+		return nil, nil
+	}
+
+	raw, err := ioutil.ReadFile(originFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Unable to read add-on origin '%s'", originFile)
+	}
+
+	origin := &Origin{}
+	if err := json.Unmarshal(raw, origin); err != nil {
+		return nil, errors.Wrapf(err, "Unable to parse add-on origin '%s'", originFile)
+	}
+
+	return origin, nil
+}
+
+// WriteOrigin records the specified origin in the add-on directory addOnDir.
+func WriteOrigin(addOnDir string, origin *Origin) error {
+	jsonData, err := json.MarshalIndent(origin, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(addOnDir, OriginFileName), jsonData, 0644)
+}
+
+func fetchLocal(source string, path string, stagingDir string, trustedKey crypto.PublicKey) (string, *Origin, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if filehelper.IsDirectory(absPath) {
+		if trustedKey != nil {
+			return "", nil, errors.New(fmt.Sprintf(unsignedSourceError, source))
+		}
+		return absPath, &Origin{Source: absPath, Type: Directory}, nil
+	}
+
+	if filehelper.Exists(absPath) && isArchive(absPath) {
+		return unpackArchive(absPath, source, stagingDir, &verification{trustedKey: trustedKey, signature: absPath + bundle.SignatureSuffix})
+	}
+
+	return "", nil, errors.New(fmt.Sprintf(notADirectoryError, source))
+}
+
+// locateAddOn searches root for the single directory containing an add-on definition. If the name of this directory
+// does not match the name of the add-on definition, the directory is moved into stagingDir under the expected name.
+func locateAddOn(root string, stagingDir string) (string, error) {
+	var addOnDirs []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == gitMetadataDir {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), addOnSuffix) {
+			addOnDirs = append(addOnDirs, filepath.Dir(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(addOnDirs) == 0 {
+		return "", errors.New(fmt.Sprintf("Unable to find an add-on definition in '%s'", root))
+	}
+	if len(addOnDirs) > 1 {
+		return "", errors.New(fmt.Sprintf("Found multiple add-on definitions in '%s'. Specify the add-on sub directory to install", root))
+	}
+
+	addOnDir := addOnDirs[0]
+	addOnName, err := addOnFileName(addOnDir)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(addOnDir) == addOnName {
+		return addOnDir, nil
+	}
+
+	namedDir := filepath.Join(stagingDir, addOnName)
+	if err := os.Rename(addOnDir, namedDir); err != nil {
+		return "", errors.Wrapf(err, "Unable to stage add-on '%s'", addOnName)
+	}
+	return namedDir, nil
+}
+
+func addOnFileName(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), addOnSuffix) {
+			return strings.TrimSuffix(file.Name(), addOnSuffix), nil
+		}
+	}
+	return "", errors.New(fmt.Sprintf("Unable to find an add-on definition in '%s'", dir))
+}
@@ -0,0 +1,310 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/addon/bundle"
+	"github.com/stretchr/testify/assert"
+)
+
+var anyuid = `# Name: anyuid
+# Description: Allows authenticated users to run images to run with USER as per Dockerfile
+
+oc adm policy add-scc-to-group anyuid system:authenticated
+`
+
+func Test_fetch_local_directory(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	addOnDir := filepath.Join(testDir, "anyuid")
+	writeAddOn(t, addOnDir)
+
+	dir, origin, err := Fetch(addOnDir, testDir)
+	assert.NoError(t, err)
+	assert.Equal(t, addOnDir, dir)
+	assert.Equal(t, Directory, origin.Type)
+	assert.Equal(t, addOnDir, origin.Source)
+}
+
+func Test_fetch_non_existing_path_returns_an_error(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	_, _, err := Fetch("foo", testDir)
+	assert.EqualError(t, err, "The source of a addon needs to be a directory. 'foo' is not")
+}
+
+func Test_fetch_archive_via_http(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	archive := createTarGz(t, testDir, "anyuid-1.0.0")
+	server := httptest.NewServer(http.FileServer(http.Dir(testDir)))
+	defer server.Close()
+
+	stagingDir := filepath.Join(testDir, "staging")
+	os.Mkdir(stagingDir, 0755)
+
+	source := fmt.Sprintf("%s/%s", server.URL, filepath.Base(archive))
+	dir, origin, err := Fetch(source, stagingDir)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(stagingDir, "anyuid"), dir)
+	assert.True(t, fileExists(filepath.Join(dir, "anyuid.addon")))
+	assert.Equal(t, Archive, origin.Type)
+	assert.Equal(t, source, origin.Source)
+	assert.Len(t, origin.Revision, 64)
+}
+
+func Test_fetch_missing_archive_via_http_returns_an_error(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	server := httptest.NewServer(http.FileServer(http.Dir(testDir)))
+	defer server.Close()
+
+	_, _, err := Fetch(server.URL+"/foo.tar.gz", testDir)
+	assert.Error(t, err)
+	assert.Regexp(t, "^Cannot download add-on from .*404 Not Found", err.Error())
+}
+
+func Test_fetch_archive_via_file_url(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	archive := createTarGz(t, testDir, "anyuid")
+	stagingDir := filepath.Join(testDir, "staging")
+	os.Mkdir(stagingDir, 0755)
+
+	dir, origin, err := Fetch("file://"+filepath.ToSlash(archive), stagingDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "anyuid", filepath.Base(dir))
+	assert.Equal(t, Archive, origin.Type)
+}
+
+func Test_fetch_git_repository(t *testing.T) {
+	if _, err := exec.LookPath(gitBinary); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	// create a work tree containing the add-on in a sub directory and push it into a bare repository
+	workDir := filepath.Join(testDir, "work")
+	writeAddOn(t, filepath.Join(workDir, "add-ons", "anyuid"))
+	bareDir := filepath.Join(testDir, "addons.git")
+	git(t, testDir, "init", "--quiet", "--bare", bareDir)
+	git(t, workDir, "init", "--quiet")
+	git(t, workDir, "add", ".")
+	git(t, workDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "anyuid")
+	git(t, workDir, "tag", "v1.0.0")
+	git(t, workDir, "push", "--quiet", bareDir, "HEAD:refs/heads/master", "v1.0.0")
+	expectedRevision := git(t, workDir, "rev-parse", "HEAD")
+
+	stagingDir := filepath.Join(testDir, "staging")
+	os.Mkdir(stagingDir, 0755)
+
+	source := fmt.Sprintf("git+file://%s#v1.0.0:add-ons/anyuid", filepath.ToSlash(bareDir))
+	dir, origin, err := Fetch(source, stagingDir)
+	assert.NoError(t, err)
+	assert.True(t, fileExists(filepath.Join(dir, "anyuid.addon")))
+	assert.False(t, fileExists(filepath.Join(dir, gitMetadataDir)))
+	assert.Equal(t, Git, origin.Type)
+	assert.Equal(t, expectedRevision, origin.Revision)
+}
+
+func Test_parse_git_source(t *testing.T) {
+	var testCases = []struct {
+		source     string
+		repository string
+		ref        string
+		subDir     string
+	}{
+		{"git+https://github.com/minishift/minishift-addons", "https://github.com/minishift/minishift-addons", "", ""},
+		{"git+https://github.com/minishift/minishift-addons#master", "https://github.com/minishift/minishift-addons", "master", ""},
+		{"git+https://github.com/minishift/minishift-addons#master:add-ons/che", "https://github.com/minishift/minishift-addons", "master", "add-ons/che"},
+		{"git+https://github.com/minishift/minishift-addons#:add-ons/che/", "https://github.com/minishift/minishift-addons", "", "add-ons/che"},
+	}
+
+	for _, testCase := range testCases {
+		repository, ref, subDir, err := parseGitSource(testCase.source)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.repository, repository)
+		assert.Equal(t, testCase.ref, ref)
+		assert.Equal(t, testCase.subDir, subDir)
+	}
+}
+
+func Test_parse_git_source_rejects_options(t *testing.T) {
+	var sources = []string{
+		"git+",
+		"git+#master",
+		"git+--upload-pack=touch /tmp/pwned",
+		"git+-u/tmp/evil#master",
+		"git+https://github.com/minishift/minishift-addons#--output=/tmp/pwned",
+		"git+https://github.com/minishift/minishift-addons#-f:add-ons/che",
+	}
+
+	for _, source := range sources {
+		_, _, _, err := parseGitSource(source)
+		assert.EqualError(t, err, fmt.Sprintf("Invalid git add-on source '%s'", source))
+
+		_, _, err = Fetch(source, os.TempDir())
+		assert.EqualError(t, err, fmt.Sprintf("Invalid git add-on source '%s'", source))
+	}
+}
+
+func Test_write_and_read_origin(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	origin, err := ReadOrigin(testDir)
+	assert.NoError(t, err)
+	assert.Nil(t, origin)
+
+	expectedOrigin := &Origin{Source: "https://example.com/anyuid.tar.gz", Type: Archive, Revision: "1234"}
+	assert.NoError(t, WriteOrigin(testDir, expectedOrigin))
+
+	origin, err = ReadOrigin(testDir)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedOrigin, origin)
+}
+
+func Test_fetch_signed_bundle_with_trusted_key(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	addOnDir := filepath.Join(testDir, "src", "anyuid")
+	writeVersionedAddOn(t, addOnDir)
+	bundlePath, err := bundle.Create(addOnDir, testDir, signingKey)
+	assert.NoError(t, err)
+
+	stagingDir := filepath.Join(testDir, "staging")
+	os.Mkdir(stagingDir, 0755)
+	dir, origin, err := FetchVerified(bundlePath, stagingDir, &signingKey.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(stagingDir, "fetch", "anyuid"), dir)
+	assert.True(t, origin.Signed)
+
+	os.RemoveAll(stagingDir)
+	os.Mkdir(stagingDir, 0755)
+	_, _, err = FetchVerified(bundlePath, stagingDir, &otherKey.PublicKey)
+	assert.EqualError(t, err, fmt.Sprintf("The signature of bundle '%s' does not match the trusted key", bundlePath))
+}
+
+func Test_fetch_unsigned_sources_with_trusted_key_returns_error(t *testing.T) {
+	testDir := createTempDir(t)
+	defer os.RemoveAll(testDir)
+
+	trustedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	addOnDir := filepath.Join(testDir, "src", "anyuid")
+	writeVersionedAddOn(t, addOnDir)
+	_, _, err = FetchVerified(addOnDir, testDir, &trustedKey.PublicKey)
+	assert.EqualError(t, err, fmt.Sprintf(unsignedSourceError, addOnDir))
+
+	archive := createTarGz(t, testDir, "anyuid")
+	_, _, err = FetchVerified(archive, testDir, &trustedKey.PublicKey)
+	assert.EqualError(t, err, fmt.Sprintf(unsignedSourceError, archive))
+
+	bundlePath, err := bundle.Create(addOnDir, testDir, nil)
+	assert.NoError(t, err)
+	stagingDir := filepath.Join(testDir, "staging")
+	os.Mkdir(stagingDir, 0755)
+	_, _, err = FetchVerified(bundlePath, stagingDir, &trustedKey.PublicKey)
+	assert.EqualError(t, err, fmt.Sprintf("Unable to find the signature '%s.sig' of the bundle", bundlePath))
+
+	// without trusted key the digests of the bundle are still verified
+	dir, origin, err := Fetch(bundlePath, stagingDir)
+	assert.NoError(t, err)
+	assert.True(t, fileExists(filepath.Join(dir, "anyuid.addon")))
+	assert.False(t, origin.Signed)
+}
+
+func writeVersionedAddOn(t *testing.T, dir string) {
+	err := os.MkdirAll(dir, 0755)
+	assert.NoError(t, err, "Error creating add-on directory")
+	err = ioutil.WriteFile(filepath.Join(dir, "anyuid.addon"), []byte("# Addon-Version: 1.0.0\n"+anyuid), 0644)
+	assert.NoError(t, err, "Error writing add-on file")
+}
+
+func createTempDir(t *testing.T) string {
+	testDir, err := ioutil.TempDir("", "minishift-test-addon-source-")
+	assert.NoError(t, err, "Error creating temp directory")
+	return testDir
+}
+
+func writeAddOn(t *testing.T, dir string) {
+	err := os.MkdirAll(dir, 0755)
+	assert.NoError(t, err, "Error creating add-on directory")
+	err = ioutil.WriteFile(filepath.Join(dir, "anyuid.addon"), []byte(anyuid), 0644)
+	assert.NoError(t, err, "Error writing add-on file")
+}
+
+// createTarGz creates anyuid.tar.gz in dir containing the anyuid add-on below rootDir
+func createTarGz(t *testing.T, dir string, rootDir string) string {
+	archivePath := filepath.Join(dir, "anyuid.tar.gz")
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err, "Error creating archive")
+	defer f.Close()
+
+	gzipWriter := gzip.NewWriter(f)
+	defer gzipWriter.Close()
+	tarWriter := tar.NewWriter(gzipWriter)
+	defer tarWriter.Close()
+
+	err = tarWriter.WriteHeader(&tar.Header{Name: rootDir + "/", Typeflag: tar.TypeDir, Mode: 0755})
+	assert.NoError(t, err)
+	err = tarWriter.WriteHeader(&tar.Header{Name: rootDir + "/anyuid.addon", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(anyuid))})
+	assert.NoError(t, err)
+	_, err = tarWriter.Write([]byte(anyuid))
+	assert.NoError(t, err)
+
+	return archivePath
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	out, err := runGit(dir, args...)
+	assert.NoError(t, err)
+	return out
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/blang/semver"
+)
+
+const (
+	alternativeSeparator = "||"
+	comparatorSeparator  = ","
+)
+
+var (
+	versionRegexp    = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}(-[0-9A-Za-z.-]+)?$`)
+	comparatorRegexp = regexp.MustCompile(`^(==|=|!=|>=|<=|>|<)?(.*)$`)
+)
+
+// VersionRange is a set of semantic version constraints, eg '>=3.6, <3.10' or '1.2.0 || >=2'. Comparators
+// separated by comma or whitespace all need to match, alternatives separated by '||' are or-ed. Versions can be
+// specified as <major>, <major>.<minor> or <major>.<minor>.<patch> with an optional pre-release suffix.
+type VersionRange struct {
+	raw          string
+	alternatives [][]versionComparator
+}
+
+type versionComparator struct {
+	operator string
+	version  semver.Version
+}
+
+// ParseVersionRange parses the specified version range.
+func ParseVersionRange(versionRange string) (*VersionRange, error) {
+	parsed := &VersionRange{raw: strings.TrimSpace(versionRange)}
+	for _, alternative := range strings.Split(versionRange, alternativeSeparator) {
+		comparators, err := parseComparators(alternative)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid version range '%s': %s", versionRange, err.Error()))
+		}
+		parsed.alternatives = append(parsed.alternatives, comparators)
+	}
+	return parsed, nil
+}
+
+// ParseVersion parses a single version in the format accepted within version ranges.
+func ParseVersion(version string) (semver.Version, error) {
+	version = strings.TrimSpace(version)
+	if !versionRegexp.MatchString(version) {
+		return semver.Version{}, errors.New(fmt.Sprintf("'%s' is not a valid version", version))
+	}
+	return semver.ParseTolerant(version)
+}
+
+// Contains returns true if the specified version satisfies this version range.
+func (r *VersionRange) Contains(version semver.Version) bool {
+	for _, comparators := range r.alternatives {
+		matches := true
+		for _, comparator := range comparators {
+			if !comparator.matches(version) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *VersionRange) String() string {
+	return r.raw
+}
+
+func parseComparators(alternative string) ([]versionComparator, error) {
+	// allow whitespace between operator and version, eg '>= 1.2'
+	tokens := strings.Fields(strings.Replace(alternative, comparatorSeparator, " ", -1))
+	var comparators []versionComparator
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if comparatorRegexp.FindStringSubmatch(token)[2] == "" && i+1 < len(tokens) {
+			i++
+			token += tokens[i]
+		}
+
+		match := comparatorRegexp.FindStringSubmatch(token)
+		version, err := ParseVersion(match[2])
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, versionComparator{operator: match[1], version: version})
+	}
+
+	if len(comparators) == 0 {
+		return nil, errors.New("Empty version constraint")
+	}
+	return comparators, nil
+}
+
+func (c versionComparator) matches(version semver.Version) bool {
+	switch c.operator {
+	case ">=":
+		return version.GTE(c.version)
+	case ">":
+		return version.GT(c.version)
+	case "<=":
+		return version.LTE(c.version)
+	case "<":
+		return version.LT(c.version)
+	case "!=":
+		return version.NE(c.version)
+	default:
+		return version.EQ(c.version)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package addon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_version_range_contains(t *testing.T) {
+	var testCases = []struct {
+		versionRange string
+		version      string
+		expected     bool
+	}{
+		{"3.6.0", "3.6.0", true},
+		{"3.6", "3.6.0", true},
+		{"3.6", "3.6.1", false},
+		{">=3.6, <3.10", "3.9.0", true},
+		{">=3.6, <3.10", "3.10.0", false},
+		{">= 3.6 < 3.10", "3.5.9", false},
+		{">3.6, <=3.9, !=3.7.0", "3.7.0", false},
+		{">3.6, <=3.9, !=3.7.0", "3.8.0", true},
+		{"3.6 || >=3.9", "3.6.0", true},
+		{"3.6 || >=3.9", "3.7.0", false},
+		{"3.6 || >=3.9", "3.11.0", true},
+		{"<3.10", "3.10.0-rc.0", true},
+	}
+
+	for _, testCase := range testCases {
+		versionRange, err := ParseVersionRange(testCase.versionRange)
+		assert.NoError(t, err)
+		version, err := ParseVersion(testCase.version)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, versionRange.Contains(version), "Unexpected result for '%s' in '%s'", testCase.version, testCase.versionRange)
+	}
+}
+
+func Test_invalid_version_range_returns_error(t *testing.T) {
+	_, err := ParseVersionRange(">=3.6, <")
+	assert.EqualError(t, err, "Invalid version range '>=3.6, <': '' is not a valid version")
+
+	_, err = ParseVersionRange("")
+	assert.EqualError(t, err, "Invalid version range '': Empty version constraint")
+}
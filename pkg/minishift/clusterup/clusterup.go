@@ -62,6 +62,7 @@ type ClusterUpConfig struct {
 	SSHCommander         provision.SSHCommander
 	OcBinaryPathInsideVM string
 	SshUser              string
+	ForceAddOns          bool
 }
 
 // ClusterUp execute oc binary in order to run 'cluster up'
@@ -143,7 +144,7 @@ func PostClusterUp(clusterUpConfig *ClusterUpConfig, sshCommander provision.SSHC
 		return err
 	}
 
-	err = applyAddOns(addOnManager, clusterUpConfig.Ip, clusterUpConfig.RoutingSuffix, clusterUpConfig.SshUser, clusterUpConfig.AddonEnv, ocRunner, sshCommander)
+	err = applyAddOns(addOnManager, clusterUpConfig.Ip, clusterUpConfig.RoutingSuffix, clusterUpConfig.SshUser, clusterUpConfig.AddonEnv, clusterUpConfig.ForceAddOns, ocRunner, sshCommander)
 	if err != nil {
 		return err
 	}
@@ -215,13 +216,13 @@ func CopyOcBinaryFromImageToVM(dockerCommander docker.DockerCommander, image str
 	return nil
 }
 
-func applyAddOns(addOnManager *manager.AddOnManager, ip string, routingSuffix string, sshUser string, addonEnv []string, ocRunner *oc.OcRunner, sshCommander provision.SSHCommander) error {
+func applyAddOns(addOnManager *manager.AddOnManager, ip string, routingSuffix string, sshUser string, addonEnv []string, forceAddOns bool, ocRunner *oc.OcRunner, sshCommander provision.SSHCommander) error {
 	context, err := GetExecutionContext(ip, routingSuffix, sshUser, addonEnv, ocRunner, sshCommander)
 	if err != nil {
 		return err
 	}
 
-	err = addOnManager.Apply(context)
+	err = addOnManager.Apply(context, forceAddOns)
 	if err != nil {
 		return err
 	}
@@ -33,6 +33,7 @@ type InstanceConfigType struct {
 	CacheImages []string `json:"cache-images"`
 	HostFolders []hostFolderConfig.HostFolderConfig
 	AddonConfig map[string]*addOnConfig.AddOnConfig `json:"addons"`
+	DnsRecords  map[string]string                   `json:"dns-records,omitempty"` // host name to IP address
 }
 
 // Create new object with data if file exists or
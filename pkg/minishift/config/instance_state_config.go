@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"os"
 
+	addOnConfig "github.com/minishift/minishift/pkg/minishift/addon/config"
 	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
 )
 
@@ -36,8 +37,12 @@ type InstanceStateConfigType struct {
 	SupportsDnsmasqServer     bool                      // minishift state
 	OpenshiftVersion          string                    // minishift state
 	TimeZone                  string                    // minishift state
+	HostFolderMonitorPID      int                       // minishift state
+	DnsResolverPID            int                       // minishift state
 	HostFolders               []config.HostFolderConfig // This is temporary and should be removed after 2-3 release.
 
+	AddOnHistory map[string][]*addOnConfig.AddOnApplyRecord // minishift state, apply history per add-on
+
 	VMDriver string // general config
 }
 
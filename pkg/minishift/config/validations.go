@@ -129,6 +129,14 @@ func IsValidProxy(name string, uri string) error {
 	return nil
 }
 
+// IsValidProxyRoutes checks a comma separated list of proxy routes of the form '<host pattern>=<proxy URL|DIRECT>'.
+func IsValidProxyRoutes(name string, routes string) error {
+	if _, err := util.ParseProxyRoutes(strings.Split(routes, ",")); err != nil {
+		return fmt.Errorf("'%s' is not valid: %v", name, err)
+	}
+	return nil
+}
+
 func IsValidISOUrl(_ string, isoURL string) error {
 	for _, isoAlias := range minishiftConstants.ValidIsoAliases {
 		if isoURL == isoAlias {
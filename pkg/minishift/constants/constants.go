@@ -43,12 +43,14 @@ const (
 	SystemtrayDaemon               = "systemtray"
 	SftpdDaemon                    = "sftpd"
 	ProxyDaemon                    = "proxy"
+	HostFolderMonitorDaemon        = "hostfolder-monitor"
+	DnsResolverDaemon              = "dns-resolver"
 )
 
 var (
 	ValidIsoAliases = []string{CentOsIsoAlias}
 	ValidComponents = []string{"automation-service-broker", "service-catalog", "template-service-broker"}
-	ValidServices   = []string{SystemtrayDaemon, SftpdDaemon, ProxyDaemon}
+	ValidServices   = []string{SystemtrayDaemon, SftpdDaemon, ProxyDaemon, HostFolderMonitorDaemon, DnsResolverDaemon}
 )
 
 // ProfileAuthorizedKeysPath returns the path of authorized_keys file in profile dir used for authentication purpose
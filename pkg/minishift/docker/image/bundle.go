@@ -0,0 +1,464 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minishift/minishift/pkg/util/archive"
+	"github.com/minishift/minishift/pkg/util/filehelper"
+)
+
+const (
+	// BundleManifestFileName is the name of the manifest describing the content of an image bundle
+	BundleManifestFileName = "bundle-manifest.json"
+
+	bundleFormatVersion = 1
+	// bundleImagesDir is the directory of an image bundle containing the OCI layout with the bundled images
+	bundleImagesDir = "images"
+)
+
+// BundleFile is a file contained in an image bundle.
+type BundleFile struct {
+	// Path is the slash separated path of the file within the bundle and relative to the cache directory
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// BundleManifest describes the content of an image bundle. It is the first entry of the bundle.
+type BundleManifest struct {
+	FormatVersion    int           `json:"formatVersion"`
+	OpenShiftVersion string        `json:"openshiftVersion,omitempty"`
+	Created          time.Time     `json:"created"`
+	Images           []string      `json:"images"`
+	Files            []*BundleFile `json:"files"`
+}
+
+// BundleOptions configures the creation of an image bundle.
+type BundleOptions struct {
+	// CacheDir is the Minishift cache directory. The bundled files are loaded relative to it.
+	CacheDir string
+	// ImageCacheDir is the OCI image cache from which the images are bundled
+	ImageCacheDir string
+	// Images are the names of the images to bundle. All of them need to be cached.
+	Images []string
+	// Files are additional files to bundle, eg the oc binary or the ISO, as slash separated paths relative to CacheDir
+	Files []string
+	// OpenShiftVersion is the OpenShift version the bundle is created for
+	OpenShiftVersion string
+}
+
+// BundleLoadResult describes the outcome of loading an image bundle.
+type BundleLoadResult struct {
+	Manifest *BundleManifest
+	// LoadedBlobs is the number of blobs added to the image cache, SkippedBlobs the number of blobs which were cached already
+	LoadedBlobs  int
+	SkippedBlobs int
+	// LoadedFiles are the additional files added to the cache, SkippedFiles the ones which were cached already
+	LoadedFiles  []string
+	SkippedFiles []string
+}
+
+// CreateBundle creates the uncompressed tar archive target containing the specified images of the image cache and
+// the additional files, so that they can be loaded into the cache of a machine without network access.
+func (handler *OciImageHandler) CreateBundle(target string, options *BundleOptions) (*BundleManifest, error) {
+	index, err := handler.getIndex(options.ImageCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		index = &Index{}
+	}
+
+	manifests := make(map[string]Manifest)
+	for _, manifest := range index.Manifests {
+		manifests[manifest.Annotations.Name] = manifest
+	}
+
+	bundleIndex := &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	var missing []string
+	for _, image := range options.Images {
+		manifest, found := manifests[image]
+		if !found {
+			missing = append(missing, image)
+			continue
+		}
+		bundleIndex.Manifests = append(bundleIndex.Manifests, manifest)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("The images %s are not cached. Export them first using 'minishift image export'", strings.Join(missing, ", "))
+	}
+
+	stagingDir, err := ioutil.TempDir(options.ImageCacheDir, ".bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	indexPath := filepath.Join(stagingDir, "index.json")
+	if err := handler.updateIndex(stagingDir, bundleIndex); err != nil {
+		return nil, err
+	}
+
+	bundleManifest := &BundleManifest{
+		FormatVersion:    bundleFormatVersion,
+		OpenShiftVersion: options.OpenShiftVersion,
+		Created:          time.Now().UTC(),
+		Images:           options.Images,
+	}
+	entries := []archive.Entry{}
+	addFile := func(name string, filePath string, checksum string) error {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return err
+		}
+		if checksum == "" {
+			if checksum, err = sha256File(filePath); err != nil {
+				return err
+			}
+		}
+		bundleManifest.Files = append(bundleManifest.Files, &BundleFile{Path: name, SHA256: checksum, Size: info.Size()})
+		entries = append(entries, archive.Entry{Name: name, Path: filePath})
+		return nil
+	}
+
+	if err := addFile(path.Join(bundleImagesDir, "index.json"), indexPath, ""); err != nil {
+		return nil, err
+	}
+	layoutPath := filepath.Join(options.ImageCacheDir, "oci-layout")
+	if filehelper.Exists(layoutPath) {
+		if err := addFile(path.Join(bundleImagesDir, "oci-layout"), layoutPath, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	blobs := make(map[string]bool)
+	for _, manifest := range bundleIndex.Manifests {
+		for _, blob := range getImageBlobs(options.ImageCacheDir, manifest) {
+			if blobs[blob] {
+				continue
+			}
+			blobs[blob] = true
+
+			// The digest of a blob is its checksum, so it does not need to be computed again
+			parts := strings.SplitN(blob, ":", 2)
+			checksum := ""
+			if len(parts) == 2 && parts[0] == "sha256" {
+				checksum = parts[1]
+			}
+			name := path.Join(bundleImagesDir, "blobs", strings.Replace(blob, ":", "/", 1))
+			if err := addFile(name, blobPath(options.ImageCacheDir, blob), checksum); err != nil {
+				return nil, fmt.Errorf("The cached image '%s' is incomplete. Export it again using 'minishift image export --overwrite': %v",
+					manifest.Annotations.Name, err)
+			}
+		}
+	}
+
+	for _, file := range options.Files {
+		if err := validateBundlePath(file); err != nil {
+			return nil, err
+		}
+		if err := addFile(file, filepath.Join(options.CacheDir, filepath.FromSlash(file)), ""); err != nil {
+			return nil, err
+		}
+	}
+
+	manifestPath := filepath.Join(stagingDir, BundleManifestFileName)
+	jsonData, err := json.MarshalIndent(bundleManifest, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(manifestPath, jsonData, 0644); err != nil {
+		return nil, err
+	}
+	entries = append([]archive.Entry{{Name: BundleManifestFileName, Path: manifestPath}}, entries...)
+
+	// Write to a temporary file first, so that an interrupted run does not leave a truncated bundle behind
+	partial := target + ".part"
+	if err := archive.Tar(partial, entries); err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+	if err := os.Rename(partial, target); err != nil {
+		os.Remove(partial)
+		return nil, err
+	}
+
+	return bundleManifest, nil
+}
+
+// LoadBundle verifies the image bundle bundlePath against its manifest and adds its images to imageCacheDir and its
+// additional files to cacheDir. Blobs and files which are cached already are kept.
+func (handler *OciImageHandler) LoadBundle(bundlePath string, cacheDir string, imageCacheDir string) (*BundleLoadResult, error) {
+	if err := os.MkdirAll(imageCacheDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Unpack next to the cache, so that the content can be moved into it. The staging directory is claimed like the one
+	// of an export, so that the garbage collection does not remove the blobs before they are added to the index.
+	stagingDir, err := claimBundleStagingDir(imageCacheDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stagingDir)
+	bundleDir := filepath.Join(stagingDir, "bundle")
+
+	if err := archive.Untar(bundlePath, bundleDir); err != nil {
+		return nil, fmt.Errorf("Cannot unpack the image bundle '%s': %v", bundlePath, err)
+	}
+
+	manifest, err := readBundleManifest(bundleDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyBundle(bundleDir, manifest); err != nil {
+		return nil, fmt.Errorf("The image bundle '%s' is invalid: %v", bundlePath, err)
+	}
+
+	result := &BundleLoadResult{Manifest: manifest}
+	imagesPrefix := bundleImagesDir + "/"
+	for _, file := range manifest.Files {
+		source := filepath.Join(bundleDir, filepath.FromSlash(file.Path))
+		switch {
+		case file.Path == path.Join(bundleImagesDir, "index.json"):
+			// merged into the index of the cache once all blobs are in place
+		case strings.HasPrefix(file.Path, imagesPrefix):
+			loaded, err := moveIfAbsent(source, filepath.Join(imageCacheDir, filepath.FromSlash(strings.TrimPrefix(file.Path, imagesPrefix))))
+			if err != nil {
+				return nil, err
+			}
+			if file.Path == path.Join(bundleImagesDir, "oci-layout") {
+				continue
+			}
+			if loaded {
+				result.LoadedBlobs++
+			} else {
+				result.SkippedBlobs++
+			}
+		default:
+			loaded, err := moveIfAbsent(source, filepath.Join(cacheDir, filepath.FromSlash(file.Path)))
+			if err != nil {
+				return nil, err
+			}
+			if loaded {
+				result.LoadedFiles = append(result.LoadedFiles, file.Path)
+			} else {
+				result.SkippedFiles = append(result.SkippedFiles, file.Path)
+			}
+		}
+	}
+
+	if err := handler.mergeIndex(imageCacheDir, filepath.Join(bundleDir, bundleImagesDir)); err != nil {
+		return nil, err
+	}
+
+	if err := touchImages(imageCacheDir, manifest.Images); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mergeIndex adds the manifests of the index in bundleDir to the index of the cache, replacing the manifests of
+// images with the same name.
+func (handler *OciImageHandler) mergeIndex(cacheDir string, bundleDir string) error {
+	bundleIndex, err := handler.getIndex(bundleDir)
+	if err != nil {
+		return err
+	}
+
+	handler.indexLock.Lock()
+	defer handler.indexLock.Unlock()
+	unlock, err := lockImageCache(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index, err := handler.getIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	if index == nil {
+		index = &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	}
+
+	bundled := make(map[string]bool)
+	for _, manifest := range bundleIndex.Manifests {
+		bundled[manifest.Annotations.Name] = true
+	}
+	manifests := Manifests{}
+	for _, manifest := range index.Manifests {
+		if !bundled[manifest.Annotations.Name] {
+			manifests = append(manifests, manifest)
+		}
+	}
+	index.Manifests = append(manifests, bundleIndex.Manifests...)
+
+	return handler.updateIndex(cacheDir, index)
+}
+
+// claimBundleStagingDir creates a staging directory for loading a bundle in imageCacheDir and records the id of the
+// current process in it, while holding the lock of the cache.
+func claimBundleStagingDir(imageCacheDir string) (string, error) {
+	unlock, err := lockImageCache(imageCacheDir)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	stagingDir, err := ioutil.TempDir(imageCacheDir, ".bundle-")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(stagingDir, exportPidFile), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", err
+	}
+	return stagingDir, nil
+}
+
+func readBundleManifest(bundleDir string) (*BundleManifest, error) {
+	raw, err := ioutil.ReadFile(filepath.Join(bundleDir, BundleManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("The image bundle does not contain %s: %v", BundleManifestFileName, err)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("Cannot parse %s: %v", BundleManifestFileName, err)
+	}
+	if manifest.FormatVersion != bundleFormatVersion {
+		return nil, fmt.Errorf("Unsupported image bundle format version %d", manifest.FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// verifyBundle checks that the unpacked bundle in bundleDir contains exactly the files listed in the manifest with
+// the recorded sizes and checksums.
+func verifyBundle(bundleDir string, manifest *BundleManifest) error {
+	expected := make(map[string]*BundleFile)
+	for _, file := range manifest.Files {
+		if err := validateBundlePath(file.Path); err != nil {
+			return err
+		}
+		expected[file.Path] = file
+	}
+	if _, found := expected[path.Join(bundleImagesDir, "index.json")]; !found {
+		return fmt.Errorf("The bundle does not contain an image index")
+	}
+
+	var unexpected []string
+	err := filepath.Walk(bundleDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(bundleDir, filePath)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relative)
+		if name == BundleManifestFileName {
+			return nil
+		}
+
+		file, found := expected[name]
+		if !found {
+			unexpected = append(unexpected, name)
+			return nil
+		}
+		delete(expected, name)
+
+		if info.Size() != file.Size {
+			return fmt.Errorf("The size of '%s' is %d instead of %d", name, info.Size(), file.Size)
+		}
+		checksum, err := sha256File(filePath)
+		if err != nil {
+			return err
+		}
+		if checksum != file.SHA256 {
+			return fmt.Errorf("The checksum of '%s' does not match the manifest", name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(unexpected) > 0 {
+		return fmt.Errorf("The files %s are not listed in the manifest", strings.Join(unexpected, ", "))
+	}
+	if len(expected) > 0 {
+		var missing []string
+		for name := range expected {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("The files %s listed in the manifest are missing", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateBundlePath makes sure a bundle path stays within the cache directory
+func validateBundlePath(name string) error {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") ||
+		strings.Contains(name, "\\") {
+		return fmt.Errorf("Invalid bundle path '%s'", name)
+	}
+	return nil
+}
+
+// moveIfAbsent moves source to target unless target exists already. It returns whether the file was moved.
+func moveIfAbsent(source string, target string) (bool, error) {
+	if filehelper.Exists(target) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return false, err
+	}
+	if err := os.Rename(source, target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func sha256File(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
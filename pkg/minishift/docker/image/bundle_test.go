@@ -0,0 +1,206 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testOcPath = "oc/v3.11.0/linux/oc"
+
+func Test_bundle_can_be_loaded_into_empty_cache(t *testing.T) {
+	sourceDir := createBundleSource(t)
+	defer os.RemoveAll(sourceDir)
+
+	bundle := filepath.Join(sourceDir, "bundle.tar")
+	handler := &OciImageHandler{}
+	manifest, err := handler.CreateBundle(bundle, &BundleOptions{
+		CacheDir:         sourceDir,
+		ImageCacheDir:    filepath.Join(sourceDir, "images"),
+		Images:           []string{"acme/foo:v1", "acme/bar:v1"},
+		Files:            []string{testOcPath},
+		OpenShiftVersion: "v3.11.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "v3.11.0", manifest.OpenShiftVersion)
+	assert.False(t, fileExists(bundle+".part"))
+
+	targetDir := createCacheDir(t)
+	defer os.RemoveAll(targetDir)
+	imageCacheDir := filepath.Join(targetDir, "images")
+
+	result, err := handler.LoadBundle(bundle, targetDir, imageCacheDir)
+	assert.NoError(t, err)
+	// base layer, both image layers, configs and manifests
+	assert.Equal(t, 7, result.LoadedBlobs)
+	assert.Equal(t, 0, result.SkippedBlobs)
+	assert.Equal(t, []string{testOcPath}, result.LoadedFiles)
+
+	config := &ImageCacheConfig{HostCacheDir: imageCacheDir, CachedImages: []string{"acme/foo:v1", "acme/bar:v1"}}
+	assert.True(t, handler.AreImagesCached(config))
+	assert.False(t, handler.IsImageCached(config, "acme/baz:v1"))
+
+	info, err := os.Stat(filepath.Join(targetDir, filepath.FromSlash(testOcPath)))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// the loaded images can be imported without any network access
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	config.Out = ioutil.Discard
+	imported, err := daemon.handler().ImportImages(config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/foo:v1", "acme/bar:v1"}, imported)
+
+	references, err := LoadImageReferences(imageCacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, references.LastUsed, 2)
+
+	entries, err := ioutil.ReadDir(imageCacheDir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".bundle-", "Staging directory was not removed")
+	}
+}
+
+func Test_loading_bundle_keeps_cached_blobs_and_files(t *testing.T) {
+	sourceDir := createBundleSource(t)
+	defer os.RemoveAll(sourceDir)
+
+	bundle := filepath.Join(sourceDir, "bundle.tar")
+	handler := &OciImageHandler{}
+	_, err := handler.CreateBundle(bundle, &BundleOptions{
+		CacheDir:      sourceDir,
+		ImageCacheDir: filepath.Join(sourceDir, "images"),
+		Images:        []string{"acme/foo:v1", "acme/baz:v1"},
+		Files:         []string{testOcPath},
+	})
+	assert.NoError(t, err)
+
+	// loading into the cache the bundle was created from does not change anything but the index entries
+	result, err := handler.LoadBundle(bundle, sourceDir, filepath.Join(sourceDir, "images"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.LoadedBlobs)
+	assert.Equal(t, 7, result.SkippedBlobs)
+	assert.Empty(t, result.LoadedFiles)
+	assert.Equal(t, []string{testOcPath}, result.SkippedFiles)
+
+	index, err := handler.getIndex(filepath.Join(sourceDir, "images"))
+	assert.NoError(t, err)
+	assert.Len(t, index.Manifests, 4)
+}
+
+func Test_bundle_creation_fails_for_images_which_are_not_cached(t *testing.T) {
+	sourceDir := createBundleSource(t)
+	defer os.RemoveAll(sourceDir)
+
+	bundle := filepath.Join(sourceDir, "bundle.tar")
+	handler := &OciImageHandler{}
+	_, err := handler.CreateBundle(bundle, &BundleOptions{
+		CacheDir:      sourceDir,
+		ImageCacheDir: filepath.Join(sourceDir, "images"),
+		Images:        []string{"acme/foo:v1", "acme/missing:v1", "acme/other:v2"},
+	})
+	assert.EqualError(t, err, "The images acme/missing:v1, acme/other:v2 are not cached. Export them first using 'minishift image export'")
+	assert.False(t, fileExists(bundle))
+}
+
+func Test_invalid_bundles_are_rejected(t *testing.T) {
+	index := []byte(`{"manifests":[],"schemaVersion":2}`)
+	validManifest := func() *BundleManifest {
+		return &BundleManifest{
+			FormatVersion: bundleFormatVersion,
+			Files:         []*BundleFile{{Path: "images/index.json", SHA256: sha256Hex(index), Size: int64(len(index))}},
+		}
+	}
+
+	var testCases = []struct {
+		description string
+		manifest    func() *BundleManifest
+		files       map[string][]byte
+		expectedErr string
+	}{
+		{"valid", validManifest, nil, ""},
+		{"unknown format", func() *BundleManifest {
+			manifest := validManifest()
+			manifest.FormatVersion = 2
+			return manifest
+		}, nil, "Unsupported image bundle format version 2"},
+		{"checksum mismatch", validManifest, map[string][]byte{"images/index.json": []byte(`{"manifests":[],"schemaVersion":3}`)},
+			"checksum of 'images/index.json' does not match"},
+		{"unlisted file", validManifest, map[string][]byte{"config/config.json": []byte("{}")},
+			"files config/config.json are not listed in the manifest"},
+		{"missing file", func() *BundleManifest {
+			manifest := validManifest()
+			manifest.Files = append(manifest.Files, &BundleFile{Path: "iso/centos/minishift.iso", SHA256: "abc", Size: 3})
+			return manifest
+		}, nil, "files iso/centos/minishift.iso listed in the manifest are missing"},
+		{"path outside of cache", func() *BundleManifest {
+			manifest := validManifest()
+			manifest.Files = append(manifest.Files, &BundleFile{Path: "../evil", SHA256: "abc", Size: 3})
+			return manifest
+		}, nil, "Invalid bundle path '../evil'"},
+	}
+
+	for _, testCase := range testCases {
+		testDir := createCacheDir(t)
+
+		raw, err := json.Marshal(testCase.manifest())
+		assert.NoError(t, err)
+		files := map[string][]byte{BundleManifestFileName: raw, "images/index.json": index}
+		for name, content := range testCase.files {
+			files[name] = content
+		}
+		bundle := filepath.Join(testDir, "bundle.tar")
+		assert.NoError(t, ioutil.WriteFile(bundle, createTar(t, files), 0644))
+
+		handler := &OciImageHandler{}
+		_, err = handler.LoadBundle(bundle, testDir, filepath.Join(testDir, "images"))
+		if testCase.expectedErr == "" {
+			assert.NoError(t, err, testCase.description)
+		} else {
+			assert.Error(t, err, testCase.description)
+			if err != nil {
+				assert.Contains(t, err.Error(), testCase.expectedErr, testCase.description)
+			}
+			assert.False(t, fileExists(filepath.Join(testDir, "images", "index.json")), testCase.description)
+		}
+		os.RemoveAll(testDir)
+	}
+}
+
+// createBundleSource creates a cache directory containing the test images and a cached oc binary
+func createBundleSource(t *testing.T) string {
+	cacheDir := createCacheDir(t)
+	exportTestImages(t, filepath.Join(cacheDir, "images"), testImages...)
+
+	ocPath := filepath.Join(cacheDir, filepath.FromSlash(testOcPath))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(ocPath), 0755))
+	assert.NoError(t, ioutil.WriteFile(ocPath, []byte("#!/bin/sh\necho oc\n"), 0755))
+	return cacheDir
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// ImageDetails describes an image of the image cache.
+type ImageDetails struct {
+	Name string `json:"name"`
+	// Digest is the digest of the image manifest
+	Digest string `json:"digest"`
+	// Size is the compressed size of the manifest, config and layer blobs of the image
+	Size   int64 `json:"size"`
+	Layers int   `json:"layers"`
+	// Created is the creation time recorded in the image config
+	Created time.Time `json:"created"`
+	// LastUsed is the time the image was last imported or exported. It is zero if this has not been recorded.
+	LastUsed time.Time `json:"lastUsed"`
+	// Profiles are the profiles which have recorded that they need the image
+	Profiles []string `json:"profiles"`
+}
+
+// ociConfig is the part of an OCI image config needed for the image details
+type ociConfig struct {
+	Created time.Time `json:"created"`
+}
+
+// GetCachedImageDetails returns the details of all images of the specified cache directory, sorted by name.
+func (handler *OciImageHandler) GetCachedImageDetails(cacheDir string) ([]*ImageDetails, error) {
+	details := []*ImageDetails{}
+	index, err := handler.getIndex(cacheDir)
+	if err != nil || index == nil {
+		return details, err
+	}
+
+	references, err := LoadImageReferences(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	blobSizes, err := getBlobSizes(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, manifest := range index.Manifests {
+		name := manifest.Annotations.Name
+		image := &ImageDetails{
+			Name:     name,
+			Digest:   manifest.Digest,
+			LastUsed: references.LastUsed[name],
+			Profiles: references.ReferencingProfiles(name),
+		}
+		if image.Profiles == nil {
+			image.Profiles = []string{}
+		}
+
+		for _, blob := range getImageBlobs(cacheDir, manifest) {
+			image.Size += blobSizes[blob]
+		}
+
+		raw, err := ioutil.ReadFile(blobPath(cacheDir, manifest.Digest))
+		if err == nil {
+			var imageManifest ociManifest
+			if json.Unmarshal(raw, &imageManifest) == nil {
+				image.Layers = len(imageManifest.Layers)
+				image.Created = getCreated(cacheDir, imageManifest.Config.Digest)
+			}
+		}
+
+		details = append(details, image)
+	}
+
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Name < details[j].Name
+	})
+	return details, nil
+}
+
+// getCreated returns the creation time recorded in the specified config blob, or the zero time if it is unknown
+func getCreated(cacheDir string, configDigest string) time.Time {
+	if configDigest == "" {
+		return time.Time{}
+	}
+	raw, err := ioutil.ReadFile(blobPath(cacheDir, configDigest))
+	if err != nil {
+		return time.Time{}
+	}
+	var config ociConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return time.Time{}
+	}
+	return config.Created
+}
@@ -0,0 +1,73 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cached_image_details(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	assert.NoError(t, RecordProfileImages(cacheDir, "other", []string{"acme/foo:v1", "acme/baz:v1"}))
+
+	lastUsed := time.Date(2018, 7, 1, 12, 0, 0, 0, time.UTC)
+	setLastUsed(t, cacheDir, map[string]time.Time{"acme/foo:v1": lastUsed})
+
+	handler := &OciImageHandler{}
+	details, err := handler.GetCachedImageDetails(cacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, details, 2)
+
+	bar, foo := details[0], details[1]
+	assert.Equal(t, "acme/bar:v1", bar.Name)
+	assert.Equal(t, "acme/foo:v1", foo.Name)
+
+	index, err := handler.getIndex(cacheDir)
+	assert.NoError(t, err)
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations.Name == "acme/foo:v1" {
+			assert.Equal(t, manifest.Digest, foo.Digest)
+		}
+	}
+
+	assert.Equal(t, 2, foo.Layers)
+	assert.Equal(t, imagesSize(t, cacheDir, "acme/foo:v1"), foo.Size)
+	assert.True(t, testImageCreated.Equal(foo.Created), "Unexpected creation time %v", foo.Created)
+	assert.True(t, lastUsed.Equal(foo.LastUsed))
+	assert.Equal(t, []string{"minishift", "other"}, foo.Profiles)
+
+	// exporting records the time of the export
+	assert.WithinDuration(t, time.Now(), bar.LastUsed, time.Minute)
+	assert.Equal(t, []string{}, bar.Profiles)
+}
+
+func Test_image_details_of_empty_cache(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	details, err := handler.GetCachedImageDetails(cacheDir)
+	assert.NoError(t, err)
+	assert.Empty(t, details)
+}
@@ -0,0 +1,248 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDockerDaemon emulates the parts of the Docker API used by the docker-daemon transport as well as the docker
+// commands run in the VM, so that images can be imported and exported without a VM
+type fakeDockerDaemon struct {
+	server *httptest.Server
+	lock   sync.Mutex
+	// images contains the images of the daemon as docker save archives keyed by image name
+	images map[string][]byte
+	// registry contains the images which can be pulled by the daemon
+	registry map[string][]byte
+	// delay is the time each save and load request takes, to make parallel requests observable
+	delay       time.Duration
+	inFlight    int
+	maxInFlight int
+	saves       int
+	loads       int
+}
+
+func newFakeDockerDaemon(t *testing.T) *fakeDockerDaemon {
+	daemon := &fakeDockerDaemon{images: make(map[string][]byte), registry: make(map[string][]byte)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.22/images/get", daemon.saveImage)
+	mux.HandleFunc("/v1.22/images/load", daemon.loadImage)
+	daemon.server = httptest.NewServer(mux)
+	return daemon
+}
+
+func (d *fakeDockerDaemon) close() {
+	d.server.Close()
+}
+
+// handler returns an OciImageHandler connected to the fake daemon
+func (d *fakeDockerDaemon) handler() *OciImageHandler {
+	return &OciImageHandler{
+		dockerClientSettings: &dockerClientConfig{DockerHost: d.server.URL},
+		runInVM:              d.runInVM,
+	}
+}
+
+func (d *fakeDockerDaemon) imageNames() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var names []string
+	for name := range d.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *fakeDockerDaemon) runInVM(cmd string) (string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	switch {
+	case strings.HasPrefix(cmd, "docker images "):
+		var names []string
+		for name := range d.images {
+			names = append(names, name)
+		}
+		return strings.Join(names, "\n"), nil
+	case strings.HasPrefix(cmd, "docker pull "):
+		name := strings.TrimPrefix(cmd, "docker pull ")
+		archive, found := d.registry[name]
+		if !found {
+			return "", fmt.Errorf("Error running command '%s': Process exited with status 1 \nmanifest for %s not found", cmd, name)
+		}
+		d.images[name] = archive
+		return "", nil
+	}
+	return "", fmt.Errorf("Unexpected command '%s'", cmd)
+}
+
+func (d *fakeDockerDaemon) begin() {
+	d.lock.Lock()
+	d.inFlight++
+	if d.inFlight > d.maxInFlight {
+		d.maxInFlight = d.inFlight
+	}
+	d.lock.Unlock()
+	time.Sleep(d.delay)
+}
+
+func (d *fakeDockerDaemon) end() {
+	d.lock.Lock()
+	d.inFlight--
+	d.lock.Unlock()
+}
+
+func (d *fakeDockerDaemon) saveImage(w http.ResponseWriter, r *http.Request) {
+	d.begin()
+	defer d.end()
+
+	name := r.URL.Query().Get("names")
+	d.lock.Lock()
+	archive, found := d.images[name]
+	d.saves++
+	d.lock.Unlock()
+	if !found {
+		http.Error(w, fmt.Sprintf(`{"message":"reference does not exist: %s"}`, name), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Write(archive)
+}
+
+func (d *fakeDockerDaemon) loadImage(w http.ResponseWriter, r *http.Request) {
+	d.begin()
+	defer d.end()
+
+	archive, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var manifest []struct{ RepoTags []string }
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if header.Name == "manifest.json" {
+			if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if len(manifest) != 1 || len(manifest[0].RepoTags) != 1 {
+		http.Error(w, "Invalid manifest", http.StatusBadRequest)
+		return
+	}
+
+	// like the Docker daemon, image names are shown without the default registry
+	name := strings.TrimPrefix(manifest[0].RepoTags[0], "docker.io/")
+	d.lock.Lock()
+	d.images[name] = archive
+	d.loads++
+	d.lock.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"stream":"Loaded image: %s\n"}`, name)
+}
+
+// testImageCreated is the creation time of all images created by createImageArchive
+var testImageCreated = time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+
+// createImageArchive creates an archive in the format of docker save for an image with the specified name and layers.
+// Each layer is a tar file containing a single file with the specified content.
+func createImageArchive(t *testing.T, name string, layerContents ...string) []byte {
+	var layers [][]byte
+	var diffIDs, layerPaths []string
+	for i, content := range layerContents {
+		layer := createTar(t, map[string][]byte{fmt.Sprintf("layer-%d.txt", i): []byte(content)})
+		layers = append(layers, layer)
+		digest := sha256Hex(layer)
+		diffIDs = append(diffIDs, "sha256:"+digest)
+		layerPaths = append(layerPaths, digest+"/layer.tar")
+	}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"created":      testImageCreated,
+		"config":       map[string]interface{}{},
+		"rootfs":       map[string]interface{}{"type": "layers", "diff_ids": diffIDs},
+	})
+	assert.NoError(t, err)
+	configPath := sha256Hex(config) + ".json"
+
+	manifest, err := json.Marshal([]map[string]interface{}{
+		{"Config": configPath, "RepoTags": []string{name}, "Layers": layerPaths},
+	})
+	assert.NoError(t, err)
+
+	files := map[string][]byte{configPath: config, "manifest.json": manifest}
+	for i, layer := range layers {
+		files[layerPaths[i]] = layer
+	}
+	return createTar(t, files)
+}
+
+func createTar(t *testing.T, files map[string][]byte) []byte {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buffer := new(bytes.Buffer)
+	writer := tar.NewWriter(buffer)
+	for _, name := range names {
+		err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), ModTime: time.Unix(0, 0)})
+		assert.NoError(t, err)
+		_, err = writer.Write(files[name])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,386 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reasons for removing an image from the cache during garbage collection
+const (
+	Unreferenced = "unreferenced"
+	Unused       = "unused"
+	CacheSize    = "cache size"
+)
+
+// Reasons for keeping an image in the cache which would otherwise be removed by the garbage collection
+const (
+	UnrecordedProfiles = "profiles without recorded images"
+	UnknownLastUse     = "last use unknown"
+)
+
+// GCOptions configures the garbage collection of the image cache.
+type GCOptions struct {
+	// Profiles are the names of the existing profiles. The references of all other profiles are dropped.
+	Profiles []string
+	// ProfileImages replaces the recorded references of the specified profiles before the garbage is collected. The
+	// references are only written if the garbage collection is not a dry run.
+	ProfileImages map[string][]string
+	// MaxSize is the maximum size of the cache in bytes. If the cache is larger once the unreferenced images are
+	// removed, the least recently used images are evicted until the cache fits. 0 disables the size based eviction.
+	MaxSize int64
+	// MaxUnused evicts all images which have not been imported or exported for longer than the specified duration.
+	// 0 disables the eviction of unused images.
+	MaxUnused time.Duration
+	// DryRun only determines what would be removed without changing the cache
+	DryRun bool
+	// Force removes unreferenced images even though some of the existing profiles have not recorded their image
+	// references yet, ie even though these images might still be needed
+	Force bool
+
+	now time.Time
+}
+
+// RemovedImage is an image removed from the cache by the garbage collection.
+type RemovedImage struct {
+	Name   string
+	Reason string
+	// Size is the size of the blobs which are only used by this image
+	Size int64
+}
+
+// KeptImage is an image kept in the cache by the garbage collection since it is not known whether it is still needed.
+type KeptImage struct {
+	Name   string
+	Reason string
+}
+
+// GCResult describes the outcome of a garbage collection.
+type GCResult struct {
+	RemovedImages []*RemovedImage
+	// KeptImages are the images which would have been removed, but are kept since their use is unknown
+	KeptImages []*KeptImage
+	// RemovedProfiles are the profiles whose references were dropped since they do not exist anymore
+	RemovedProfiles []string
+	// UnrecordedProfiles are existing profiles which have not recorded their image references yet. Unreferenced
+	// images are kept as long as there are such profiles, unless the removal is forced.
+	UnrecordedProfiles []string
+	// OrphanedBlobs is the number of removed blobs which did not belong to any image
+	OrphanedBlobs int
+	// ReclaimedBytes is the size of all removed blobs
+	ReclaimedBytes int64
+	// CacheSize is the size of the cache after the garbage collection
+	CacheSize int64
+}
+
+// ociManifest is the part of an OCI image manifest needed to determine the blobs of an image
+type ociManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+}
+
+// cachedImage is an image of the cache index together with the blobs it consists of
+type cachedImage struct {
+	manifest Manifest
+	blobs    []string
+	lastUsed time.Time
+}
+
+// CollectGarbage removes the images which are not referenced by any profile from the cache, evicts images according
+// to the specified options and removes all blobs which do not belong to any of the remaining images.
+func (handler *OciImageHandler) CollectGarbage(cacheDir string, options *GCOptions) (*GCResult, error) {
+	// exports and bundle loads of other processes claim their staging directory and update the index while holding the
+	// lock, so no export can start while the garbage is collected
+	unlock, err := lockImageCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if image, inProgress := exportInProgress(cacheDir); inProgress {
+		return nil, fmt.Errorf("Exporting %s is in progress. Run the garbage collection once the export has finished", image)
+	}
+
+	now := options.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	result := &GCResult{}
+	index, err := handler.getIndex(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		index = &Index{Manifests: Manifests{}, SchemaVersion: 2}
+	}
+
+	references, err := LoadImageReferences(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	for profile, images := range options.ProfileImages {
+		references.SetProfileImages(profile, images)
+	}
+	if options.Profiles != nil {
+		result.RemovedProfiles = references.RetainProfiles(options.Profiles)
+		for _, profile := range options.Profiles {
+			if _, recorded := references.Profiles[profile]; !recorded {
+				result.UnrecordedProfiles = append(result.UnrecordedProfiles, profile)
+			}
+		}
+	}
+
+	blobSizes, err := getBlobSizes(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []*cachedImage
+	for _, manifest := range index.Manifests {
+		images = append(images, &cachedImage{
+			manifest: manifest,
+			blobs:    getImageBlobs(cacheDir, manifest),
+			lastUsed: references.LastUsed[manifest.Annotations.Name],
+		})
+	}
+
+	// least recently used images first
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].lastUsed.Before(images[j].lastUsed)
+	})
+
+	// evictable are the kept images which can be evicted to fit the cache size, ie the images with a known last use
+	var kept, evictable []*cachedImage
+	remove := func(image *cachedImage, reason string) {
+		result.RemovedImages = append(result.RemovedImages, &RemovedImage{Name: image.manifest.Annotations.Name, Reason: reason})
+	}
+	keep := func(image *cachedImage, reason string) {
+		kept = append(kept, image)
+		if reason != "" {
+			result.KeptImages = append(result.KeptImages, &KeptImage{Name: image.manifest.Annotations.Name, Reason: reason})
+		}
+	}
+	unrecorded := len(result.UnrecordedProfiles) > 0 && !options.Force
+	for _, image := range images {
+		name := image.manifest.Annotations.Name
+		switch {
+		case len(references.ReferencingProfiles(name)) == 0 && unrecorded:
+			keep(image, UnrecordedProfiles)
+		case len(references.ReferencingProfiles(name)) == 0:
+			remove(image, Unreferenced)
+		case image.lastUsed.IsZero() && (options.MaxUnused > 0 || options.MaxSize > 0):
+			keep(image, UnknownLastUse)
+		case options.MaxUnused > 0 && now.Sub(image.lastUsed) > options.MaxUnused:
+			remove(image, Unused)
+		default:
+			keep(image, "")
+			evictable = append(evictable, image)
+		}
+	}
+
+	for options.MaxSize > 0 && len(evictable) > 0 && cacheSize(kept, blobSizes) > options.MaxSize {
+		remove(evictable[0], CacheSize)
+		kept = withoutImage(kept, evictable[0])
+		evictable = evictable[1:]
+	}
+
+	// the size of a removed image is the size of its blobs which are not shared with any remaining image
+	reachable := reachableBlobs(kept)
+	removedNames := make(map[string]bool)
+	for _, removed := range result.RemovedImages {
+		removedNames[removed.Name] = true
+		for _, image := range images {
+			if image.manifest.Annotations.Name != removed.Name {
+				continue
+			}
+			for _, blob := range image.blobs {
+				if !reachable[blob] {
+					removed.Size += blobSizes[blob]
+				}
+			}
+		}
+	}
+
+	imageBlobs := reachableBlobs(images)
+	var obsoleteBlobs []string
+	for blob, size := range blobSizes {
+		if reachable[blob] {
+			result.CacheSize += size
+			continue
+		}
+		obsoleteBlobs = append(obsoleteBlobs, blob)
+		result.ReclaimedBytes += size
+		if !imageBlobs[blob] {
+			result.OrphanedBlobs++
+		}
+	}
+
+	if options.DryRun {
+		return result, nil
+	}
+
+	var manifests Manifests
+	for _, manifest := range index.Manifests {
+		if !removedNames[manifest.Annotations.Name] {
+			manifests = append(manifests, manifest)
+		}
+	}
+	if len(result.RemovedImages) > 0 {
+		index.Manifests = manifests
+		if index.Manifests == nil {
+			index.Manifests = Manifests{}
+		}
+		if err := handler.updateIndex(cacheDir, index); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, blob := range obsoleteBlobs {
+		if err := os.Remove(blobPath(cacheDir, blob)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	for name := range removedNames {
+		delete(references.LastUsed, name)
+	}
+	if err := references.Write(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// exportInProgress returns the staging directory of an export which is currently running, if any
+func exportInProgress(cacheDir string) (string, bool) {
+	entries, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		raw, err := ioutil.ReadFile(filepath.Join(cacheDir, entry.Name(), exportPidFile))
+		if err != nil {
+			continue
+		}
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil && isProcessRunning(pid) {
+			return entry.Name(), true
+		}
+	}
+	return "", false
+}
+
+// getBlobSizes returns the sizes of all blobs of the cache keyed by digest
+func getBlobSizes(cacheDir string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	blobDir := filepath.Join(cacheDir, "blobs")
+	algorithms, err := ioutil.ReadDir(blobDir)
+	if os.IsNotExist(err) {
+		return sizes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, algorithm := range algorithms {
+		if !algorithm.IsDir() {
+			continue
+		}
+		blobs, err := ioutil.ReadDir(filepath.Join(blobDir, algorithm.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range blobs {
+			if !blob.IsDir() {
+				sizes[fmt.Sprintf("%s:%s", algorithm.Name(), blob.Name())] = blob.Size()
+			}
+		}
+	}
+	return sizes, nil
+}
+
+// getImageBlobs returns the digests of the manifest, config and layer blobs of the specified image
+func getImageBlobs(cacheDir string, manifest Manifest) []string {
+	blobs := []string{manifest.Digest}
+	raw, err := ioutil.ReadFile(blobPath(cacheDir, manifest.Digest))
+	if err != nil {
+		return blobs
+	}
+
+	var imageManifest ociManifest
+	if err := json.Unmarshal(raw, &imageManifest); err != nil {
+		return blobs
+	}
+	if imageManifest.Config.Digest != "" {
+		blobs = append(blobs, imageManifest.Config.Digest)
+	}
+	for _, layer := range imageManifest.Layers {
+		blobs = append(blobs, layer.Digest)
+	}
+	return blobs
+}
+
+func withoutImage(images []*cachedImage, image *cachedImage) []*cachedImage {
+	var remaining []*cachedImage
+	for _, candidate := range images {
+		if candidate != image {
+			remaining = append(remaining, candidate)
+		}
+	}
+	return remaining
+}
+
+func reachableBlobs(images []*cachedImage) map[string]bool {
+	reachable := make(map[string]bool)
+	for _, image := range images {
+		for _, blob := range image.blobs {
+			reachable[blob] = true
+		}
+	}
+	return reachable
+}
+
+func cacheSize(images []*cachedImage, blobSizes map[string]int64) int64 {
+	var size int64
+	for blob := range reachableBlobs(images) {
+		size += blobSizes[blob]
+	}
+	return size
+}
+
+func blobPath(cacheDir string, digest string) string {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return filepath.Join(cacheDir, "blobs", digest)
+	}
+	return filepath.Join(cacheDir, "blobs", parts[0], parts[1])
+}
@@ -0,0 +1,385 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_gc_removes_unreferenced_images_and_orphaned_blobs(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, testImages...)
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	assert.NoError(t, RecordProfileImages(cacheDir, "other", []string{"acme/bar:v1", "acme/foo:v1"}))
+
+	orphan := filepath.Join(cacheDir, "blobs", "sha256", "0123456789abcdef")
+	assert.NoError(t, ioutil.WriteFile(orphan, []byte("orphan"), 0644))
+	blobsBefore, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(cacheDir, &GCOptions{Profiles: []string{"minishift", "other"}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"acme/baz:v1", "acme/qux:v1"}, removedImageNames(result))
+	for _, removed := range result.RemovedImages {
+		assert.Equal(t, Unreferenced, removed.Reason)
+		assert.True(t, removed.Size > 0)
+	}
+	assert.Equal(t, 1, result.OrphanedBlobs)
+
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"acme/foo:v1", "acme/bar:v1"}}
+	assert.True(t, handler.AreImagesCached(config))
+	assert.False(t, handler.IsImageCached(config, "acme/baz:v1"))
+	_, err = os.Stat(orphan)
+	assert.True(t, os.IsNotExist(err))
+
+	// the removed images had each their own layer, config and manifest, the base layer is still used
+	blobsAfter, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, blobsAfter, len(blobsBefore)-7)
+	assert.Equal(t, sumSizes(blobsBefore)-sumSizes(blobsAfter), result.ReclaimedBytes)
+	assert.Equal(t, sumSizes(blobsAfter), result.CacheSize)
+}
+
+func Test_gc_dry_run_does_not_change_cache(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, testImages...)
+	blobsBefore, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(cacheDir, &GCOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Len(t, result.RemovedImages, 4)
+	assert.Equal(t, sumSizes(blobsBefore), result.ReclaimedBytes)
+	assert.Equal(t, int64(0), result.CacheSize)
+
+	blobsAfter, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, blobsBefore, blobsAfter)
+	assert.True(t, handler.AreImagesCached(&ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: testImages}))
+}
+
+func Test_gc_uses_profile_images_and_only_records_them_without_dry_run(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	referencesFile := filepath.Join(cacheDir, ReferencesFileName)
+	before, err := ioutil.ReadFile(referencesFile)
+	assert.NoError(t, err)
+
+	handler := &OciImageHandler{}
+	options := &GCOptions{
+		Profiles:      []string{"minishift"},
+		ProfileImages: map[string][]string{"minishift": {"acme/bar:v1"}},
+		DryRun:        true,
+	}
+	result, err := handler.CollectGarbage(cacheDir, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/foo:v1"}, removedImageNames(result))
+	after, err := ioutil.ReadFile(referencesFile)
+	assert.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+
+	options.DryRun = false
+	result, err = handler.CollectGarbage(cacheDir, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/foo:v1"}, removedImageNames(result))
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"minishift": {"acme/bar:v1"}}, references.Profiles)
+}
+
+func Test_gc_drops_references_of_deleted_profiles(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	assert.NoError(t, RecordProfileImages(cacheDir, "deleted", []string{"acme/bar:v1"}))
+
+	result, err := (&OciImageHandler{}).CollectGarbage(cacheDir, &GCOptions{Profiles: []string{"minishift", "new"}, Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/bar:v1"}, removedImageNames(result))
+	assert.Equal(t, []string{"deleted"}, result.RemovedProfiles)
+	assert.Equal(t, []string{"new"}, result.UnrecordedProfiles)
+
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"minishift": {"acme/foo:v1"}}, references.Profiles)
+	_, found := references.LastUsed["acme/bar:v1"]
+	assert.False(t, found)
+}
+
+func Test_gc_keeps_unreferenced_images_while_profiles_are_unrecorded(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	blobsBefore, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(cacheDir, &GCOptions{Profiles: []string{"minishift", "other"}})
+	assert.NoError(t, err)
+	assert.Empty(t, result.RemovedImages)
+	assert.Equal(t, []*KeptImage{{Name: "acme/bar:v1", Reason: UnrecordedProfiles}}, result.KeptImages)
+	assert.Equal(t, []string{"other"}, result.UnrecordedProfiles)
+	assert.Equal(t, int64(0), result.ReclaimedBytes)
+
+	blobsAfter, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, blobsBefore, blobsAfter)
+	assert.True(t, handler.AreImagesCached(&ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"acme/foo:v1", "acme/bar:v1"}}))
+
+	result, err = handler.CollectGarbage(cacheDir, &GCOptions{Profiles: []string{"minishift", "other"}, Force: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/bar:v1"}, removedImageNames(result))
+	assert.Empty(t, result.KeptImages)
+}
+
+func Test_gc_evicts_least_recently_used_images_to_fit_max_size(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, testImages...)
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", testImages))
+	setLastUsed(t, cacheDir, map[string]time.Time{
+		"acme/foo:v1": time.Unix(4000, 0),
+		"acme/bar:v1": time.Unix(1000, 0),
+		"acme/baz:v1": time.Unix(3000, 0),
+		"acme/qux:v1": time.Unix(2000, 0),
+	})
+
+	handler := &OciImageHandler{}
+	dryRun, err := handler.CollectGarbage(cacheDir, &GCOptions{DryRun: true, MaxSize: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/bar:v1", "acme/qux:v1", "acme/baz:v1", "acme/foo:v1"}, removedImageNames(dryRun))
+
+	// the cache should only fit the two most recently used images
+	maxSize := imagesSize(t, cacheDir, "acme/foo:v1", "acme/baz:v1")
+	result, err := handler.CollectGarbage(cacheDir, &GCOptions{MaxSize: maxSize})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/bar:v1", "acme/qux:v1"}, removedImageNames(result))
+	for _, removed := range result.RemovedImages {
+		assert.Equal(t, CacheSize, removed.Reason)
+	}
+	assert.Equal(t, maxSize, result.CacheSize)
+	assert.True(t, handler.AreImagesCached(&ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"acme/foo:v1", "acme/baz:v1"}}))
+}
+
+func Test_gc_evicts_images_unused_for_max_unused(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1", "acme/bar:v1"}))
+	now := time.Now()
+	setLastUsed(t, cacheDir, map[string]time.Time{"acme/foo:v1": now.Add(-time.Hour), "acme/bar:v1": now.Add(-48 * time.Hour)})
+
+	result, err := (&OciImageHandler{}).CollectGarbage(cacheDir, &GCOptions{MaxUnused: 24 * time.Hour, now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/bar:v1"}, removedImageNames(result))
+	assert.Equal(t, Unused, result.RemovedImages[0].Reason)
+}
+
+func Test_gc_keeps_images_without_recorded_use(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1", "acme/bar:v1")
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1", "acme/bar:v1"}))
+	now := time.Now()
+	setLastUsed(t, cacheDir, map[string]time.Time{"acme/foo:v1": now.Add(-48 * time.Hour)})
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	delete(references.LastUsed, "acme/bar:v1")
+	assert.NoError(t, references.Write())
+
+	handler := &OciImageHandler{}
+	result, err := handler.CollectGarbage(cacheDir, &GCOptions{MaxUnused: 24 * time.Hour, now: now})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/foo:v1"}, removedImageNames(result))
+	assert.Equal(t, []*KeptImage{{Name: "acme/bar:v1", Reason: UnknownLastUse}}, result.KeptImages)
+	assert.True(t, handler.IsImageCached(&ImageCacheConfig{HostCacheDir: cacheDir}, "acme/bar:v1"))
+
+	result, err = handler.CollectGarbage(cacheDir, &GCOptions{MaxSize: 1, now: now})
+	assert.NoError(t, err)
+	assert.Empty(t, result.RemovedImages)
+	assert.Equal(t, []*KeptImage{{Name: "acme/bar:v1", Reason: UnknownLastUse}}, result.KeptImages)
+}
+
+func Test_gc_fails_while_export_is_in_progress(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	stagingDir := filepath.Join(cacheDir, "acme-foo-v1")
+	assert.NoError(t, os.MkdirAll(stagingDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(stagingDir, exportPidFile), []byte(strconv.Itoa(os.Getpid())), 0644))
+
+	_, err := (&OciImageHandler{}).CollectGarbage(cacheDir, &GCOptions{})
+	assert.EqualError(t, err, "Exporting acme-foo-v1 is in progress. Run the garbage collection once the export has finished")
+}
+
+func Test_gc_sees_exports_claimed_while_waiting_for_the_lock(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	unlock, err := lockImageCache(cacheDir)
+	assert.NoError(t, err)
+	gcErr := make(chan error, 1)
+	go func() {
+		_, err := (&OciImageHandler{}).CollectGarbage(cacheDir, &GCOptions{})
+		gcErr <- err
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, claimStagingDir(filepath.Join(cacheDir, "acme-foo-v1"), "acme/foo:v1"))
+	unlock()
+
+	assert.EqualError(t, <-gcErr, "Exporting acme-foo-v1 is in progress. Run the garbage collection once the export has finished")
+}
+
+func Test_gc_fails_while_bundle_is_loaded(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	stagingDir, err := claimBundleStagingDir(cacheDir)
+	assert.NoError(t, err)
+	_, err = (&OciImageHandler{}).CollectGarbage(cacheDir, &GCOptions{})
+	assert.EqualError(t, err, fmt.Sprintf("Exporting %s is in progress. Run the garbage collection once the export has finished", filepath.Base(stagingDir)))
+}
+
+func Test_exported_images_are_recorded_as_used(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	before := time.Now().Add(-time.Second)
+	exportTestImages(t, cacheDir, "acme/foo:v1")
+
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.True(t, references.LastUsed["acme/foo:v1"].After(before))
+}
+
+func Test_profile_images_are_recorded_without_duplicates(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"b", "a", "b"}))
+	assert.NoError(t, RecordProfileImages(cacheDir, "other", []string{"a"}))
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, references.Profiles["minishift"])
+	assert.Equal(t, []string{"minishift", "other"}, references.ReferencingProfiles("a"))
+	assert.Equal(t, []string{"minishift"}, references.ReferencingProfiles("b"))
+
+	assert.NoError(t, RemoveProfileImages(cacheDir, "minishift"))
+	references, err = LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Empty(t, references.ReferencingProfiles("b"))
+}
+
+func Test_concurrent_reference_updates_are_not_lost(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(profile string) {
+			defer wg.Done()
+			assert.NoError(t, RecordProfileImages(cacheDir, profile, []string{"acme/foo:v1"}))
+			assert.NoError(t, touchImages(cacheDir, []string{profile}))
+		}(fmt.Sprintf("profile-%d", i))
+	}
+	wg.Wait()
+
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, references.Profiles, 10)
+	assert.Len(t, references.LastUsed, 10)
+	_, err = os.Stat(filepath.Join(cacheDir, cacheLockFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_stale_cache_lock_is_broken(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	lockFile := filepath.Join(cacheDir, cacheLockFileName)
+	assert.NoError(t, ioutil.WriteFile(lockFile, []byte("0"), 0644))
+	oldTime := time.Now().Add(-2 * cacheLockStaleAfter)
+	assert.NoError(t, os.Chtimes(lockFile, oldTime, oldTime))
+
+	assert.NoError(t, RecordProfileImages(cacheDir, "minishift", []string{"acme/foo:v1"}))
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/foo:v1"}, references.Profiles["minishift"])
+}
+
+func removedImageNames(result *GCResult) []string {
+	var names []string
+	for _, removed := range result.RemovedImages {
+		names = append(names, removed.Name)
+	}
+	return names
+}
+
+func setLastUsed(t *testing.T, cacheDir string, lastUsed map[string]time.Time) {
+	references, err := LoadImageReferences(cacheDir)
+	assert.NoError(t, err)
+	for image, time := range lastUsed {
+		references.Touch(time, image)
+	}
+	assert.NoError(t, references.Write())
+}
+
+// imagesSize returns the size of the blobs used by the specified cached images
+func imagesSize(t *testing.T, cacheDir string, names ...string) int64 {
+	index, err := (&OciImageHandler{}).getIndex(cacheDir)
+	assert.NoError(t, err)
+	blobSizes, err := getBlobSizes(cacheDir)
+	assert.NoError(t, err)
+
+	var images []*cachedImage
+	for _, manifest := range index.Manifests {
+		for _, name := range names {
+			if manifest.Annotations.Name == name {
+				images = append(images, &cachedImage{manifest: manifest, blobs: getImageBlobs(cacheDir, manifest)})
+			}
+		}
+	}
+	assert.Len(t, images, len(names))
+	return cacheSize(images, blobSizes)
+}
+
+func sumSizes(sizes map[string]int64) int64 {
+	var sum int64
+	for _, size := range sizes {
+		sum += size
+	}
+	return sum
+}
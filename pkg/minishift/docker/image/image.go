@@ -36,6 +36,8 @@ type ImageCacheConfig struct {
 	CachedImages      []string
 	Out               io.Writer
 	ImageMissStrategy ImageMissStrategy
+	// Concurrency is the number of images imported or exported in parallel, DefaultConcurrency if not set
+	Concurrency int
 }
 
 // GetOpenShiftImageNames returns the full images names for the images requires for a fully functioning OpenShift instance
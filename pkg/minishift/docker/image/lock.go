@@ -0,0 +1,95 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// cacheLockFileName is the file in the image cache directory which serializes the updates of the index and the
+	// references of the cache between Minishift processes. It contains the id of the process holding the lock.
+	cacheLockFileName = "cache.lock"
+	// cacheLockTimeout is how long to wait for the lock held by another process
+	cacheLockTimeout = 30 * time.Second
+	// cacheLockStaleAfter is the age after which a lock is considered left behind by a crashed process, even though
+	// the process holding it cannot be checked, eg on Windows
+	cacheLockStaleAfter = time.Minute
+)
+
+// lockImageCache acquires the lock of the specified cache directory, waiting for other processes holding it. The index
+// and the references need to be loaded once the lock is acquired. The returned function releases the lock.
+func lockImageCache(cacheDir string) (func(), error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, err
+	}
+
+	lockFile := filepath.Join(cacheDir, cacheLockFileName)
+	deadline := time.Now().Add(cacheLockTimeout)
+	for {
+		file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, err = file.WriteString(strconv.Itoa(os.Getpid()))
+			file.Close()
+			if err != nil {
+				os.Remove(lockFile)
+				return nil, err
+			}
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if isStaleLock(lockFile) {
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out waiting for the lock '%s' of the image cache. Remove it if no other Minishift process is running", lockFile)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// isStaleLock returns true if the specified lock file has been left behind by a process which is not running anymore
+func isStaleLock(lockFile string) bool {
+	info, err := os.Stat(lockFile)
+	if err != nil {
+		return false
+	}
+	if time.Since(info.ModTime()) > cacheLockStaleAfter {
+		return true
+	}
+
+	raw, err := ioutil.ReadFile(lockFile)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		// the lock file has just been created and the id is not written yet
+		return false
+	}
+	return pid != os.Getpid() && !isProcessRunning(pid)
+}
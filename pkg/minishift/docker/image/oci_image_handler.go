@@ -32,6 +32,7 @@ import (
 	"github.com/containers/image/transports/alltransports"
 	"github.com/containers/image/types"
 	"github.com/docker/machine/libmachine/drivers"
+	"github.com/golang/glog"
 	"github.com/minishift/minishift/pkg/minikube/sshutil"
 	"github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/filehelper"
@@ -39,15 +40,24 @@ import (
 	"golang.org/x/crypto/ssh"
 	"io/ioutil"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 )
 
 // OciImageHandler is an ImageHandler implementation using OCI format to maintain the local cache.
 type OciImageHandler struct {
 	driver               drivers.Driver
 	dockerClientSettings *dockerClientConfig
+	// runInVM runs the specified command in the VM and returns its standard output
+	runInVM   func(cmd string) (string, error)
+	indexLock sync.Mutex
 }
 
+// exportPidFile is the file in the staging directory of an image export containing the id of the exporting process
+const exportPidFile = "export.pid"
+
 type dockerClientConfig struct {
 	DockerHost      string
 	DockerCertPath  string
@@ -87,7 +97,9 @@ func NewOciImageHandler(driver drivers.Driver, dockerEnv map[string]string) (*Oc
 	if err != nil {
 		return nil, err
 	}
-	return &OciImageHandler{driver: driver, dockerClientSettings: settings}, nil
+	handler := &OciImageHandler{driver: driver, dockerClientSettings: settings}
+	handler.runInVM = handler.runSSHCommand
+	return handler, nil
 }
 
 // NewLocalOnlyOciImageHandler creates  a new ImageHandler which can only interact with the local cache.
@@ -96,76 +108,93 @@ func NewLocalOnlyOciImageHandler() (*OciImageHandler, error) {
 	return &OciImageHandler{driver: nil, dockerClientSettings: nil}, nil
 }
 
-// ImportImages imports cached images from the host into the Docker daemon of the VM.
+// ImportImages imports cached images from the host into the Docker daemon of the VM. Up to config.Concurrency images
+// are imported in parallel.
 func (handler *OciImageHandler) ImportImages(config *ImageCacheConfig) ([]string, error) {
 	out := handler.getOutputWriter(config)
 	importedImages := []string{}
 
-	policyContext, err := handler.getPolicyContext()
-	if err != nil {
-		return importedImages, fmt.Errorf("Error creating security context: %s", err.Error())
-	}
-
 	availableImages, err := handler.GetDockerImages()
 	if err != nil {
 		return importedImages, err
 	}
+	cachedImages := handler.GetCachedImages(config)
 
-	multiError := util.MultiError{}
-	for _, imageName := range config.CachedImages {
-		fmt.Fprint(out, fmt.Sprintf("   Importing '%s' ", imageName))
-		progressDots := progressdots.New()
-		progressDots.SetWriter(out)
-		progressDots.Start()
+	workers := concurrency(config)
+	reporter := newProgressReporter(out, workers)
+	imported := make([]bool, len(config.CachedImages))
+	errs := make([]error, len(config.CachedImages))
+	forEachImage(config.CachedImages, workers, func(index int, imageName string) {
+		progress := reporter.start(fmt.Sprintf("   Importing '%s' ", imageName))
 		if _, found := availableImages[imageName]; found {
-			handler.endProgress(progressDots, out, OK)
-			importedImages = append(importedImages, imageName)
-			continue
-
+			progress.end(OK)
+			imported[index] = true
+			return
 		}
 
-		if !handler.IsImageCached(config, imageName) {
-			handler.endProgress(progressDots, out, CACHE_MISS)
-			continue
+		if _, found := cachedImages[imageName]; !found {
+			progress.end(CACHE_MISS)
+			return
 		}
 
-		err := handler.importImage(imageName, config, policyContext, out)
-		handler.endProgress(progressDots, out, handler.progressStatusForError(err))
-		multiError.Collect(err)
-		if err == nil {
+		errs[index] = handler.importImage(imageName, config, out)
+		progress.end(handler.progressStatusForError(errs[index]))
+		imported[index] = errs[index] == nil
+	})
+
+	multiError := util.MultiError{}
+	for index, imageName := range config.CachedImages {
+		multiError.Collect(errs[index])
+		if imported[index] {
 			importedImages = append(importedImages, imageName)
 		}
 	}
+	if err := touchImages(config.HostCacheDir, importedImages); err != nil {
+		glog.Warningf("Unable to record the use of the imported images: %v", err)
+	}
 	return importedImages, multiError.ToError()
 }
 
-// ExportImages exports the images specified as part of the ImageCacheConfig from the VM to the host.
+// ExportImages exports the images specified as part of the ImageCacheConfig from the VM to the host. Up to
+// config.Concurrency images are exported in parallel. Layers which are already part of the cache are not copied again,
+// so an interrupted export can be resumed by exporting the images again.
 func (handler *OciImageHandler) ExportImages(config *ImageCacheConfig, overwrite bool) ([]string, error) {
 	out := handler.getOutputWriter(config)
 	exportedImages := []string{}
 
-	policyContext, err := handler.getPolicyContext()
-	if err != nil {
-		return exportedImages, fmt.Errorf("Error creating security context: %s", err.Error())
-	}
-
-	multiError := util.MultiError{}
+	cachedImages := handler.GetCachedImages(config)
+	var availableImages map[string]bool
+	var err error
 	for _, imageName := range config.CachedImages {
-		fmt.Fprint(out, fmt.Sprintf("Exporting '%s'", imageName))
-		err = nil
-		progressDots := progressdots.New()
-		progressDots.SetWriter(out)
-		progressDots.Start()
+		if _, found := cachedImages[imageName]; !found || overwrite {
+			if availableImages, err = handler.GetDockerImages(); err != nil {
+				return exportedImages, err
+			}
+			break
+		}
+	}
 
-		if !handler.IsImageCached(config, imageName) || overwrite {
-			err = handler.exportImage(imageName, config, policyContext, out, overwrite)
+	workers := concurrency(config)
+	reporter := newProgressReporter(out, workers)
+	errs := make([]error, len(config.CachedImages))
+	forEachImage(config.CachedImages, workers, func(index int, imageName string) {
+		progress := reporter.start(fmt.Sprintf("Exporting '%s'", imageName))
+		if _, found := cachedImages[imageName]; !found || overwrite {
+			errs[index] = handler.exportImage(imageName, config, availableImages, overwrite)
 		}
-		handler.endProgress(progressDots, out, handler.progressStatusForError(err))
-		multiError.Collect(err)
-		if err != nil {
+		progress.end(handler.progressStatusForError(errs[index]))
+	})
+
+	multiError := util.MultiError{}
+	for index, imageName := range config.CachedImages {
+		multiError.Collect(errs[index])
+		if errs[index] == nil {
 			exportedImages = append(exportedImages, imageName)
 		}
 	}
+	if err := touchImages(config.HostCacheDir, exportedImages); err != nil {
+		glog.Warningf("Unable to record the use of the exported images: %v", err)
+	}
 
 	return exportedImages, multiError.ToError()
 }
@@ -234,25 +263,17 @@ func (handler *OciImageHandler) GetCachedImages(config *ImageCacheConfig) map[st
 func (handler *OciImageHandler) GetDockerImages() (map[string]bool, error) {
 	dockerImages := make(map[string]bool)
 
-	session, err := handler.createSSHSession()
-	if err != nil {
-		return nil, err
-	}
-	defer session.Close()
-
 	// We don't want to have an image which have <none> tag in our output list
 	// Also in case there is no image in docker daemon then exit code should be 0 instead 1
 	// and `|| true` is used.
 	// which refer to dangling image for docker.
 	cmd := "docker images --format '{{.Repository}}:{{.Tag}}' | grep -vw '<none>' || true"
-	var buffer bytes.Buffer
-	session.Stdout = &buffer
-	err = session.Run(cmd)
+	output, err := handler.runInVM(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("Error running command '%s': %v", cmd, err)
+		return nil, err
 	}
 
-	for _, image := range strings.Split(buffer.String(), "\n") {
+	for _, image := range strings.Split(output, "\n") {
 		if len(image) > 0 {
 			dockerImages[image] = true
 		}
@@ -262,19 +283,28 @@ func (handler *OciImageHandler) GetDockerImages() (map[string]bool, error) {
 }
 
 func (handler *OciImageHandler) pullImage(image string, out io.Writer) error {
+	cmd := fmt.Sprintf("docker pull %s", image)
+	_, err := handler.runInVM(cmd)
+	return err
+}
+
+// runSSHCommand runs the specified command in the VM via SSH
+func (handler *OciImageHandler) runSSHCommand(cmd string) (string, error) {
 	session, err := handler.createSSHSession()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer session.Close()
 
-	cmd := fmt.Sprintf("docker pull %s", image)
-	cmdOut, err := session.CombinedOutput(cmd)
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	err = session.Run(cmd)
 	if err != nil {
-		return fmt.Errorf("Error running command '%s': %v \n%s", cmd, err, string(cmdOut[:]))
+		return "", fmt.Errorf("Error running command '%s': %v \n%s", cmd, err, stderr.String())
 	}
 
-	return nil
+	return stdout.String(), nil
 }
 
 func (handler *OciImageHandler) getIndex(cacheDir string) (*Index, error) {
@@ -297,7 +327,7 @@ func (handler *OciImageHandler) getIndex(cacheDir string) (*Index, error) {
 	return &index, nil
 }
 
-func (handler *OciImageHandler) importImage(image string, config *ImageCacheConfig, policyContext *signature.PolicyContext, out io.Writer) error {
+func (handler *OciImageHandler) importImage(image string, config *ImageCacheConfig, out io.Writer) error {
 	srcRef, err := layout.NewReference(config.HostCacheDir, image)
 	if err != nil {
 		return fmt.Errorf("Invalid image source '%v': %v", srcRef, err)
@@ -308,7 +338,7 @@ func (handler *OciImageHandler) importImage(image string, config *ImageCacheConf
 		return fmt.Errorf("Invalid image source '%s': %v", image, err)
 	}
 
-	err = handler.copyImage(srcRef, destRef, policyContext, config.HostCacheDir)
+	err = handler.copyImage(srcRef, destRef, config.HostCacheDir)
 	if err != nil {
 		return err
 	}
@@ -316,12 +346,7 @@ func (handler *OciImageHandler) importImage(image string, config *ImageCacheConf
 	return nil
 }
 
-func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConfig, policyContext *signature.PolicyContext, out io.Writer, overwrite bool) error {
-	availableImages, err := handler.GetDockerImages()
-	if err != nil {
-		return err
-	}
-
+func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConfig, availableImages map[string]bool, overwrite bool) error {
 	if _, found := availableImages[image]; !found || overwrite {
 		err := handler.pullImage(image, config.Out)
 		if err != nil {
@@ -340,16 +365,27 @@ func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConf
 	r := strings.NewReplacer(":", "-", "/", "-")
 	ImageIndexLocation := filepath.Join(config.HostCacheDir, r.Replace(image))
 
-	if _, err := os.Stat(ImageIndexLocation); !os.IsNotExist(err) {
-		return fmt.Errorf("Importing %s is already in progress", image)
+	// The staging directory is claimed under the lock of the cache, so that the garbage collection of another process
+	// either finishes before or sees the export in progress and does not remove the blobs being copied
+	unlock, err := lockImageCache(config.HostCacheDir)
+	if err != nil {
+		return err
 	}
+	err = claimStagingDir(ImageIndexLocation, image)
+	unlock()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(ImageIndexLocation)
 
 	destRef, err := layout.NewReference(ImageIndexLocation, image)
 	if err != nil {
 		return fmt.Errorf("Invalid image destination '%v': %v", destRef, err)
 	}
 
-	err = handler.copyImage(srcRef, destRef, policyContext, config.HostCacheDir)
+	// The layers are stored in the shared blob directory of the cache. Layers which are already present, eg from
+	// another image or an interrupted export of the same image, are not copied again.
+	err = handler.copyImage(srcRef, destRef, config.HostCacheDir)
 	if err != nil {
 		return err
 	}
@@ -360,10 +396,14 @@ func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConf
 		return err
 	}
 
-	// Remove tmpImageIndexLocation
-	if err := os.RemoveAll(ImageIndexLocation); err != nil {
+	// The index of the cache is shared by all images exported in parallel, also by other processes
+	handler.indexLock.Lock()
+	defer handler.indexLock.Unlock()
+	unlock, err = lockImageCache(config.HostCacheDir)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
 	// Get index of already available image
 	availableImageIndex, err := handler.getIndex(config.HostCacheDir)
@@ -375,7 +415,14 @@ func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConf
 		availableImageIndex = &Index{Manifests: Manifests{}, SchemaVersion: 2}
 	}
 
-	availableImageIndex.Manifests = append(availableImageIndex.Manifests, pulledImageIndex.Manifests...)
+	// Replace the manifest of a previous export of the image, eg when exporting with overwrite
+	manifests := Manifests{}
+	for _, manifest := range availableImageIndex.Manifests {
+		if manifest.Annotations.Name != image {
+			manifests = append(manifests, manifest)
+		}
+	}
+	availableImageIndex.Manifests = append(manifests, pulledImageIndex.Manifests...)
 
 	if err := handler.updateIndex(config.HostCacheDir, availableImageIndex); err != nil {
 		return err
@@ -384,6 +431,43 @@ func (handler *OciImageHandler) exportImage(image string, config *ImageCacheConf
 	return nil
 }
 
+// claimStagingDir creates the staging directory for the export of the specified image and records the id of the
+// current process in it. A staging directory left behind by an interrupted export is removed, an error is returned if
+// the image is currently exported by another process.
+func claimStagingDir(stagingDir string, image string) error {
+	pidFile := filepath.Join(stagingDir, exportPidFile)
+	if filehelper.Exists(stagingDir) {
+		if raw, err := ioutil.ReadFile(pidFile); err == nil {
+			if pid, err := strconv.Atoi(strings.TrimSpace(string(raw))); err == nil && pid != os.Getpid() && isProcessRunning(pid) {
+				return fmt.Errorf("Exporting %s is already in progress", image)
+			}
+		}
+		if err := os.RemoveAll(stagingDir); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0644)
+}
+
+func isProcessRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// for Windows FindProcess is enough
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	// for non Windows we need to send a signal to get more information
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
 func (handler *OciImageHandler) pruneImage(image string, config *ImageCacheConfig) error {
 	index, err := handler.getIndex(config.HostCacheDir)
 	if index == nil || err != nil {
@@ -404,9 +488,17 @@ func (handler *OciImageHandler) pruneImage(image string, config *ImageCacheConfi
 	return nil
 }
 
-func (handler *OciImageHandler) copyImage(srcRef types.ImageReference, destRef types.ImageReference, policyContext *signature.PolicyContext, cacheDir string) error {
+// copyImage copies the image from srcRef to destRef. Each copy uses its own policy context, since a policy context must
+// not be used by concurrent copies.
+func (handler *OciImageHandler) copyImage(srcRef types.ImageReference, destRef types.ImageReference, cacheDir string) error {
+	policyContext, err := handler.getPolicyContext()
+	if err != nil {
+		return err
+	}
+	defer policyContext.Destroy()
+
 	ctx := context.TODO()
-	err := copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
+	err = copy.Image(ctx, policyContext, destRef, srcRef, &copy.Options{
 		RemoveSignatures: false,
 		SignBy:           "",
 		ReportWriter:     nil,
@@ -17,9 +17,15 @@ limitations under the License.
 package image
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -76,3 +82,201 @@ func Test_Get_Docker_Settings(t *testing.T) {
 		assert.EqualValues(t, envTest.dockerSettings, clientConfig)
 	}
 }
+
+var testImages = []string{"acme/foo:v1", "acme/bar:v1", "acme/baz:v1", "acme/qux:v1"}
+
+func Test_images_are_exported_in_parallel(t *testing.T) {
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	daemon.delay = 100 * time.Millisecond
+	for _, image := range testImages {
+		daemon.registry[image] = createImageArchive(t, image, "base", image)
+	}
+
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	out := new(bytes.Buffer)
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: testImages, Out: out, Concurrency: 2}
+	exported, err := daemon.handler().ExportImages(config, false)
+	assert.NoError(t, err)
+	assert.Equal(t, testImages, exported)
+	assert.Equal(t, 2, daemon.maxInFlight)
+	assert.Equal(t, 4, daemon.saves)
+
+	handler := OciImageHandler{}
+	assert.True(t, handler.AreImagesCached(config))
+	index, err := handler.getIndex(cacheDir)
+	assert.NoError(t, err)
+	assert.Len(t, index.Manifests, 4)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	for _, line := range lines {
+		assert.Regexp(t, `^Exporting 'acme/[a-z]+:v1' OK$`, line)
+	}
+}
+
+func Test_images_are_imported_in_parallel(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, testImages...)
+
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	daemon.delay = 100 * time.Millisecond
+
+	out := new(bytes.Buffer)
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: append(testImages, "acme/missing:v1"), Out: out, Concurrency: 3}
+	imported, err := daemon.handler().ImportImages(config)
+	assert.NoError(t, err)
+	assert.Equal(t, testImages, imported)
+	assert.Equal(t, []string{"acme/bar:v1", "acme/baz:v1", "acme/foo:v1", "acme/qux:v1"}, daemon.imageNames())
+	assert.Equal(t, 3, daemon.maxInFlight)
+	assert.Contains(t, out.String(), "   Importing 'acme/missing:v1' CACHE MISS\n")
+}
+
+func Test_images_are_processed_sequentially_with_concurrency_one(t *testing.T) {
+	images := testImages[:2]
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, images...)
+
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	daemon.images["acme/foo:v1"] = createImageArchive(t, "acme/foo:v1", "base")
+
+	out := new(bytes.Buffer)
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: images, Out: out, Concurrency: 1}
+	imported, err := daemon.handler().ImportImages(config)
+	assert.NoError(t, err)
+	assert.Equal(t, images, imported)
+	assert.Equal(t, 1, daemon.loads)
+
+	// progress dots might be shown while an image is imported
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	for i, line := range lines {
+		assert.Regexp(t, "^   Importing '"+images[i]+"' \\.* OK$", line)
+	}
+}
+
+func Test_export_does_not_copy_cached_layers_again(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1")
+
+	baseLayer := filepath.Join(cacheDir, "blobs", "sha256", sha256Hex(createTar(t, map[string][]byte{"layer-0.txt": []byte("base")})))
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	assert.NoError(t, os.Chtimes(baseLayer, past, past))
+
+	exportTestImages(t, cacheDir, "acme/bar:v1")
+
+	info, err := os.Stat(baseLayer)
+	assert.NoError(t, err)
+	assert.Equal(t, past, info.ModTime(), "The cached base layer should not have been written again")
+}
+
+func Test_interrupted_export_can_be_resumed(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	// staging directory of an export which got interrupted, the exporting process does not exist anymore
+	stagingDir := filepath.Join(cacheDir, "acme-foo-v1")
+	assert.NoError(t, os.MkdirAll(stagingDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(stagingDir, exportPidFile), []byte("999999999"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(stagingDir, "oci-put-blob123"), []byte("partial"), 0644))
+
+	exportTestImages(t, cacheDir, "acme/foo:v1")
+
+	_, err := os.Stat(stagingDir)
+	assert.True(t, os.IsNotExist(err), "The staging directory should have been removed")
+	assert.True(t, (&OciImageHandler{}).IsImageCached(&ImageCacheConfig{HostCacheDir: cacheDir}, "acme/foo:v1"))
+}
+
+func Test_export_fails_if_image_is_exported_by_another_process(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	stagingDir := filepath.Join(cacheDir, "acme-foo-v1")
+	assert.NoError(t, os.MkdirAll(stagingDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(stagingDir, exportPidFile), []byte(strconv.Itoa(os.Getppid())), 0644))
+
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	daemon.registry["acme/foo:v1"] = createImageArchive(t, "acme/foo:v1", "base")
+
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"acme/foo:v1"}, Out: ioutil.Discard}
+	exported, err := daemon.handler().ExportImages(config, false)
+	assert.EqualError(t, err, "Exporting acme/foo:v1 is already in progress")
+	assert.Empty(t, exported)
+}
+
+func Test_failed_exports_are_reported(t *testing.T) {
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	daemon.registry["acme/foo:v1"] = createImageArchive(t, "acme/foo:v1", "base")
+
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	out := new(bytes.Buffer)
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: []string{"acme/missing:v1", "acme/foo:v1"}, Out: out}
+	exported, err := daemon.handler().ExportImages(config, false)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "manifest for acme/missing:v1 not found")
+	assert.Equal(t, []string{"acme/foo:v1"}, exported)
+	assert.Contains(t, out.String(), "Exporting 'acme/missing:v1' FAIL\n")
+}
+
+func Test_for_each_image_uses_bounded_number_of_workers(t *testing.T) {
+	images := []string{"a", "b", "c", "d", "e", "f", "g"}
+	var lock sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	processed := make([]string, len(images))
+
+	forEachImage(images, 3, func(index int, image string) {
+		lock.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		lock.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		processed[index] = image
+
+		lock.Lock()
+		inFlight--
+		lock.Unlock()
+	})
+
+	assert.Equal(t, images, processed)
+	assert.Equal(t, 3, maxInFlight)
+}
+
+func Test_concurrency_defaults_and_is_bounded_by_number_of_images(t *testing.T) {
+	assert.Equal(t, DefaultConcurrency, concurrency(&ImageCacheConfig{CachedImages: testImages}))
+	assert.Equal(t, 2, concurrency(&ImageCacheConfig{CachedImages: testImages, Concurrency: 2}))
+	assert.Equal(t, 4, concurrency(&ImageCacheConfig{CachedImages: testImages, Concurrency: 10}))
+}
+
+func createCacheDir(t *testing.T) string {
+	cacheDir, err := ioutil.TempDir("", "minishift-test-image-cache-")
+	assert.NoError(t, err)
+	return cacheDir
+}
+
+// exportTestImages exports the specified images into the cache. Each image consists of a shared base layer and a
+// layer specific to the image.
+func exportTestImages(t *testing.T, cacheDir string, images ...string) {
+	daemon := newFakeDockerDaemon(t)
+	defer daemon.close()
+	for _, image := range images {
+		daemon.images[image] = createImageArchive(t, image, "base", image)
+	}
+
+	config := &ImageCacheConfig{HostCacheDir: cacheDir, CachedImages: images, Out: ioutil.Discard}
+	_, err := daemon.handler().ExportImages(config, false)
+	assert.NoError(t, err)
+}
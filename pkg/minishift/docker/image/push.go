@@ -0,0 +1,174 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/copy"
+	"github.com/containers/image/docker/reference"
+	"github.com/containers/image/oci/layout"
+	"github.com/containers/image/transports/alltransports"
+	"github.com/containers/image/types"
+)
+
+const (
+	dockerArchiveTransport = "docker-archive:"
+	ociArchiveTransport    = "oci-archive:"
+	// registryUser is the user name used to authenticate against the integrated registry. The registry only checks
+	// the token, so any non empty user name works.
+	registryUser = "minishift"
+)
+
+// PushOptions configures pushing an image into the integrated registry.
+type PushOptions struct {
+	// Source is the name of a cached image or a local archive prefixed with docker-archive: or oci-archive:
+	Source string
+	// CacheDir is the image cache containing the cached images
+	CacheDir string
+	// Registry is the host and port of the integrated registry
+	Registry string
+	// Project is the project to push the image to. The image stream is created if it does not exist.
+	Project string
+	// Name is the name and tag of the image stream tag to push to. It defaults to the name and tag of the source.
+	Name string
+	// Token is the OpenShift token used to authenticate against the registry
+	Token string
+	// TLSVerify enables the verification of the TLS certificate of the registry
+	TLSVerify bool
+	// Out receives the progress of the push. Nothing is reported if it is nil.
+	Out io.Writer
+}
+
+// PushImage pushes the image specified by the options into the integrated registry and returns the pushed image.
+func (handler *OciImageHandler) PushImage(options *PushOptions) (string, error) {
+	srcRef, err := handler.pushSource(options)
+	if err != nil {
+		return "", err
+	}
+
+	name := options.Name
+	if name == "" {
+		if name, err = defaultPushName(options.Source, srcRef); err != nil {
+			return "", err
+		}
+	}
+	target, err := pushTarget(options.Registry, options.Project, name)
+	if err != nil {
+		return "", err
+	}
+
+	destRef, err := alltransports.ParseImageName(fmt.Sprintf("docker://%s", target))
+	if err != nil {
+		return "", fmt.Errorf("Invalid image destination '%s': %v", target, err)
+	}
+
+	policyContext, err := handler.getPolicyContext()
+	if err != nil {
+		return "", err
+	}
+	defer policyContext.Destroy()
+
+	err = copy.Image(context.TODO(), policyContext, destRef, srcRef, &copy.Options{
+		ReportWriter: options.Out,
+		SourceCtx: &types.SystemContext{
+			OCIAcceptUncompressedLayers: true,
+			OCISharedBlobDirPath:        filepath.Join(options.CacheDir, "blobs"),
+		},
+		DestinationCtx: &types.SystemContext{
+			DockerAuthConfig:            &types.DockerAuthConfig{Username: registryUser, Password: options.Token},
+			DockerInsecureSkipTLSVerify: !options.TLSVerify,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error pushing '%s' to '%s': %v", options.Source, target, err)
+	}
+
+	return target, nil
+}
+
+// pushSource returns the reference of the image to push, either an archive or an image of the cache
+func (handler *OciImageHandler) pushSource(options *PushOptions) (types.ImageReference, error) {
+	if isArchive(options.Source) {
+		ref, err := alltransports.ParseImageName(options.Source)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid image source '%s': %v", options.Source, err)
+		}
+		return ref, nil
+	}
+
+	config := &ImageCacheConfig{HostCacheDir: options.CacheDir}
+	if !handler.IsImageCached(config, options.Source) {
+		return nil, fmt.Errorf("The image '%s' is not cached. Export it first using 'minishift image export' or specify an archive", options.Source)
+	}
+	ref, err := layout.NewReference(options.CacheDir, options.Source)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid image source '%s': %v", options.Source, err)
+	}
+	return ref, nil
+}
+
+// defaultPushName returns the last path component and the tag of the image name of the source, eg 'foo:v1' for
+// 'acme/foo:v1'
+func defaultPushName(source string, srcRef types.ImageReference) (string, error) {
+	named := srcRef.DockerReference()
+	if named == nil {
+		if isArchive(source) {
+			return "", fmt.Errorf("The image name cannot be determined from '%s'. Specify the name of the image stream tag", source)
+		}
+		// images of the cache are only known by the name annotation of the OCI index
+		var err error
+		if named, err = reference.ParseNormalizedNamed(source); err != nil {
+			return "", fmt.Errorf("Invalid image name '%s': %v", source, err)
+		}
+	}
+
+	tag := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	return fmt.Sprintf("%s:%s", path.Base(reference.Path(named)), tag), nil
+}
+
+// pushTarget returns the image reference of the image stream tag name in the specified project of the registry
+func pushTarget(registry string, project string, name string) (string, error) {
+	registry = strings.TrimSpace(registry)
+	if registry == "" {
+		return "", fmt.Errorf("The address of the registry is unknown")
+	}
+	if project == "" {
+		return "", fmt.Errorf("You need to specify the project to push the image to")
+	}
+	if !strings.Contains(name, ":") {
+		name = name + ":latest"
+	}
+
+	target := fmt.Sprintf("%s/%s/%s", registry, project, name)
+	if _, err := reference.ParseNormalizedNamed(target); err != nil {
+		return "", fmt.Errorf("Invalid image stream tag '%s': %v", name, err)
+	}
+	return target, nil
+}
+
+func isArchive(source string) bool {
+	return strings.HasPrefix(source, dockerArchiveTransport) || strings.HasPrefix(source, ociArchiveTransport)
+}
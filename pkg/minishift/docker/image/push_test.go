@@ -0,0 +1,196 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRegistry implements the parts of the Docker registry API needed to push images, requiring basic authentication
+// with the specified token as password
+type fakeRegistry struct {
+	server    *httptest.Server
+	token     string
+	lock      sync.Mutex
+	blobs     map[string]bool
+	manifests map[string][]byte
+}
+
+func newFakeRegistry(token string) *fakeRegistry {
+	registry := &fakeRegistry{token: token, blobs: make(map[string]bool), manifests: make(map[string][]byte)}
+	registry.server = httptest.NewTLSServer(http.HandlerFunc(registry.handle))
+	return registry
+}
+
+func (r *fakeRegistry) address() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+func (r *fakeRegistry) handle(w http.ResponseWriter, req *http.Request) {
+	if _, password, ok := req.BasicAuth(); !ok || password != r.token {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	path := req.URL.Path
+	switch {
+	case path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.Contains(path, "/blobs/uploads/") && req.Method == http.MethodPost:
+		w.Header().Set("Location", path+"upload")
+		w.WriteHeader(http.StatusAccepted)
+	case strings.Contains(path, "/blobs/uploads/") && (req.Method == http.MethodPatch || req.Method == http.MethodPut):
+		ioutil.ReadAll(req.Body)
+		if digest := req.URL.Query().Get("digest"); digest != "" {
+			r.blobs[digest] = true
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Location", path)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.Contains(path, "/blobs/"):
+		digest := path[strings.LastIndex(path, "/")+1:]
+		if !r.blobs[digest] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case strings.Contains(path, "/manifests/") && req.Method == http.MethodPut:
+		manifest, _ := ioutil.ReadAll(req.Body)
+		r.manifests[strings.TrimPrefix(path, "/v2/")] = manifest
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_cached_image_is_pushed_to_registry(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1")
+
+	registry := newFakeRegistry("secret")
+	defer registry.server.Close()
+
+	handler := &OciImageHandler{}
+	target, err := handler.PushImage(&PushOptions{
+		Source:   "acme/foo:v1",
+		CacheDir: cacheDir,
+		Registry: registry.address(),
+		Project:  "myproject",
+		Token:    "secret",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s/myproject/foo:v1", registry.address()), target)
+	assert.Contains(t, registry.manifests, "myproject/foo/manifests/v1")
+	// config and both layers
+	assert.Len(t, registry.blobs, 3)
+}
+
+func Test_archive_is_pushed_with_specified_name(t *testing.T) {
+	testDir := createCacheDir(t)
+	defer os.RemoveAll(testDir)
+	archive := filepath.Join(testDir, "foo.tar")
+	assert.NoError(t, ioutil.WriteFile(archive, createImageArchive(t, "acme/foo:v1", "base"), 0644))
+
+	registry := newFakeRegistry("secret")
+	defer registry.server.Close()
+
+	handler := &OciImageHandler{}
+	_, err := handler.PushImage(&PushOptions{
+		Source:   "docker-archive:" + archive,
+		Registry: registry.address(),
+		Project:  "myproject",
+		Name:     "bar:v2",
+		Token:    "secret",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, registry.manifests, "myproject/bar/manifests/v2")
+}
+
+func Test_push_fails_with_invalid_token(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+	exportTestImages(t, cacheDir, "acme/foo:v1")
+
+	registry := newFakeRegistry("secret")
+	defer registry.server.Close()
+
+	handler := &OciImageHandler{}
+	_, err := handler.PushImage(&PushOptions{
+		Source:   "acme/foo:v1",
+		CacheDir: cacheDir,
+		Registry: registry.address(),
+		Project:  "myproject",
+		Token:    "wrong",
+	})
+	assert.Error(t, err)
+	assert.Empty(t, registry.manifests)
+}
+
+func Test_push_fails_for_image_which_is_not_cached(t *testing.T) {
+	cacheDir := createCacheDir(t)
+	defer os.RemoveAll(cacheDir)
+
+	handler := &OciImageHandler{}
+	_, err := handler.PushImage(&PushOptions{Source: "acme/foo:v1", CacheDir: cacheDir, Registry: "registry", Project: "myproject"})
+	assert.EqualError(t, err, "The image 'acme/foo:v1' is not cached. Export it first using 'minishift image export' or specify an archive")
+}
+
+func Test_push_target(t *testing.T) {
+	var testCases = []struct {
+		registry    string
+		project     string
+		name        string
+		expected    string
+		expectedErr string
+	}{
+		{"172.30.1.1:5000", "myproject", "foo:v1", "172.30.1.1:5000/myproject/foo:v1", ""},
+		{"docker-registry-default.192.168.99.100.nip.io\n", "myproject", "foo", "docker-registry-default.192.168.99.100.nip.io/myproject/foo:latest", ""},
+		{"", "myproject", "foo", "", "The address of the registry is unknown"},
+		{"172.30.1.1:5000", "", "foo", "", "You need to specify the project to push the image to"},
+		{"172.30.1.1:5000", "myproject", "Foo:v1", "", "Invalid image stream tag 'Foo:v1'"},
+	}
+
+	for _, testCase := range testCases {
+		target, err := pushTarget(testCase.registry, testCase.project, testCase.name)
+		if testCase.expectedErr != "" {
+			assert.Error(t, err)
+			if err != nil {
+				assert.Contains(t, err.Error(), testCase.expectedErr)
+			}
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, target)
+	}
+}
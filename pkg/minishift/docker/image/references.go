@@ -0,0 +1,192 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/minishift/minishift/pkg/util/filehelper"
+)
+
+// ReferencesFileName is the name of the file in the image cache directory which records which profiles need which of
+// the cached images, together with the time each image was last imported or exported.
+const ReferencesFileName = "references.json"
+
+// ImageReferences tracks the references of profiles to the images of the shared image cache.
+type ImageReferences struct {
+	FilePath string `json:"-"`
+	// Profiles maps the profile names to the images the profile needs, ie its cache-images setting and the images of
+	// the OpenShift version it runs
+	Profiles map[string][]string `json:"profiles"`
+	// LastUsed maps image names to the time the image was last imported or exported
+	LastUsed map[string]time.Time `json:"lastUsed"`
+}
+
+// LoadImageReferences reads the image references of the specified cache directory. If no references have been
+// recorded yet, empty references are returned.
+func LoadImageReferences(cacheDir string) (*ImageReferences, error) {
+	references := &ImageReferences{
+		FilePath: filepath.Join(cacheDir, ReferencesFileName),
+		Profiles: make(map[string][]string),
+		LastUsed: make(map[string]time.Time),
+	}
+	if !filehelper.Exists(references.FilePath) {
+		return references, nil
+	}
+
+	raw, err := ioutil.ReadFile(references.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, references); err != nil {
+		return nil, err
+	}
+	if references.Profiles == nil {
+		references.Profiles = make(map[string][]string)
+	}
+	if references.LastUsed == nil {
+		references.LastUsed = make(map[string]time.Time)
+	}
+	return references, nil
+}
+
+// Write persists the references. The file is replaced atomically, since several Minishift processes might use the
+// cache at the same time.
+func (r *ImageReferences) Write() error {
+	jsonData, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.FilePath), 0755); err != nil {
+		return err
+	}
+
+	tmpFile := r.FilePath + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, jsonData, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, r.FilePath)
+}
+
+// SetProfileImages replaces the images referenced by the specified profile.
+func (r *ImageReferences) SetProfileImages(profile string, images []string) {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if !seen[image] {
+			seen[image] = true
+			unique = append(unique, image)
+		}
+	}
+	sort.Strings(unique)
+	r.Profiles[profile] = unique
+}
+
+// RemoveProfile removes all references of the specified profile.
+func (r *ImageReferences) RemoveProfile(profile string) {
+	delete(r.Profiles, profile)
+}
+
+// RetainProfiles removes the references of all profiles which are not part of the specified list, eg because they
+// have been deleted. It returns the names of the removed profiles.
+func (r *ImageReferences) RetainProfiles(profiles []string) []string {
+	existing := make(map[string]bool)
+	for _, profile := range profiles {
+		existing[profile] = true
+	}
+
+	var removed []string
+	for profile := range r.Profiles {
+		if !existing[profile] {
+			removed = append(removed, profile)
+			delete(r.Profiles, profile)
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// ReferencingProfiles returns the sorted names of the profiles referencing the specified image.
+func (r *ImageReferences) ReferencingProfiles(image string) []string {
+	var profiles []string
+	for profile, images := range r.Profiles {
+		for _, referenced := range images {
+			if referenced == image {
+				profiles = append(profiles, profile)
+				break
+			}
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// Touch records the specified time as last use of the specified images.
+func (r *ImageReferences) Touch(now time.Time, images ...string) {
+	for _, image := range images {
+		r.LastUsed[image] = now
+	}
+}
+
+// RecordProfileImages records the images referenced by the specified profile in the references file of the cache.
+func RecordProfileImages(cacheDir string, profile string, images []string) error {
+	return updateImageReferences(cacheDir, func(references *ImageReferences) {
+		references.SetProfileImages(profile, images)
+	})
+}
+
+// RemoveProfileImages removes the references of the specified profile from the references file of the cache.
+func RemoveProfileImages(cacheDir string, profile string) error {
+	if !filehelper.Exists(filepath.Join(cacheDir, ReferencesFileName)) {
+		return nil
+	}
+	return updateImageReferences(cacheDir, func(references *ImageReferences) {
+		references.RemoveProfile(profile)
+	})
+}
+
+// touchImages records the current time as last use of the specified images.
+func touchImages(cacheDir string, images []string) error {
+	if len(images) == 0 {
+		return nil
+	}
+	return updateImageReferences(cacheDir, func(references *ImageReferences) {
+		references.Touch(time.Now(), images...)
+	})
+}
+
+// updateImageReferences loads the references of the specified cache directory, applies the specified update and
+// writes them back, while holding the lock of the cache.
+func updateImageReferences(cacheDir string, update func(references *ImageReferences)) error {
+	unlock, err := lockImageCache(cacheDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	references, err := LoadImageReferences(cacheDir)
+	if err != nil {
+		return err
+	}
+	update(references)
+	return references.Write()
+}
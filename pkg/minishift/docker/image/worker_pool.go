@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2017 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/minishift/minishift/pkg/util/progressdots"
+)
+
+// DefaultConcurrency is the number of images imported or exported in parallel if ImageCacheConfig does not specify a concurrency
+const DefaultConcurrency = 3
+
+// concurrency returns the number of images to process in parallel for the specified config, which is never more than
+// the number of images
+func concurrency(config *ImageCacheConfig) int {
+	workers := config.Concurrency
+	if workers < 1 {
+		workers = DefaultConcurrency
+	}
+	if workers > len(config.CachedImages) {
+		workers = len(config.CachedImages)
+	}
+	return workers
+}
+
+// forEachImage calls fn for each of the specified images, using at most workers goroutines. fn is called with the index
+// of the image, so that results can be stored by index and processed in the order of the images once all images are done.
+func forEachImage(images []string, workers int, fn func(index int, image string)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				fn(index, images[index])
+			}
+		}()
+	}
+
+	for index := range images {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// progressReporter writes the progress of the processed images. If the images are processed one at a time, progress
+// dots are shown while an image is processed. Otherwise a single line is written once an image is done, since the
+// progress dots of several images would be interleaved.
+type progressReporter struct {
+	out        io.Writer
+	sequential bool
+	lock       sync.Mutex
+}
+
+type imageProgress struct {
+	reporter     *progressReporter
+	message      string
+	progressDots *progressdots.ProgressDots
+}
+
+func newProgressReporter(out io.Writer, workers int) *progressReporter {
+	return &progressReporter{out: out, sequential: workers <= 1}
+}
+
+// start marks the start of processing an image, message describes the operation, eg "Exporting 'foo'".
+func (r *progressReporter) start(message string) *imageProgress {
+	progress := &imageProgress{reporter: r, message: message}
+	if r.sequential {
+		fmt.Fprint(r.out, message)
+		progress.progressDots = progressdots.New()
+		progress.progressDots.SetWriter(r.out)
+		progress.progressDots.Start()
+	}
+	return progress
+}
+
+// end marks the end of processing the image with the specified status.
+func (p *imageProgress) end(status ProgressStatus) {
+	if p.progressDots != nil {
+		p.progressDots.Stop()
+		fmt.Fprintf(p.reporter.out, " %s\n", status.String())
+		return
+	}
+
+	p.reporter.lock.Lock()
+	defer p.reporter.lock.Unlock()
+	fmt.Fprintf(p.reporter.out, "%s %s\n", strings.TrimRight(p.message, " "), status.String())
+}
@@ -78,6 +78,10 @@ func (h *CifsHostFolder) Mount(driver drivers.Driver) error {
 		cmd = fmt.Sprintf("%s,domain=%s", cmd, h.config.Options["domain"])
 	}
 
+	if options := cifsMountOptions(h.config); len(options) > 0 {
+		cmd = fmt.Sprintf("%s,%s", cmd, strings.Join(options, ","))
+	}
+
 	if err := h.ensureMountPointExists(driver); err != nil {
 		fmt.Println("FAIL")
 		return fmt.Errorf("error occured while creating mountpoint. %s", err)
@@ -24,6 +24,14 @@ const (
 	Password     = "password"
 	Domain       = "domain"
 	ExtraOptions = "extra-options"
+	ReadOnly     = "read-only"
+	Ignore       = "ignore"
+	UID          = "uid"
+	GID          = "gid"
+	FileMode     = "file-mode"
+	DirMode      = "dir-mode"
+	CacheMode    = "cache"
+	PVSize       = "pv-size"
 )
 
 type HostFolderConfig struct {
@@ -39,3 +47,8 @@ func (hf *HostFolderConfig) Option(key string) string {
 func (hf *HostFolderConfig) MountPoint() string {
 	return hf.Options[MountPoint]
 }
+
+// IsReadOnly returns true if the host folder may only be read from the VM.
+func (hf *HostFolderConfig) IsReadOnly() bool {
+	return hf.Options[ReadOnly] == "true"
+}
@@ -39,3 +39,18 @@ func TestHostFolderConfig(t *testing.T) {
 	assert.Equal(t, "am!g@4ever", hostFolderConfigActual.Option(Password))
 	assert.Equal(t, "DESKTOP-RHAIMSWIN", hostFolderConfigActual.Option(Domain))
 }
+
+func TestHostFolderConfigReadOnly(t *testing.T) {
+	hostFolderConfig := HostFolderConfig{
+		Name:    "data",
+		Type:    "sshfs",
+		Options: map[string]string{},
+	}
+	assert.False(t, hostFolderConfig.IsReadOnly())
+
+	hostFolderConfig.Options[ReadOnly] = "false"
+	assert.False(t, hostFolderConfig.IsReadOnly())
+
+	hostFolderConfig.Options[ReadOnly] = "true"
+	assert.True(t, hostFolderConfig.IsReadOnly())
+}
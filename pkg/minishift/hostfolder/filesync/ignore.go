@@ -0,0 +1,89 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"path"
+	"strings"
+)
+
+// Ignore decides which paths are excluded from the synchronization.
+type Ignore struct {
+	patterns []string
+}
+
+// NewIgnore creates an Ignore for the specified patterns. A pattern uses the syntax of path.Match. Patterns without
+// a slash are matched against the base name of a path, all other patterns against the complete relative path.
+// A pattern with a trailing slash only matches directories.
+func NewIgnore(patterns []string) *Ignore {
+	ignore := &Ignore{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			ignore.patterns = append(ignore.patterns, pattern)
+		}
+	}
+	return ignore
+}
+
+// ParseIgnore creates an Ignore from a comma separated list of patterns.
+func ParseIgnore(patterns string) *Ignore {
+	return NewIgnore(strings.Split(patterns, ","))
+}
+
+// Patterns returns the patterns of this Ignore.
+func (i *Ignore) Patterns() []string {
+	return i.patterns
+}
+
+// Matches returns true if the specified slash separated relative path is excluded from the synchronization.
+// A path is also excluded if one of its parent directories is excluded.
+func (i *Ignore) Matches(relPath string, isDir bool) bool {
+	if i == nil || len(i.patterns) == 0 {
+		return false
+	}
+
+	segments := strings.Split(relPath, "/")
+	for n := 1; n <= len(segments); n++ {
+		// all but the last segment are directories
+		if i.matchesPath(strings.Join(segments[:n], "/"), n < len(segments) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Ignore) matchesPath(relPath string, isDir bool) bool {
+	for _, pattern := range i.patterns {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		name := relPath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relPath)
+		}
+
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ignore_matches_base_name_and_parents(t *testing.T) {
+	ignore := ParseIgnore("node_modules, *.log,target/,build/out")
+
+	assert.True(t, ignore.Matches("node_modules", true))
+	assert.True(t, ignore.Matches("web/node_modules/lodash/index.js", false))
+	assert.True(t, ignore.Matches("debug.log", false))
+	assert.True(t, ignore.Matches("logs/server.log", false))
+	assert.True(t, ignore.Matches("target", true))
+	assert.True(t, ignore.Matches("module/target/classes/App.class", false))
+	assert.True(t, ignore.Matches("build/out/app", false))
+
+	assert.False(t, ignore.Matches("target", false), "directory patterns should not match files")
+	assert.False(t, ignore.Matches("src/main.go", false))
+	assert.False(t, ignore.Matches("sub/build/out", false), "patterns with a slash should match the relative path")
+	assert.Equal(t, []string{"node_modules", "*.log", "target/", "build/out"}, ignore.Patterns())
+}
+
+func Test_empty_ignore_matches_nothing(t *testing.T) {
+	assert.False(t, ParseIgnore("").Matches("foo", false))
+
+	var ignore *Ignore
+	assert.False(t, ignore.Matches("foo", false))
+}
@@ -0,0 +1,168 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"sort"
+	"strings"
+)
+
+// Action is the operation required to bring a path in sync.
+type Action int
+
+const (
+	// Push copies the local version of a path to the remote side.
+	Push Action = iota
+
+	// Pull copies the remote version of a path to the local side.
+	Pull
+
+	// DeleteLocal removes a path from the local side.
+	DeleteLocal
+
+	// DeleteRemote removes a path from the remote side.
+	DeleteRemote
+
+	// Conflict marks a path which got changed on both sides. Conflicts are only reported, neither side is changed.
+	Conflict
+)
+
+func (a Action) String() string {
+	names := [...]string{
+		"push",
+		"pull",
+		"delete-local",
+		"delete-remote",
+		"conflict"}
+
+	// prevent panicking
+	if a < Push || a > Conflict {
+		return "unknown"
+	}
+	return names[a]
+}
+
+// Change is a single Action for a path.
+type Change struct {
+	Path   string
+	Action Action
+}
+
+// State is the content of both sides after the last synchronization.
+type State struct {
+	Local  Snapshot `json:"local"`
+	Remote Snapshot `json:"remote"`
+}
+
+// Plan compares the current local and remote snapshots against the state of the last synchronization and returns
+// the changes needed to bring both sides in sync. If initial is true, the last state is ignored and the local side
+// takes precedence over the remote side.
+func Plan(state State, local Snapshot, remote Snapshot, initial bool) []Change {
+	if initial {
+		state = State{}
+	}
+
+	var changes []Change
+	for _, path := range unionPaths(state.Local, state.Remote, local, remote) {
+		lastLocal, lastLocalExists := state.Local[path]
+		currentLocal, localExists := local[path]
+		lastRemote, lastRemoteExists := state.Remote[path]
+		currentRemote, remoteExists := remote[path]
+
+		if localExists && remoteExists && currentLocal.Dir && currentRemote.Dir {
+			// directories with the same name need no synchronization
+			continue
+		}
+
+		if initial {
+			if localExists {
+				changes = append(changes, Change{path, Push})
+			} else if remoteExists {
+				changes = append(changes, Change{path, Pull})
+			}
+			continue
+		}
+
+		localChanged := !same(lastLocal, lastLocalExists, currentLocal, localExists)
+		remoteChanged := !same(lastRemote, lastRemoteExists, currentRemote, remoteExists)
+		switch {
+		case localChanged && !remoteChanged:
+			if localExists {
+				changes = append(changes, Change{path, Push})
+			} else if remoteExists {
+				changes = append(changes, Change{path, DeleteRemote})
+			}
+		case !localChanged && remoteChanged:
+			if remoteExists {
+				changes = append(changes, Change{path, Pull})
+			} else if localExists {
+				changes = append(changes, Change{path, DeleteLocal})
+			}
+		case localChanged && remoteChanged:
+			if localExists || remoteExists {
+				changes = append(changes, Change{path, Conflict})
+			}
+		}
+	}
+
+	return dropUnsafeDeletes(changes)
+}
+
+// dropUnsafeDeletes removes the deletion of directories which still contain content needed by another change,
+// for example a directory removed on the host while a file in it got changed in the VM.
+func dropUnsafeDeletes(changes []Change) []Change {
+	var kept []string
+	for _, change := range changes {
+		if change.Action != DeleteLocal && change.Action != DeleteRemote {
+			kept = append(kept, change.Path)
+		}
+	}
+
+	var result []Change
+	for _, change := range changes {
+		if (change.Action == DeleteLocal || change.Action == DeleteRemote) && containsChildOf(kept, change.Path) {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result
+}
+
+func containsChildOf(paths []string, dir string) bool {
+	for _, path := range paths {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func unionPaths(snapshots ...Snapshot) []string {
+	set := map[string]bool{}
+	for _, snapshot := range snapshots {
+		for path := range snapshot {
+			set[path] = true
+		}
+	}
+
+	var paths []string
+	for path := range set {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,119 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	dir      = FileInfo{Dir: true}
+	original = FileInfo{Size: 5, ModTime: 1000}
+	modified = FileInfo{Size: 7, ModTime: 2000}
+)
+
+func Test_initial_plan_prefers_local_side(t *testing.T) {
+	local := Snapshot{"src": dir, "src/main.go": original, "both.txt": original}
+	remote := Snapshot{"src": dir, "both.txt": modified, "vm-only.txt": modified}
+
+	changes := Plan(State{Local: local, Remote: local}, local, remote, true)
+
+	assert.Equal(t, []Change{
+		{"both.txt", Push},
+		{"src/main.go", Push},
+		{"vm-only.txt", Pull},
+	}, changes)
+}
+
+func Test_plan_propagates_one_sided_changes(t *testing.T) {
+	state := State{
+		Local:  Snapshot{"changed-on-host": original, "changed-in-vm": original, "deleted-on-host": original, "deleted-in-vm": original, "same": original},
+		Remote: Snapshot{"changed-on-host": original, "changed-in-vm": original, "deleted-on-host": original, "deleted-in-vm": original, "same": original},
+	}
+	local := Snapshot{"changed-on-host": modified, "changed-in-vm": original, "deleted-in-vm": original, "same": original, "new-on-host": original}
+	remote := Snapshot{"changed-on-host": original, "changed-in-vm": modified, "deleted-on-host": original, "same": original, "new-in-vm": original}
+
+	changes := Plan(state, local, remote, false)
+
+	assert.Equal(t, []Change{
+		{"changed-in-vm", Pull},
+		{"changed-on-host", Push},
+		{"deleted-in-vm", DeleteLocal},
+		{"deleted-on-host", DeleteRemote},
+		{"new-in-vm", Pull},
+		{"new-on-host", Push},
+	}, changes)
+}
+
+func Test_plan_reports_conflicts(t *testing.T) {
+	state := State{
+		Local:  Snapshot{"modified": original, "deleted-on-host": original},
+		Remote: Snapshot{"modified": original, "deleted-on-host": original},
+	}
+	local := Snapshot{"modified": modified, "created": original}
+	remote := Snapshot{"modified": FileInfo{Size: 9, ModTime: 3000}, "deleted-on-host": modified, "created": modified}
+
+	changes := Plan(state, local, remote, false)
+
+	assert.Equal(t, []Change{
+		{"created", Conflict},
+		{"deleted-on-host", Conflict},
+		{"modified", Conflict},
+	}, changes)
+}
+
+func Test_plan_reports_conflicts_again_after_edit(t *testing.T) {
+	state := State{Local: Snapshot{"config.yml": original}, Remote: Snapshot{"config.yml": original}}
+	local := Snapshot{"config.yml": modified}
+	remote := Snapshot{"config.yml": FileInfo{Size: 9, ModTime: 3000}}
+
+	changes := Plan(state, local, remote, false)
+	assert.Equal(t, []Change{{"config.yml", Conflict}}, changes)
+
+	state = nextState(local, remote, changes)
+	assert.Empty(t, state.Local)
+	assert.Empty(t, state.Remote)
+
+	local = Snapshot{"config.yml": FileInfo{Size: 12, ModTime: 4000}}
+	assert.Equal(t, []Change{{"config.yml", Conflict}}, Plan(state, local, remote, false))
+
+	delete(remote, "config.yml")
+	assert.Equal(t, []Change{{"config.yml", Push}}, Plan(state, local, remote, false))
+}
+
+func Test_plan_keeps_directories_with_changed_content(t *testing.T) {
+	state := State{
+		Local:  Snapshot{"dir": dir, "dir/file": original},
+		Remote: Snapshot{"dir": dir, "dir/file": original},
+	}
+	local := Snapshot{}
+	remote := Snapshot{"dir": dir, "dir/file": modified}
+
+	changes := Plan(state, local, remote, false)
+
+	assert.Equal(t, []Change{{"dir/file", Conflict}}, changes)
+}
+
+func Test_plan_ignores_modification_time_of_directories(t *testing.T) {
+	state := State{Local: Snapshot{"dir": dir}, Remote: Snapshot{"dir": dir}}
+	local := Snapshot{"dir": FileInfo{Dir: true, ModTime: 5000}}
+	remote := Snapshot{"dir": FileInfo{Dir: true, ModTime: 6000}}
+
+	assert.Empty(t, Plan(state, local, remote, false))
+}
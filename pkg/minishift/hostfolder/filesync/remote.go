@@ -0,0 +1,192 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// removeBatchSize limits the number of paths passed to a single rm command
+	removeBatchSize = 50
+)
+
+// Remote is the side of the synchronization living in the VM.
+type Remote interface {
+	// Scan creates a snapshot of the remote directory.
+	Scan(ignore *Ignore) (Snapshot, error)
+
+	// Upload copies the specified paths of the local root directory to the remote directory.
+	Upload(localRoot string, paths []string) error
+
+	// Download copies the specified file paths of the remote directory into the local root directory.
+	Download(localRoot string, paths []string) error
+
+	// Remove removes the specified paths from the remote directory.
+	Remove(paths []string) error
+}
+
+// Runner runs the shell command cmd on the remote side, connecting stdin and stdout to the command.
+type Runner func(cmd string, stdin io.Reader, stdout io.Writer) error
+
+// SSHRunner returns a Runner which executes the commands via the specified SSH client.
+func SSHRunner(client *ssh.Client) Runner {
+	return func(cmd string, stdin io.Reader, stdout io.Writer) error {
+		session, err := client.NewSession()
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		var stderr bytes.Buffer
+		session.Stdin = stdin
+		session.Stdout = stdout
+		session.Stderr = &stderr
+		if err := session.Run(cmd); err != nil {
+			return fmt.Errorf("error running '%s': %s %s", cmd, err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+}
+
+type shellRemote struct {
+	dir string
+	run Runner
+}
+
+// NewShellRemote creates a Remote for the directory dir using GNU find, GNU tar and rm commands executed by run.
+func NewShellRemote(dir string, run Runner) Remote {
+	return &shellRemote{dir: path.Clean(dir), run: run}
+}
+
+func (r *shellRemote) Scan(ignore *Ignore) (Snapshot, error) {
+	// the entries are NUL terminated, since file names may contain any other character, including newlines
+	cmd := fmt.Sprintf("sudo find %s -mindepth 1 \\( -type f -o -type d \\) -printf '%%s|%%T@|%%y|%%P\\0'", quote(r.dir))
+	var out bytes.Buffer
+	if err := r.run(cmd, nil, &out); err != nil {
+		return nil, err
+	}
+
+	snapshot := Snapshot{}
+	reader := bufio.NewReader(&out)
+	for {
+		entry, err := reader.ReadString(0)
+		if err == io.EOF {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.SplitN(strings.TrimSuffix(entry, "\x00"), "|", 4)
+		if len(fields) != 4 || fields[3] == "" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[0], 10, 64)
+		// the modification time has a fractional part, snapshots use full seconds
+		modTime, _ := strconv.ParseInt(strings.SplitN(fields[1], ".", 2)[0], 10, 64)
+		rel := fields[3]
+
+		isDir := fields[2] == "d"
+		if ignore.Matches(rel, isDir) {
+			continue
+		}
+		if isDir {
+			snapshot[rel] = FileInfo{Dir: true}
+		} else {
+			snapshot[rel] = FileInfo{Size: size, ModTime: modTime}
+		}
+	}
+}
+
+func (r *shellRemote) Upload(localRoot string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(writer, localRoot, paths))
+	}()
+
+	err := r.run(fmt.Sprintf("sudo tar -xf - -C %s", quote(r.dir)), reader, nil)
+	reader.Close()
+	return err
+}
+
+func (r *shellRemote) Download(localRoot string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	runErr := make(chan error, 1)
+	go func() {
+		// the names are NUL separated and prefixed with ./, so that neither newlines nor leading dashes in file names
+		// are interpreted by tar
+		var names bytes.Buffer
+		for _, p := range paths {
+			names.WriteString("./" + p + "\x00")
+		}
+		err := r.run(fmt.Sprintf("sudo tar --null --verbatim-files-from -cf - -C %s -T -", quote(r.dir)), &names, writer)
+		writer.CloseWithError(err)
+		runErr <- err
+	}()
+
+	err := extractTar(reader, localRoot)
+	if err == nil {
+		// tar pads its output beyond the end of the archive
+		_, err = io.Copy(ioutil.Discard, reader)
+	}
+	reader.CloseWithError(err)
+	if err := <-runErr; err != nil {
+		return err
+	}
+	return err
+}
+
+func (r *shellRemote) Remove(paths []string) error {
+	for start := 0; start < len(paths); start += removeBatchSize {
+		end := start + removeBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		var args []string
+		for _, p := range paths[start:end] {
+			args = append(args, quote(path.Join(r.dir, p)))
+		}
+		if err := r.run(fmt.Sprintf("sudo rm -rf -- %s", strings.Join(args, " ")), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quote(s string) string {
+	return "'" + minishiftStrings.EscapeSingleQuote(s) + "'"
+}
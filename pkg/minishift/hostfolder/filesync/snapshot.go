@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FileInfo describes a synchronized file or directory. ModTime is in seconds since the epoch, since this is the
+// precision available on both sides.
+type FileInfo struct {
+	Size    int64 `json:"size"`
+	ModTime int64 `json:"modTime"`
+	Dir     bool  `json:"dir,omitempty"`
+}
+
+// Snapshot maps slash separated paths relative to the synchronized directory to their FileInfo.
+type Snapshot map[string]FileInfo
+
+// Paths returns the sorted paths of the snapshot.
+func (s Snapshot) Paths() []string {
+	var paths []string
+	for p := range s {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// same returns true if the two entries are considered unchanged. The modification time of directories changes with
+// their content, so directories are only compared by their type.
+func same(a FileInfo, aExists bool, b FileInfo, bExists bool) bool {
+	if aExists != bExists {
+		return false
+	}
+	if !aExists {
+		return true
+	}
+	if a.Dir || b.Dir {
+		return a.Dir == b.Dir
+	}
+	return a.Size == b.Size && a.ModTime == b.ModTime
+}
+
+// ScanLocal creates a snapshot of the specified local directory. Files and directories matched by ignore are skipped,
+// as well as anything which is neither a regular file nor a directory.
+func ScanLocal(root string, ignore *Ignore) (Snapshot, error) {
+	snapshot := Snapshot{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if ignore.Matches(rel, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case info.IsDir():
+			snapshot[rel] = FileInfo{Dir: true}
+		case info.Mode().IsRegular():
+			snapshot[rel] = FileInfo{Size: info.Size(), ModTime: info.ModTime().Unix()}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
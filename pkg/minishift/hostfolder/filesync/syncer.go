@@ -0,0 +1,266 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+const (
+	// settleTime is the time to wait for further local changes before a synchronization is started
+	settleTime = 500 * time.Millisecond
+)
+
+// Syncer keeps a local directory and a Remote in sync. The state of the last synchronization is persisted, so that
+// changes and deletions on both sides can be told apart.
+type Syncer struct {
+	localDir  string
+	remote    Remote
+	ignore    *Ignore
+	statePath string
+}
+
+// Result lists the paths which got changed by a synchronization.
+type Result struct {
+	Pushed        []string
+	Pulled        []string
+	DeletedLocal  []string
+	DeletedRemote []string
+	Conflicts     []string
+}
+
+// NewSyncer creates a Syncer for the local directory and the remote, persisting its state in statePath.
+func NewSyncer(localDir string, remote Remote, ignore *Ignore, statePath string) *Syncer {
+	return &Syncer{
+		localDir:  localDir,
+		remote:    remote,
+		ignore:    ignore,
+		statePath: statePath,
+	}
+}
+
+// Changed returns true if the synchronization changed anything. Conflicts are reported on every synchronization until
+// they are resolved and do not count as change.
+func (r *Result) Changed() bool {
+	return len(r.Pushed)+len(r.Pulled)+len(r.DeletedLocal)+len(r.DeletedRemote) > 0
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%d pushed, %d pulled, %d deleted on host, %d deleted in VM, %d conflicts",
+		len(r.Pushed), len(r.Pulled), len(r.DeletedLocal), len(r.DeletedRemote), len(r.Conflicts))
+}
+
+// Sync synchronizes the local directory and the remote once. On the initial synchronization the local side takes
+// precedence. Afterwards a path changed on only one side is copied to or removed from the other side. A path changed
+// on both sides is reported as conflict and left untouched.
+func (s *Syncer) Sync(initial bool) (*Result, error) {
+	state, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := ScanLocal(s.localDir, s.ignore)
+	if err != nil {
+		return nil, fmt.Errorf("error scanning '%s': %s", s.localDir, err)
+	}
+
+	remote, err := s.remote.Scan(s.ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Plan(state, local, remote, initial)
+	result := &Result{}
+	var download []string
+	for _, change := range changes {
+		switch change.Action {
+		case Push:
+			result.Pushed = append(result.Pushed, change.Path)
+		case Pull:
+			result.Pulled = append(result.Pulled, change.Path)
+			if remote[change.Path].Dir {
+				if err = checkNoSymlinks(s.localDir, s.localPath(change.Path)); err == nil {
+					err = os.MkdirAll(s.localPath(change.Path), 0755)
+				}
+			} else {
+				download = append(download, change.Path)
+			}
+		case DeleteLocal:
+			result.DeletedLocal = append(result.DeletedLocal, change.Path)
+			err = os.RemoveAll(s.localPath(change.Path))
+		case DeleteRemote:
+			result.DeletedRemote = append(result.DeletedRemote, change.Path)
+		case Conflict:
+			result.Conflicts = append(result.Conflicts, change.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.remote.Remove(result.DeletedRemote); err != nil {
+		return nil, err
+	}
+	if err := s.remote.Upload(s.localDir, result.Pushed); err != nil {
+		return nil, err
+	}
+	if err := s.remote.Download(s.localDir, download); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveState(nextState(local, remote, changes)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Watch synchronizes whenever the local directory changes and at least once per interval to pick up changes of the
+// remote, until stop is closed. The outcome of every synchronization is passed to report.
+func (s *Syncer) Watch(interval time.Duration, stop <-chan struct{}, report func(*Result, error)) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		glog.Warningf("Unable to watch '%s', falling back to polling: %s", s.localDir, err)
+	} else {
+		defer watcher.Close()
+	}
+
+	var events chan fsnotify.Event
+	if watcher != nil {
+		events = watcher.Events
+		s.watchDirs(watcher)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	settle := time.NewTimer(settleTime)
+	settle.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-events:
+			settle.Reset(settleTime)
+			continue
+		case <-settle.C:
+		case <-ticker.C:
+		}
+
+		report(s.Sync(false))
+		if watcher != nil {
+			s.watchDirs(watcher)
+		}
+	}
+}
+
+// watchDirs adds all local directories to the watcher, since file system events are not reported recursively.
+func (s *Syncer) watchDirs(watcher *fsnotify.Watcher) {
+	filepath.Walk(s.localDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(s.localDir, path); err == nil && rel != "." && s.ignore.Matches(filepath.ToSlash(rel), true) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			glog.Warningf("Unable to watch '%s': %s", path, err)
+		}
+		return nil
+	})
+}
+
+func (s *Syncer) localPath(p string) string {
+	return filepath.Join(s.localDir, filepath.FromSlash(p))
+}
+
+func (s *Syncer) loadState() (State, error) {
+	state := State{}
+	content, err := ioutil.ReadFile(s.statePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("error reading synchronization state '%s': %s", s.statePath, err)
+	}
+	return state, nil
+}
+
+func (s *Syncer) saveState(state State) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.statePath), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := s.statePath + ".part"
+	if err := ioutil.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.statePath)
+}
+
+// nextState derives the state after the changes got applied. Copied entries keep their size and modification time,
+// so both sides record the same FileInfo. Conflicting paths are left out, so that they are reported again until one
+// side is deleted, which resolves the conflict in favour of the other side.
+func nextState(local Snapshot, remote Snapshot, changes []Change) State {
+	state := State{Local: Snapshot{}, Remote: Snapshot{}}
+	for p, info := range local {
+		state.Local[p] = info
+	}
+	for p, info := range remote {
+		state.Remote[p] = info
+	}
+
+	for _, change := range changes {
+		switch change.Action {
+		case Push:
+			state.Remote[change.Path] = local[change.Path]
+		case Pull:
+			state.Local[change.Path] = remote[change.Path]
+		case DeleteLocal:
+			removeTree(state.Local, change.Path)
+		case DeleteRemote:
+			removeTree(state.Remote, change.Path)
+		case Conflict:
+			delete(state.Local, change.Path)
+			delete(state.Remote, change.Path)
+		}
+	}
+	return state
+}
+
+func removeTree(snapshot Snapshot, dir string) {
+	for p := range snapshot {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			delete(snapshot, p)
+		}
+	}
+}
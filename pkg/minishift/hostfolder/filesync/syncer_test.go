@@ -0,0 +1,230 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// localRunner runs the remote commands in a local shell, without sudo
+func localRunner(cmd string, stdin io.Reader, stdout io.Writer) error {
+	command := exec.Command("sh", "-c", strings.Replace(cmd, "sudo ", "", -1))
+	command.Stdin = stdin
+	command.Stdout = stdout
+	return command.Run()
+}
+
+func Test_sync_keeps_both_sides_in_sync(t *testing.T) {
+	local, remote, syncer := setUp(t, "")
+	defer os.RemoveAll(filepath.Dir(local))
+
+	writeFile(t, local, "src/main.go", "package main", -time.Hour)
+	writeFile(t, local, "README.md", "readme", -time.Hour)
+	writeFile(t, remote, "README.md", "old readme", -2*time.Hour)
+	writeFile(t, remote, "generated.txt", "generated", -time.Hour)
+
+	result, err := syncer.Sync(true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "src", "src/main.go"}, result.Pushed)
+	assert.Equal(t, []string{"generated.txt"}, result.Pulled)
+	assertContent(t, remote, "src/main.go", "package main")
+	assertContent(t, remote, "README.md", "readme")
+	assertContent(t, local, "generated.txt", "generated")
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed(), "unexpected changes: %s", result)
+
+	writeFile(t, remote, "src/main.go", "package main // changed in VM", 0)
+	writeFile(t, local, "src/util/util.go", "package util", 0)
+	assert.NoError(t, os.Remove(filepath.Join(local, "generated.txt")))
+	assert.NoError(t, os.Remove(filepath.Join(remote, "README.md")))
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"src/util", "src/util/util.go"}, result.Pushed)
+	assert.Equal(t, []string{"src/main.go"}, result.Pulled)
+	assert.Equal(t, []string{"README.md"}, result.DeletedLocal)
+	assert.Equal(t, []string{"generated.txt"}, result.DeletedRemote)
+	assertContent(t, local, "src/main.go", "package main // changed in VM")
+	assertContent(t, remote, "src/util/util.go", "package util")
+	assertMissing(t, local, "README.md")
+	assertMissing(t, remote, "generated.txt")
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed(), "unexpected changes: %s", result)
+}
+
+func Test_sync_reports_conflicts_without_overwriting(t *testing.T) {
+	local, remote, syncer := setUp(t, "")
+	defer os.RemoveAll(filepath.Dir(local))
+
+	writeFile(t, local, "config.yml", "initial", -time.Hour)
+	_, err := syncer.Sync(true)
+	assert.NoError(t, err)
+
+	writeFile(t, local, "config.yml", "changed on host", 0)
+	writeFile(t, remote, "config.yml", "changed in the VM", 0)
+
+	result, err := syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config.yml"}, result.Conflicts)
+	assertContent(t, local, "config.yml", "changed on host")
+	assertContent(t, remote, "config.yml", "changed in the VM")
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config.yml"}, result.Conflicts, "conflicts should be reported until resolved")
+
+	// an edit after the conflict must not overwrite the other side
+	writeFile(t, local, "config.yml", "changed on host again", time.Second)
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config.yml"}, result.Conflicts)
+	assert.Empty(t, result.Pushed)
+	assertContent(t, remote, "config.yml", "changed in the VM")
+
+	// deleting one side resolves the conflict in favour of the other side
+	assert.NoError(t, os.Remove(filepath.Join(remote, "config.yml")))
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, []string{"config.yml"}, result.Pushed)
+	assertContent(t, remote, "config.yml", "changed on host again")
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed(), "unexpected changes: %s", result)
+	assert.Empty(t, result.Conflicts)
+}
+
+func Test_sync_transfers_hostile_file_names(t *testing.T) {
+	local, remote, syncer := setUp(t, "")
+	defer os.RemoveAll(filepath.Dir(local))
+
+	writeFile(t, remote, "-rf", "dash", -time.Hour)
+	writeFile(t, remote, "line\nbreak.txt", "newline", -time.Hour)
+	writeFile(t, remote, "--files-from=x/a|b", "option", -time.Hour)
+	writeFile(t, local, "-C", "pushed dash", -time.Hour)
+	writeFile(t, local, "pushed\nline.txt", "pushed newline", -time.Hour)
+
+	result, err := syncer.Sync(true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--files-from=x", "--files-from=x/a|b", "-rf", "line\nbreak.txt"}, result.Pulled)
+	assert.Equal(t, []string{"-C", "pushed\nline.txt"}, result.Pushed)
+	assertContent(t, local, "-rf", "dash")
+	assertContent(t, local, "line\nbreak.txt", "newline")
+	assertContent(t, local, "--files-from=x/a|b", "option")
+	assertContent(t, remote, "-C", "pushed dash")
+	assertContent(t, remote, "pushed\nline.txt", "pushed newline")
+
+	result, err = syncer.Sync(false)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed(), "unexpected changes: %s", result)
+}
+
+func Test_sync_does_not_write_through_local_symlinks(t *testing.T) {
+	local, remote, syncer := setUp(t, "")
+	defer os.RemoveAll(filepath.Dir(local))
+
+	outside := filepath.Join(filepath.Dir(local), "outside")
+	writeFile(t, outside, "bashrc", "original", -time.Hour)
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "bashrc"), filepath.Join(local, "bashrc")))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(local, "home")))
+
+	writeFile(t, remote, "bashrc", "written by the VM", 0)
+	_, err := syncer.Sync(true)
+	assert.Error(t, err)
+	assertContent(t, outside, "bashrc", "original")
+
+	assert.NoError(t, os.Remove(filepath.Join(remote, "bashrc")))
+	writeFile(t, remote, "home/bashrc", "written by the VM", 0)
+	_, err = syncer.Sync(true)
+	assert.Error(t, err)
+	assertContent(t, outside, "bashrc", "original")
+
+	assert.NoError(t, os.RemoveAll(filepath.Join(remote, "home")))
+	assert.NoError(t, os.MkdirAll(filepath.Join(remote, "home", "created-by-vm"), 0755))
+	_, err = syncer.Sync(true)
+	assert.Error(t, err)
+	assertMissing(t, outside, "created-by-vm")
+}
+
+func Test_sync_skips_ignored_paths(t *testing.T) {
+	local, remote, syncer := setUp(t, "node_modules,*.log")
+	defer os.RemoveAll(filepath.Dir(local))
+
+	writeFile(t, local, "index.js", "index", -time.Hour)
+	writeFile(t, local, "node_modules/lodash/lodash.js", "lodash", -time.Hour)
+	writeFile(t, remote, "npm-debug.log", "debug", -time.Hour)
+
+	result, err := syncer.Sync(true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"index.js"}, result.Pushed)
+	assert.Empty(t, result.Pulled)
+	assertMissing(t, remote, "node_modules")
+	assertMissing(t, local, "npm-debug.log")
+}
+
+func setUp(t *testing.T, ignore string) (string, string, *Syncer) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the remote commands require a POSIX shell")
+	}
+
+	testDir, err := ioutil.TempDir("", "minishift-test-filesync-")
+	assert.NoError(t, err)
+
+	local := filepath.Join(testDir, "local")
+	remote := filepath.Join(testDir, "remote")
+	for _, dir := range []string{local, remote} {
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+	}
+
+	syncer := NewSyncer(local, NewShellRemote(remote, localRunner), ParseIgnore(ignore), filepath.Join(testDir, "state.json"))
+	return local, remote, syncer
+}
+
+func writeFile(t *testing.T, root string, path string, content string, age time.Duration) {
+	file := filepath.Join(root, filepath.FromSlash(path))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	assert.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+
+	modTime := time.Now().Add(age)
+	assert.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+func assertContent(t *testing.T, root string, path string, expected string) {
+	content, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func assertMissing(t *testing.T, root string, path string) {
+	_, err := os.Stat(filepath.Join(root, filepath.FromSlash(path)))
+	assert.True(t, os.IsNotExist(err), "'%s' should not exist", path)
+}
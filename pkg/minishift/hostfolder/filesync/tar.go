@@ -0,0 +1,191 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filesync
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// writeTar writes the specified slash separated paths of the root directory as tar stream to w. Parent directories
+// are added as well, so that the stream can be extracted into an empty directory. Paths which vanished since they
+// got scanned are skipped.
+func writeTar(w io.Writer, root string, paths []string) error {
+	tarWriter := tar.NewWriter(w)
+
+	written := map[string]bool{}
+	for _, p := range withParents(paths) {
+		if written[p] {
+			continue
+		}
+		written[p] = true
+
+		if err := writeTarEntry(tarWriter, root, p); err != nil {
+			return err
+		}
+	}
+
+	return tarWriter.Close()
+}
+
+func writeTarEntry(tarWriter *tar.Writer, root string, p string) error {
+	file := filepath.Join(root, filepath.FromSlash(p))
+	info, err := os.Lstat(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = p
+	if info.IsDir() {
+		header.Name = p + "/"
+	}
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	// the tar writer rounds to full seconds, snapshots truncate
+	header.ModTime = info.ModTime().Truncate(time.Second)
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	reader, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	_, err = io.CopyN(tarWriter, reader, header.Size)
+	return err
+}
+
+// extractTar extracts the tar stream r into the root directory, keeping the modification times of the entries.
+func extractTar(r io.Reader, root string) error {
+	tarReader := tar.NewReader(r)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := targetPath(root, header.Name)
+		if err != nil {
+			return err
+		}
+		if err := checkNoSymlinks(root, target); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := extractFile(tarReader, header, target); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		if err := os.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+func extractFile(r io.Reader, header *tar.Header, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	writer, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	_, err = io.Copy(writer, r)
+	return err
+}
+
+func targetPath(root string, name string) (string, error) {
+	name = strings.TrimSuffix(name, "/")
+	target := filepath.Join(root, filepath.FromSlash(name))
+	if !strings.HasPrefix(target, filepath.Clean(root)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry '%s' points outside of '%s'", name, root)
+	}
+	return target, nil
+}
+
+// checkNoSymlinks returns an error if the target or one of its parent directories below root is a symbolic link.
+// Symbolic links are not synchronized, so writing through them could change files outside of root.
+func checkNoSymlinks(root string, target string) error {
+	root = filepath.Clean(root)
+	for p := target; p != root && strings.HasPrefix(p, root+string(os.PathSeparator)); p = filepath.Dir(p) {
+		info, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write '%s' through the symbolic link '%s'", target, p)
+		}
+	}
+	return nil
+}
+
+// withParents returns the sorted paths together with all their parent directories.
+func withParents(paths []string) []string {
+	set := map[string]bool{}
+	for _, p := range paths {
+		for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			set[dir] = true
+		}
+		set[p] = true
+	}
+
+	var result []string
+	for p := range set {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
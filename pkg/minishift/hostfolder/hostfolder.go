@@ -29,15 +29,19 @@ const (
 
 	// CIFS defines the constant to be used for the CIFS host folder type.
 	CIFS
+
+	// SYNC defines the constant to be used for host folders which get synchronized into the VM.
+	SYNC
 )
 
 func (t Type) String() string {
 	names := [...]string{
 		"sshfs",
-		"cifs"}
+		"cifs",
+		"sync"}
 
 	// prevent panicking
-	if t < SSHFS || t > CIFS {
+	if t < SSHFS || t > SYNC {
 		return "unknown"
 	}
 	return names[t]
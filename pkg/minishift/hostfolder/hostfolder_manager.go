@@ -28,18 +28,25 @@ import (
 	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
 )
 
+const (
+	// probeTimeout is the time in seconds an access to a mounted host folder may take before it is considered stale
+	probeTimeout = 5
+)
+
 type MountInfo struct {
 	Name       string
 	Type       string
 	Source     string
 	MountPoint string
 	Mounted    bool
+	Stale      bool
 }
 
 // Manager is the central point for all operations around managing hostfolders.
 type Manager struct {
 	instanceConfig     *minishiftConfig.InstanceConfigType
 	allInstancesConfig *minishiftConfig.GlobalConfigType
+	ocRunner           OcRunner
 }
 
 // NewManager creates a new add-on manager for the specified add-on directory.
@@ -49,6 +56,12 @@ func NewManager(instanceConfig *minishiftConfig.InstanceConfigType, allInstances
 		allInstancesConfig: allInstancesConfig}, nil
 }
 
+// SetOcRunner sets the runner used to create and delete the persistent volumes of host folders which are exposed as
+// persistent volumes. Without a runner, no persistent volumes are managed.
+func (m *Manager) SetOcRunner(ocRunner OcRunner) {
+	m.ocRunner = ocRunner
+}
+
 // ExistAny returns true if at least one host folder configuration exists, false otherwise.
 func (m *Manager) ExistAny() bool {
 	return len(m.instanceConfig.HostFolders) > 0 ||
@@ -60,6 +73,11 @@ func (m *Manager) Exist(name string) bool {
 	return m.getHostFolder(name) != nil
 }
 
+// HostFolder returns the host folder with the specified name, nil if no such host folder is defined.
+func (m *Manager) HostFolder(name string) HostFolder {
+	return m.getHostFolder(name)
+}
+
 // Add adds teh specified host folder to the configuration. Depending on the allInstances flag the configuration is either
 // saved to the instance configuration or the global all instances configuration.
 func (m *Manager) Add(hostFolder HostFolder, allInstances bool) {
@@ -78,6 +96,20 @@ func (m *Manager) Remove(name string) error {
 		return fmt.Errorf("no host folder defined with name '%s'", name)
 	}
 
+	// a synchronized host folder does not need the VM to stop its synchronization
+	if syncHostFolder, ok := m.getHostFolder(name).(*SyncHostFolder); ok {
+		syncHostFolder.Stop()
+	}
+
+	hostFolderConfig := m.getHostFolder(name).Config()
+
+	// the persistent volume is deleted first, so that a failed deletion keeps the host folder to retry the removal
+	if hostFolderConfig.Option(config.PVSize) != "" && m.ocRunner != nil {
+		if err := deletePersistentVolume(m.ocRunner, hostFolderConfig); err != nil {
+			return fmt.Errorf("host folder '%s' not removed: %s", name, err.Error())
+		}
+	}
+
 	m.instanceConfig.HostFolders = m.removeFromHostFolders(name, minishiftConfig.InstanceConfig.HostFolders)
 	m.instanceConfig.Write()
 
@@ -110,11 +142,12 @@ func (m *Manager) List(driver drivers.Driver) ([]MountInfo, error) {
 		switch hostFolder.Type {
 		case CIFS.String():
 			source = hostFolder.Options[config.UncPath]
-		case SSHFS.String():
+		case SSHFS.String(), SYNC.String():
 			source = hostFolder.Options[config.Source]
 		}
 
 		mounted := false
+		stale := false
 		if isRunning {
 			isMounted, err := m.isHostFolderMounted(driver, hostFolder)
 			if err != nil {
@@ -123,6 +156,7 @@ func (m *Manager) List(driver drivers.Driver) ([]MountInfo, error) {
 			if isMounted {
 				mounted = true
 			}
+			stale = m.isHostFolderStale(driver, hostFolder, mounted)
 		}
 
 		mount := MountInfo{
@@ -131,6 +165,7 @@ func (m *Manager) List(driver drivers.Driver) ([]MountInfo, error) {
 			Source:     source,
 			MountPoint: hostFolder.MountPoint(),
 			Mounted:    mounted,
+			Stale:      stale,
 		}
 
 		mounts = append(mounts, mount)
@@ -166,9 +201,42 @@ func (m *Manager) Mount(driver drivers.Driver, name string) error {
 	if err != nil {
 		return err
 	}
+	return m.expose(hostFolder.Config())
+}
+
+// ExposeAll creates the persistent volumes of all mounted host folders which are exposed as persistent volumes.
+func (m *Manager) ExposeAll(driver drivers.Driver) error {
+	hostFolderConfigs := m.allInstancesConfig.HostFolders
+	hostFolderConfigs = append(hostFolderConfigs, m.instanceConfig.HostFolders...)
+	for _, hostFolderConfig := range hostFolderConfigs {
+		if hostFolderConfig.Option(config.PVSize) == "" {
+			continue
+		}
+
+		mounted, err := m.isHostFolderMounted(driver, hostFolderConfig)
+		if err != nil {
+			return err
+		}
+		if !mounted {
+			continue
+		}
+
+		if err := m.expose(hostFolderConfig); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// expose creates the persistent volume of the host folder, if it is exposed as persistent volume and an oc runner
+// is available.
+func (m *Manager) expose(hostFolderConfig config.HostFolderConfig) error {
+	if hostFolderConfig.Option(config.PVSize) == "" || m.ocRunner == nil {
+		return nil
+	}
+	return createPersistentVolume(m.ocRunner, hostFolderConfig)
+}
+
 // MountAll mounts all defined host folders.
 func (m *Manager) MountAll(driver drivers.Driver) error {
 	if !m.isHostRunning(driver) {
@@ -219,6 +287,41 @@ func (m *Manager) Umount(driver drivers.Driver, name string) error {
 	return nil
 }
 
+// Remount re-establishes the host folder specified by name after it went stale, for example after the host was
+// suspended or the sftp daemon exited. A stale mount is lazily unmounted first, since a regular unmount can block on it.
+func (m *Manager) Remount(driver drivers.Driver, name string) error {
+	if !m.isHostRunning(driver) {
+		return errors.New("host is in the wrong state")
+	}
+
+	hostFolder := m.getHostFolder(name)
+	if hostFolder == nil {
+		return fmt.Errorf("no host folder with the name '%s' defined", name)
+	}
+
+	// a synchronized host folder only needs its synchronization process, the copy in the VM is still intact
+	if syncHostFolder, ok := hostFolder.(*SyncHostFolder); ok {
+		return syncHostFolder.Resume()
+	}
+
+	mounted, err := m.isHostFolderMounted(driver, hostFolder.Config())
+	if err != nil {
+		return err
+	}
+	if mounted {
+		hostFolderConfig := hostFolder.Config()
+		cmd := fmt.Sprintf("sudo umount -l %s", hostFolderConfig.MountPoint())
+		if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+			return fmt.Errorf("error unmounting stale host folder '%s': %s", name, err)
+		}
+	}
+
+	if err := m.ensureMountPointExists(driver, hostFolder.Config()); err != nil {
+		return err
+	}
+	return hostFolder.Mount(driver)
+}
+
 func (m *Manager) getHostFolder(name string) HostFolder {
 	config := m.getHostFolderConfig(name, minishiftConfig.InstanceConfig.HostFolders)
 	if config != nil {
@@ -239,6 +342,8 @@ func (m *Manager) hostFolderForConfig(config *config.HostFolderConfig) HostFolde
 		return NewCifsHostFolder(*config)
 	case SSHFS.String():
 		return NewSSHFSHostFolder(*config, m.allInstancesConfig)
+	case SYNC.String():
+		return NewSyncHostFolder(*config)
 	default:
 		return nil
 	}
@@ -283,6 +388,11 @@ func (m *Manager) removeFromHostFolders(name string, hostfolders []config.HostFo
 }
 
 func (m *Manager) isHostFolderMounted(driver drivers.Driver, hostFolderConfig config.HostFolderConfig) (bool, error) {
+	// synchronized host folders are no mounts, they are active as long as their synchronization runs
+	if hostFolderConfig.Type == SYNC.String() {
+		return NewSyncHostFolder(hostFolderConfig).(*SyncHostFolder).IsRunning(), nil
+	}
+
 	cmd := "cat /proc/mounts"
 	procMounts, err := drivers.RunSSHCommandFromDriver(driver, cmd)
 	if err != nil {
@@ -296,3 +406,29 @@ func (m *Manager) isHostFolderMounted(driver drivers.Driver, hostFolderConfig co
 
 	return true, nil
 }
+
+// isHostFolderStale returns true if the host folder is supposed to be available in the VM, but is not usable.
+// A mount is stale if its mount point cannot be accessed, which is probed with a timeout since accessing a dead
+// network mount can block. An SSHFS mount is also stale if the sftp daemon on the host is gone. A synchronized host
+// folder is stale if its synchronization process died without being unmounted.
+func (m *Manager) isHostFolderStale(driver drivers.Driver, hostFolderConfig config.HostFolderConfig, mounted bool) bool {
+	switch hostFolderConfig.Type {
+	case SYNC.String():
+		return NewSyncHostFolder(hostFolderConfig).(*SyncHostFolder).IsStale()
+	case SSHFS.String():
+		if mounted && !NewSSHFSHostFolder(hostFolderConfig, m.allInstancesConfig).(*SSHFSHostFolder).isRunning() {
+			return true
+		}
+	}
+
+	if !mounted {
+		return false
+	}
+
+	cmd := fmt.Sprintf("timeout %d stat -t %s > /dev/null", probeTimeout, hostFolderConfig.MountPoint())
+	if _, err := drivers.RunSSHCommandFromDriver(driver, cmd); err != nil {
+		glog.Warningf("Host folder '%s' is stale: %s", hostFolderConfig.Name, err)
+		return true
+	}
+	return false
+}
@@ -23,4 +23,6 @@ import (
 
 func Test_type_string(t *testing.T) {
 	assert.Equal(t, CIFS.String(), "cifs", "unexpected string representation of host folder type")
+	assert.Equal(t, SYNC.String(), "sync", "unexpected string representation of host folder type")
+	assert.Equal(t, Type(42).String(), "unknown", "unexpected string representation of host folder type")
 }
@@ -0,0 +1,216 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"io"
+	goos "os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/util/os"
+	"github.com/minishift/minishift/pkg/util/os/process"
+)
+
+const (
+	// MonitorInterval is the interval in which the monitor checks the host folders
+	MonitorInterval = 30 * time.Second
+
+	minRemountBackoff = 10 * time.Second
+	maxRemountBackoff = 5 * time.Minute
+	monitorLogFile    = "monitor.log"
+)
+
+// mountManager is the part of the Manager the Monitor depends on.
+type mountManager interface {
+	List(driver drivers.Driver) ([]MountInfo, error)
+	Remount(driver drivers.Driver, name string) error
+}
+
+// Monitor detects stale host folders and remounts them. Failed remounts are retried with an exponential backoff.
+type Monitor struct {
+	manager mountManager
+	driver  drivers.Driver
+	out     io.Writer
+	now     func() time.Time
+	backoff map[string]*remountBackoff
+}
+
+type remountBackoff struct {
+	delay time.Duration
+	next  time.Time
+}
+
+// NewMonitor creates a Monitor for the host folders of the specified manager, logging every event to out.
+func NewMonitor(manager *Manager, driver drivers.Driver, out io.Writer) *Monitor {
+	return newMonitor(manager, driver, out, time.Now)
+}
+
+func newMonitor(manager mountManager, driver drivers.Driver, out io.Writer, now func() time.Time) *Monitor {
+	return &Monitor{
+		manager: manager,
+		driver:  driver,
+		out:     out,
+		now:     now,
+		backoff: make(map[string]*remountBackoff),
+	}
+}
+
+// Run checks the host folders every interval for as long as the VM is running.
+func (m *Monitor) Run(interval time.Duration) {
+	m.log("Monitoring host folders")
+	for drivers.MachineInState(m.driver, state.Running)() {
+		m.Check()
+		time.Sleep(interval)
+	}
+	m.log("VM is not running, stopping to monitor host folders")
+}
+
+// Check remounts all stale host folders which are not waiting for a retry. A host folder which is healthy again
+// starts over with the minimal backoff.
+func (m *Monitor) Check() {
+	mountInfos, err := m.manager.List(m.driver)
+	if err != nil {
+		m.log("ERROR Unable to check host folders: %s", err)
+		return
+	}
+
+	for _, mountInfo := range mountInfos {
+		if !mountInfo.Stale {
+			if _, ok := m.backoff[mountInfo.Name]; ok {
+				m.log("Host folder '%s' is healthy again", mountInfo.Name)
+				delete(m.backoff, mountInfo.Name)
+			}
+			continue
+		}
+
+		backoff, ok := m.backoff[mountInfo.Name]
+		if !ok {
+			m.log("Host folder '%s' is stale", mountInfo.Name)
+			backoff = &remountBackoff{delay: minRemountBackoff}
+			m.backoff[mountInfo.Name] = backoff
+		}
+		if m.now().Before(backoff.next) {
+			continue
+		}
+
+		// every attempt is followed by the backoff, in case a successful remount goes stale right away
+		err := m.manager.Remount(m.driver, mountInfo.Name)
+		backoff.next = m.now().Add(backoff.delay)
+		if err != nil {
+			m.log("ERROR Remounting host folder '%s' failed, retrying in %s: %s", mountInfo.Name, backoff.delay, err)
+		} else {
+			m.log("Remounted host folder '%s'", mountInfo.Name)
+		}
+
+		backoff.delay *= 2
+		if backoff.delay > maxRemountBackoff {
+			backoff.delay = maxRemountBackoff
+		}
+	}
+}
+
+func (m *Monitor) log(format string, args ...interface{}) {
+	logSync(m.out, format, args...)
+}
+
+// MonitorLogFile returns the path of the log file of the host folder monitor of the current instance.
+func MonitorLogFile() string {
+	return filepath.Join(constants.Minipath, "hostfolders", monitorLogFile)
+}
+
+// EnsureMonitorRunning starts the host folder monitor daemon for the current instance, unless it is running already.
+func EnsureMonitorRunning() error {
+	if pid := MonitorPID(); pid > 0 {
+		if glog.V(2) {
+			fmt.Println(fmt.Sprintf("host folder monitor running with pid %d", pid))
+		}
+		return nil
+	}
+
+	monitorCmd, err := createMonitorCommand()
+	if err != nil {
+		return err
+	}
+
+	err = monitorCmd.Start()
+	if err != nil {
+		return err
+	}
+
+	minishiftConfig.InstanceStateConfig.HostFolderMonitorPID = monitorCmd.Process.Pid
+	return minishiftConfig.InstanceStateConfig.Write()
+}
+
+// MonitorPID returns the PID of the host folder monitor daemon of the current instance, 0 if it is not running.
+func MonitorPID() int {
+	pid := minishiftConfig.InstanceStateConfig.HostFolderMonitorPID
+	if isProcessRunning(pid) {
+		return pid
+	}
+	return 0
+}
+
+func createMonitorCommand() (*exec.Cmd, error) {
+	cmd, err := os.CurrentExecutable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"daemon",
+		"hostfolder-monitor",
+		"--profile",
+		constants.ProfileName}
+	monitorCmd := exec.Command(cmd, args...)
+	// don't inherit any file handles
+	monitorCmd.Stderr = nil
+	monitorCmd.Stdin = nil
+	monitorCmd.Stdout = nil
+	monitorCmd.SysProcAttr = process.SysProcForBackgroundProcess()
+	monitorCmd.Env = process.EnvForBackgroundProcess()
+
+	return monitorCmd, nil
+}
+
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := goos.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// for Windows FindProcess is enough
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	// for non Windows we need to send a signal to get more information
+	return process.Signal(syscall.Signal(0)) == nil
+}
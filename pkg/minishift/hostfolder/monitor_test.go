@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMountManager struct {
+	stale         map[string]bool
+	remountErrors map[string]error
+	remounts      []string
+}
+
+func (f *fakeMountManager) List(driver drivers.Driver) ([]MountInfo, error) {
+	var mountInfos []MountInfo
+	for _, name := range []string{"data", "web"} {
+		mountInfos = append(mountInfos, MountInfo{Name: name, Mounted: true, Stale: f.stale[name]})
+	}
+	return mountInfos, nil
+}
+
+func (f *fakeMountManager) Remount(driver drivers.Driver, name string) error {
+	f.remounts = append(f.remounts, name)
+	return f.remountErrors[name]
+}
+
+func Test_monitor_remounts_stale_host_folders(t *testing.T) {
+	manager := &fakeMountManager{stale: map[string]bool{"web": true}}
+	now := time.Now()
+	out := new(bytes.Buffer)
+	monitor := newMonitor(manager, nil, out, func() time.Time { return now })
+
+	monitor.Check()
+	assert.Equal(t, []string{"web"}, manager.remounts)
+	assert.Contains(t, out.String(), "Host folder 'web' is stale")
+	assert.Contains(t, out.String(), "Remounted host folder 'web'")
+
+	manager.stale["web"] = false
+	monitor.Check()
+	assert.Equal(t, []string{"web"}, manager.remounts)
+	assert.Contains(t, out.String(), "Host folder 'web' is healthy again")
+	assert.NotContains(t, out.String(), "'data'")
+}
+
+func Test_monitor_retries_failed_remounts_with_backoff(t *testing.T) {
+	manager := &fakeMountManager{
+		stale:         map[string]bool{"data": true},
+		remountErrors: map[string]error{"data": errors.New("sftpd unreachable")},
+	}
+	now := time.Now()
+	out := new(bytes.Buffer)
+	monitor := newMonitor(manager, nil, out, func() time.Time { return now })
+
+	monitor.Check()
+	assert.Len(t, manager.remounts, 1)
+	assert.Contains(t, out.String(), "Remounting host folder 'data' failed, retrying in 10s: sftpd unreachable")
+
+	now = now.Add(5 * time.Second)
+	monitor.Check()
+	assert.Len(t, manager.remounts, 1, "remount should wait for the backoff")
+
+	now = now.Add(5 * time.Second)
+	monitor.Check()
+	assert.Len(t, manager.remounts, 2)
+	assert.Contains(t, out.String(), "retrying in 20s")
+
+	for i := 0; i < 10; i++ {
+		now = now.Add(maxRemountBackoff)
+		monitor.Check()
+	}
+	assert.Equal(t, maxRemountBackoff, monitor.backoff["data"].delay)
+
+	manager.remountErrors = nil
+	now = now.Add(maxRemountBackoff)
+	monitor.Check()
+	assert.Contains(t, out.String(), "Remounted host folder 'data'")
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	minishiftStrings "github.com/minishift/minishift/pkg/util/strings"
+)
+
+var (
+	// ValidCacheModes are the supported values of the cache option
+	ValidCacheModes = []string{"none", "strict", "loose"}
+
+	mountOptions = []string{config.UID, config.GID, config.FileMode, config.DirMode, config.ReadOnly, config.CacheMode}
+)
+
+// ValidateMountOptions checks the structured mount options of a host folder of the specified type.
+func ValidateMountOptions(hostFolderType string, options map[string]string) error {
+	if value := options[config.PVSize]; value != "" {
+		if err := ValidatePersistentVolumeSize(value); err != nil {
+			return err
+		}
+	}
+
+	if hostFolderType == SYNC.String() {
+		for _, option := range mountOptions {
+			if isSet(options, option) {
+				return fmt.Errorf("the option '%s' is not supported for %s host folders", option, SYNC)
+			}
+		}
+		return nil
+	}
+
+	for _, option := range []string{config.UID, config.GID} {
+		if value := options[option]; value != "" {
+			if id, err := strconv.Atoi(value); err != nil || id < 0 {
+				return fmt.Errorf("invalid %s '%s', it needs to be a non-negative number", option, value)
+			}
+		}
+	}
+
+	for _, option := range []string{config.FileMode, config.DirMode} {
+		if value := options[option]; value != "" {
+			if _, err := parseMode(value); err != nil {
+				return fmt.Errorf("invalid %s '%s', it needs to be an octal permission like 0775", option, value)
+			}
+		}
+	}
+
+	if value := options[config.ReadOnly]; value != "" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid %s '%s', it needs to be true or false", config.ReadOnly, value)
+		}
+	}
+
+	if value := options[config.CacheMode]; value != "" && !minishiftStrings.Contains(ValidCacheModes, value) {
+		return fmt.Errorf("invalid %s '%s', valid values are %s", config.CacheMode, value, strings.Join(ValidCacheModes, ", "))
+	}
+
+	// FUSE applies a single umask to files and directories
+	if hostFolderType == SSHFS.String() && isSet(options, config.FileMode) && isSet(options, config.DirMode) {
+		fileMode, _ := parseMode(options[config.FileMode])
+		dirMode, _ := parseMode(options[config.DirMode])
+		if fileMode != dirMode {
+			return fmt.Errorf("%s host folders only support the same %s and %s", SSHFS, config.FileMode, config.DirMode)
+		}
+	}
+
+	return nil
+}
+
+// sshfsMountOptions translates the structured options of the host folder into sshfs options.
+func sshfsMountOptions(hostFolder config.HostFolderConfig) []string {
+	var options []string
+	if uid := hostFolder.Option(config.UID); uid != "" {
+		options = append(options, fmt.Sprintf("-o uid=%s", uid))
+	}
+	if gid := hostFolder.Option(config.GID); gid != "" {
+		options = append(options, fmt.Sprintf("-o gid=%s", gid))
+	}
+
+	mode := hostFolder.Option(config.DirMode)
+	if mode == "" {
+		mode = hostFolder.Option(config.FileMode)
+	}
+	if perm, err := parseMode(mode); err == nil {
+		options = append(options, fmt.Sprintf("-o umask=%04o", 0777&^perm))
+	}
+
+	if hostFolder.IsReadOnly() {
+		options = append(options, "-o ro")
+	}
+
+	switch hostFolder.Option(config.CacheMode) {
+	case "none":
+		options = append(options, "-o cache=no")
+	case "strict":
+		options = append(options, "-o cache=yes")
+	case "loose":
+		options = append(options, "-o cache=yes", "-o kernel_cache")
+	}
+
+	return options
+}
+
+// cifsMountOptions translates the structured options of the host folder into mount.cifs options.
+func cifsMountOptions(hostFolder config.HostFolderConfig) []string {
+	var options []string
+	// forcing the ids makes them apply even if the server supports the CIFS Unix extensions
+	if uid := hostFolder.Option(config.UID); uid != "" {
+		options = append(options, fmt.Sprintf("uid=%s", uid), "forceuid")
+	}
+	if gid := hostFolder.Option(config.GID); gid != "" {
+		options = append(options, fmt.Sprintf("gid=%s", gid), "forcegid")
+	}
+	if perm, err := parseMode(hostFolder.Option(config.FileMode)); err == nil {
+		options = append(options, fmt.Sprintf("file_mode=%04o", perm))
+	}
+	if perm, err := parseMode(hostFolder.Option(config.DirMode)); err == nil {
+		options = append(options, fmt.Sprintf("dir_mode=%04o", perm))
+	}
+	if hostFolder.IsReadOnly() {
+		options = append(options, "ro")
+	}
+	if cache := hostFolder.Option(config.CacheMode); cache != "" {
+		options = append(options, fmt.Sprintf("cache=%s", cache))
+	}
+	return options
+}
+
+func parseMode(mode string) (uint64, error) {
+	if len(mode) < 3 || len(mode) > 4 {
+		return 0, fmt.Errorf("invalid mode '%s'", mode)
+	}
+	return strconv.ParseUint(mode, 8, 32)
+}
+
+func isSet(options map[string]string, option string) bool {
+	value := options[option]
+	return value != "" && !(option == config.ReadOnly && value == "false")
+}
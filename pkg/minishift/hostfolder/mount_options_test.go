@@ -0,0 +1,98 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_valid_mount_options(t *testing.T) {
+	options := map[string]string{
+		config.UID:       "1000",
+		config.GID:       "0",
+		config.FileMode:  "0666",
+		config.DirMode:   "777",
+		config.ReadOnly:  "true",
+		config.CacheMode: "loose",
+	}
+	assert.NoError(t, ValidateMountOptions(CIFS.String(), options))
+	assert.NoError(t, ValidateMountOptions(SSHFS.String(), map[string]string{config.FileMode: "0777", config.DirMode: "777"}))
+	assert.NoError(t, ValidateMountOptions(SYNC.String(), map[string]string{config.ReadOnly: "false"}))
+}
+
+func Test_invalid_mount_options(t *testing.T) {
+	var testCases = []struct {
+		hostFolderType string
+		options        map[string]string
+		expectedError  string
+	}{
+		{CIFS.String(), map[string]string{config.UID: "-1"}, "invalid uid '-1', it needs to be a non-negative number"},
+		{CIFS.String(), map[string]string{config.GID: "wheel"}, "invalid gid 'wheel', it needs to be a non-negative number"},
+		{SSHFS.String(), map[string]string{config.FileMode: "0686"}, "invalid file-mode '0686', it needs to be an octal permission like 0775"},
+		{SSHFS.String(), map[string]string{config.DirMode: "7"}, "invalid dir-mode '7', it needs to be an octal permission like 0775"},
+		{SSHFS.String(), map[string]string{config.ReadOnly: "maybe"}, "invalid read-only 'maybe', it needs to be true or false"},
+		{CIFS.String(), map[string]string{config.CacheMode: "always"}, "invalid cache 'always', valid values are none, strict, loose"},
+		{SSHFS.String(), map[string]string{config.PVSize: "lots"}, "invalid persistent volume size 'lots', it needs to be a quantity like 10Gi"},
+		{SSHFS.String(), map[string]string{config.FileMode: "0666", config.DirMode: "0777"}, "sshfs host folders only support the same file-mode and dir-mode"},
+		{SYNC.String(), map[string]string{config.UID: "1000"}, "the option 'uid' is not supported for sync host folders"},
+	}
+
+	for _, testCase := range testCases {
+		err := ValidateMountOptions(testCase.hostFolderType, testCase.options)
+		if assert.Error(t, err) {
+			assert.Equal(t, testCase.expectedError, err.Error())
+		}
+	}
+}
+
+func Test_sshfs_mount_options(t *testing.T) {
+	hostFolder := config.HostFolderConfig{
+		Name: "data",
+		Type: SSHFS.String(),
+		Options: map[string]string{
+			config.UID:       "1000",
+			config.GID:       "1001",
+			config.FileMode:  "0775",
+			config.ReadOnly:  "true",
+			config.CacheMode: "loose",
+		},
+	}
+
+	assert.Equal(t, []string{"-o uid=1000", "-o gid=1001", "-o umask=0002", "-o ro", "-o cache=yes", "-o kernel_cache"}, sshfsMountOptions(hostFolder))
+	assert.Empty(t, sshfsMountOptions(config.HostFolderConfig{Options: map[string]string{}}))
+}
+
+func Test_cifs_mount_options(t *testing.T) {
+	hostFolder := config.HostFolderConfig{
+		Name: "data",
+		Type: CIFS.String(),
+		Options: map[string]string{
+			config.UID:       "1000",
+			config.GID:       "0",
+			config.FileMode:  "666",
+			config.DirMode:   "0777",
+			config.ReadOnly:  "false",
+			config.CacheMode: "none",
+		},
+	}
+
+	assert.Equal(t, []string{"uid=1000", "forceuid", "gid=0", "forcegid", "file_mode=0666", "dir_mode=0777", "cache=none"}, cifsMountOptions(hostFolder))
+	assert.Empty(t, cifsMountOptions(config.HostFolderConfig{Options: map[string]string{}}))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+)
+
+const (
+	// PersistentVolumeLabel is the label identifying the host folder of a persistent volume
+	PersistentVolumeLabel = "minishift.io/hostfolder"
+
+	// ocRequestTimeout limits the time an oc command waits for the cluster
+	ocRequestTimeout = "30s"
+)
+
+var (
+	quantityRegexp   = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(Ki|Mi|Gi|Ti|Pi|Ei|k|M|G|T|P|E)?$`)
+	nonDNSCharRegexp = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// OcRunner runs oc commands against the cluster, for example *oc.OcRunner using the cached system:admin kube config.
+type OcRunner interface {
+	Run(command string, stdOut io.Writer, stdErr io.Writer) int
+}
+
+// ValidatePersistentVolumeSize checks that size is a Kubernetes storage quantity like 10Gi.
+func ValidatePersistentVolumeSize(size string) error {
+	if !quantityRegexp.MatchString(size) {
+		return fmt.Errorf("invalid persistent volume size '%s', it needs to be a quantity like 10Gi", size)
+	}
+	return nil
+}
+
+// PersistentVolumeName returns the name of the persistent volume of the host folder with the specified name.
+func PersistentVolumeName(name string) string {
+	return "hostfolder-" + labelValue(name)
+}
+
+// createPersistentVolume creates or updates the hostPath persistent volume pointing to the mount point of the
+// host folder.
+func createPersistentVolume(runner OcRunner, hostFolder config.HostFolderConfig) error {
+	manifest, err := persistentVolumeManifest(hostFolder)
+	if err != nil {
+		return err
+	}
+
+	manifestFile, err := ioutil.TempFile("", "minishift-hostfolder-pv-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(manifestFile.Name())
+	if _, err := manifestFile.Write(manifest); err != nil {
+		manifestFile.Close()
+		return err
+	}
+	manifestFile.Close()
+
+	cmd := fmt.Sprintf("apply --request-timeout=%s -f %s", ocRequestTimeout, manifestFile.Name())
+	return runOc(runner, cmd, fmt.Sprintf("creating persistent volume '%s'", PersistentVolumeName(hostFolder.Name)))
+}
+
+// deletePersistentVolume deletes the persistent volume of the host folder, if it exists.
+func deletePersistentVolume(runner OcRunner, hostFolder config.HostFolderConfig) error {
+	cmd := fmt.Sprintf("delete pv %s --ignore-not-found --request-timeout=%s", PersistentVolumeName(hostFolder.Name), ocRequestTimeout)
+	return runOc(runner, cmd, fmt.Sprintf("deleting persistent volume '%s'", PersistentVolumeName(hostFolder.Name)))
+}
+
+func runOc(runner OcRunner, cmd string, action string) error {
+	errorBuffer := new(bytes.Buffer)
+	if exitCode := runner.Run(cmd, nil, errorBuffer); exitCode != 0 {
+		return fmt.Errorf("error %s: %s", action, strings.TrimSpace(errorBuffer.String()))
+	}
+	return nil
+}
+
+func persistentVolumeManifest(hostFolder config.HostFolderConfig) ([]byte, error) {
+	accessMode := "ReadWriteMany"
+	if hostFolder.IsReadOnly() {
+		accessMode = "ReadOnlyMany"
+	}
+
+	persistentVolume := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolume",
+		"metadata": map[string]interface{}{
+			"name": PersistentVolumeName(hostFolder.Name),
+			"labels": map[string]string{
+				PersistentVolumeLabel: labelValue(hostFolder.Name),
+			},
+		},
+		"spec": map[string]interface{}{
+			"capacity": map[string]string{
+				"storage": hostFolder.Option(config.PVSize),
+			},
+			"accessModes":                   []string{accessMode},
+			"persistentVolumeReclaimPolicy": "Retain",
+			"hostPath": map[string]string{
+				"path": hostFolder.MountPoint(),
+			},
+		},
+	}
+	return json.MarshalIndent(persistentVolume, "", "  ")
+}
+
+// labelValue turns a host folder name into a value usable as label value and as part of a resource name. Names which
+// need to be changed for that get a hash of the name appended, so that names differing only in case or punctuation,
+// like My_Data and my-data, do not share a persistent volume.
+func labelValue(name string) string {
+	value := strings.Trim(nonDNSCharRegexp.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(value) > 52 {
+		value = strings.TrimRight(value[:52], "-")
+	}
+	if value == name {
+		return value
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:8]
+	if value == "" {
+		return hash
+	}
+	return value + "-" + hash
+}
@@ -0,0 +1,155 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingOcRunner struct {
+	commands  []string
+	manifests []string
+	exitCode  int
+}
+
+func (r *recordingOcRunner) Run(command string, stdOut io.Writer, stdErr io.Writer) int {
+	r.commands = append(r.commands, command)
+	if args := strings.Fields(command); args[0] == "apply" {
+		manifest, _ := ioutil.ReadFile(args[len(args)-1])
+		r.manifests = append(r.manifests, string(manifest))
+	}
+	if r.exitCode != 0 {
+		fmt.Fprint(stdErr, "connection refused\n")
+	}
+	return r.exitCode
+}
+
+func Test_persistent_volume_name(t *testing.T) {
+	assert.Equal(t, "hostfolder-myshare", PersistentVolumeName("myshare"))
+	assert.Equal(t, "hostfolder-my-share-1511b2d9", PersistentVolumeName("My_Share."))
+	assert.Equal(t, "hostfolder-my-share", PersistentVolumeName("my-share"))
+	assert.Equal(t, "hostfolder-e84c538e", PersistentVolumeName("!!!"))
+}
+
+func Test_persistent_volume_size_validation(t *testing.T) {
+	for _, size := range []string{"1Gi", "500Mi", "1.5Ti", "10G", "1024"} {
+		assert.NoError(t, ValidatePersistentVolumeSize(size))
+	}
+
+	err := ValidatePersistentVolumeSize("10GB")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid persistent volume size '10GB', it needs to be a quantity like 10Gi", err.Error())
+	}
+}
+
+func Test_create_persistent_volume(t *testing.T) {
+	runner := &recordingOcRunner{}
+	hostFolder := config.HostFolderConfig{
+		Name: "data",
+		Type: SSHFS.String(),
+		Options: map[string]string{
+			config.MountPoint: "/mnt/sda1/data",
+			config.PVSize:     "5Gi",
+			config.ReadOnly:   "true",
+		},
+	}
+
+	assert.NoError(t, createPersistentVolume(runner, hostFolder))
+	assert.Len(t, runner.manifests, 1)
+
+	var persistentVolume struct {
+		Kind     string
+		Metadata struct {
+			Name   string
+			Labels map[string]string
+		}
+		Spec struct {
+			Capacity                      map[string]string
+			AccessModes                   []string
+			PersistentVolumeReclaimPolicy string
+			HostPath                      map[string]string
+		}
+	}
+	assert.NoError(t, json.Unmarshal([]byte(runner.manifests[0]), &persistentVolume))
+	assert.Equal(t, "PersistentVolume", persistentVolume.Kind)
+	assert.Equal(t, "hostfolder-data", persistentVolume.Metadata.Name)
+	assert.Equal(t, map[string]string{PersistentVolumeLabel: "data"}, persistentVolume.Metadata.Labels)
+	assert.Equal(t, "5Gi", persistentVolume.Spec.Capacity["storage"])
+	assert.Equal(t, []string{"ReadOnlyMany"}, persistentVolume.Spec.AccessModes)
+	assert.Equal(t, "Retain", persistentVolume.Spec.PersistentVolumeReclaimPolicy)
+	assert.Equal(t, "/mnt/sda1/data", persistentVolume.Spec.HostPath["path"])
+}
+
+func Test_delete_persistent_volume(t *testing.T) {
+	runner := &recordingOcRunner{}
+	hostFolder := config.HostFolderConfig{Name: "data", Type: CIFS.String()}
+
+	assert.NoError(t, deletePersistentVolume(runner, hostFolder))
+	assert.Equal(t, []string{"delete pv hostfolder-data --ignore-not-found --request-timeout=30s"}, runner.commands)
+
+	runner.exitCode = 1
+	err := deletePersistentVolume(runner, hostFolder)
+	if assert.Error(t, err) {
+		assert.Equal(t, "error deleting persistent volume 'hostfolder-data': connection refused", err.Error())
+	}
+}
+
+func Test_failed_persistent_volume_deletion_keeps_host_folder(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-hostfolder-pv-")
+	assert.NoError(t, err, "Error creating temp directory")
+	defer os.RemoveAll(testDir)
+
+	originalInstanceConfig, originalAllInstancesConfig := minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig
+	defer func() {
+		minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig = originalInstanceConfig, originalAllInstancesConfig
+	}()
+	minishiftConfig.InstanceConfig, err = minishiftConfig.NewInstanceConfig(filepath.Join(testDir, "instance.json"))
+	assert.NoError(t, err, "Error creating instance config")
+	minishiftConfig.AllInstancesConfig, err = minishiftConfig.NewAllInstancesConfig(filepath.Join(testDir, "allinstances.json"))
+	assert.NoError(t, err, "Error creating all instances config")
+
+	manager, err := NewManager(minishiftConfig.InstanceConfig, minishiftConfig.AllInstancesConfig)
+	assert.NoError(t, err, "Error creating host folder manager")
+	manager.Add(NewCifsHostFolder(config.HostFolderConfig{
+		Name:    "data",
+		Type:    CIFS.String(),
+		Options: map[string]string{config.PVSize: "5Gi"},
+	}), false)
+
+	runner := &recordingOcRunner{exitCode: 1}
+	manager.SetOcRunner(runner)
+	err = manager.Remove("data")
+	if assert.Error(t, err) {
+		assert.Equal(t, "host folder 'data' not removed: error deleting persistent volume 'hostfolder-data': connection refused", err.Error())
+	}
+	assert.True(t, manager.Exist("data"))
+
+	runner.exitCode = 0
+	assert.NoError(t, manager.Remove("data"))
+	assert.False(t, manager.Exist("data"))
+}
@@ -0,0 +1,386 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sftpfs provides the virtual file system served by the sftp daemon for sshfs host folders. The file system
+// only contains the configured host folders, each available under its name, eg /<name>/some/file.
+package sftpfs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/pkg/sftp"
+)
+
+// SFTP open flags and attribute flags, see https://tools.ietf.org/html/draft-ietf-secsh-filexfer-02
+const (
+	openRead   = 0x00000001
+	openWrite  = 0x00000002
+	openCreate = 0x00000008
+	openTrunc  = 0x00000010
+	openExcl   = 0x00000020
+
+	attrSize        = 0x00000001
+	attrUidGid      = 0x00000002
+	attrPermissions = 0x00000004
+	attrAcModTime   = 0x00000008
+)
+
+// FileSystem is a virtual file system exposing the configured host folders by name.
+type FileSystem struct {
+	folders map[string]*folder
+	created time.Time
+}
+
+type folder struct {
+	name     string
+	source   string
+	readOnly bool
+}
+
+// NewFileSystem creates a file system containing the specified host folders. The source option of each host folder
+// is the directory on the host which is served.
+func NewFileSystem(hostFolders []config.HostFolderConfig) *FileSystem {
+	fs := &FileSystem{folders: make(map[string]*folder), created: time.Now()}
+	for _, hostFolder := range hostFolders {
+		source := hostFolder.Option(config.Source)
+		if hostFolder.Name == "" || source == "" || strings.ContainsAny(hostFolder.Name, "/\\") {
+			glog.Warningf("Skipping host folder '%s' with source '%s'", hostFolder.Name, source)
+			continue
+		}
+		fs.folders[hostFolder.Name] = &folder{name: hostFolder.Name, source: filepath.Clean(source), readOnly: hostFolder.IsReadOnly()}
+	}
+	return fs
+}
+
+// Handlers returns the handlers for a sftp.RequestServer serving the file system.
+func (fs *FileSystem) Handlers() sftp.Handlers {
+	return sftp.Handlers{FileGet: fs, FilePut: fs, FileCmd: fs, FileList: fs}
+}
+
+// Fileread opens the requested file for reading.
+func (fs *FileSystem) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	hostPath, err := fs.resolve(r, r.Filepath, false)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(hostPath)
+}
+
+// Filewrite opens the requested file for writing, creating it if requested.
+func (fs *FileSystem) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	hostPath, err := fs.resolve(r, r.Filepath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := os.O_WRONLY
+	if r.Flags&openRead != 0 && r.Flags&openWrite != 0 {
+		flags = os.O_RDWR
+	}
+	if r.Flags&openCreate != 0 {
+		flags |= os.O_CREATE
+	}
+	if r.Flags&openTrunc != 0 {
+		flags |= os.O_TRUNC
+	}
+	if r.Flags&openExcl != 0 {
+		flags |= os.O_EXCL
+	}
+	return os.OpenFile(hostPath, flags, 0666)
+}
+
+// Filecmd runs the requested command changing the file system.
+func (fs *FileSystem) Filecmd(r *sftp.Request) error {
+	if r.Method == "Symlink" {
+		return fs.reject(r, r.Filepath, "symbolic links cannot be created in host folders")
+	}
+
+	hostPath, err := fs.resolve(r, r.Filepath, true)
+	if err != nil {
+		return err
+	}
+
+	switch r.Method {
+	case "Setstat":
+		return setStat(hostPath, r.Flags, r.Attrs)
+	case "Rename":
+		target, err := fs.resolve(r, r.Target, true)
+		if err != nil {
+			return err
+		}
+		return os.Rename(hostPath, target)
+	case "Rmdir", "Remove":
+		return os.Remove(hostPath)
+	case "Mkdir":
+		return os.Mkdir(hostPath, 0777)
+	}
+	return fmt.Errorf("unsupported method %s", r.Method)
+}
+
+// Filelist lists directories and stats files. Symbolic links within a host folder are followed, so that the VM only
+// sees regular files and directories.
+func (fs *FileSystem) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		if r.Filepath == "/" {
+			return fs.listFolders(), nil
+		}
+		hostPath, err := fs.resolve(r, r.Filepath, false)
+		if err != nil {
+			return nil, err
+		}
+		return fs.listDir(r, hostPath)
+	case "Stat":
+		if r.Filepath == "/" {
+			return listerAt{&dirInfo{name: "/", modTime: fs.created}}, nil
+		}
+		hostPath, err := fs.resolve(r, r.Filepath, false)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(hostPath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	case "Readlink":
+		return nil, &os.PathError{Op: "readlink", Path: r.Filepath, Err: syscall.EINVAL}
+	}
+	return nil, fmt.Errorf("unsupported method %s", r.Method)
+}
+
+// resolve maps the virtual path to the path on the host. An error is returned if the path is not within a host
+// folder, including paths leaving a host folder via symbolic links, or if a write is requested for a read-only folder.
+func (fs *FileSystem) resolve(r *sftp.Request, virtualPath string, write bool) (string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(path.Clean("/"+virtualPath), "/"), "/", 2)
+	folder, found := fs.folders[parts[0]]
+	if !found {
+		fs.logRejection(r, virtualPath, "not within a host folder")
+		return "", &os.PathError{Op: strings.ToLower(r.Method), Path: virtualPath, Err: syscall.ENOENT}
+	}
+	if write && folder.readOnly {
+		return "", fs.reject(r, virtualPath, fmt.Sprintf("host folder '%s' is read-only", folder.name))
+	}
+	if write && len(parts) == 1 && r.Method != "Setstat" {
+		return "", fs.reject(r, virtualPath, "the root of a host folder cannot be changed")
+	}
+
+	hostPath := folder.source
+	if len(parts) == 2 {
+		hostPath = filepath.Join(folder.source, filepath.FromSlash(parts[1]))
+	}
+	if !isWithin(folder.source, hostPath) {
+		return "", fs.reject(r, virtualPath, "the path leaves the host folder via a symbolic link")
+	}
+	return hostPath, nil
+}
+
+func (fs *FileSystem) reject(r *sftp.Request, virtualPath string, reason string) error {
+	fs.logRejection(r, virtualPath, reason)
+	return &os.PathError{Op: strings.ToLower(r.Method), Path: virtualPath, Err: syscall.EPERM}
+}
+
+func (fs *FileSystem) logRejection(r *sftp.Request, virtualPath string, reason string) {
+	glog.Warningf("Rejected %s of '%s': %s", r.Method, virtualPath, reason)
+}
+
+func (fs *FileSystem) listFolders() listerAt {
+	var names []string
+	for name := range fs.folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var infos listerAt
+	for _, name := range names {
+		if info, err := os.Stat(fs.folders[name].source); err == nil && info.IsDir() {
+			infos = append(infos, &dirInfo{name: name, modTime: info.ModTime()})
+		}
+	}
+	return infos
+}
+
+func (fs *FileSystem) listDir(r *sftp.Request, hostPath string) (listerAt, error) {
+	entries, err := ioutil.ReadDir(hostPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos listerAt
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink == 0 {
+			infos = append(infos, entry)
+			continue
+		}
+
+		// links leaving the host folder and dangling links are not listed
+		virtualPath := path.Join(r.Filepath, entry.Name())
+		target, err := fs.resolve(r, virtualPath, false)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(target)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, &namedInfo{FileInfo: info, name: entry.Name()})
+	}
+	return infos, nil
+}
+
+// isWithin returns true if hostPath is source or within source once all symbolic links are resolved. For paths which
+// do not exist yet, the links of the existing parent directories are resolved.
+func isWithin(source string, hostPath string) bool {
+	resolvedSource, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return false
+	}
+
+	resolved, err := evalExistingSymlinks(hostPath)
+	if err != nil {
+		return false
+	}
+	return resolved == resolvedSource || strings.HasPrefix(resolved, resolvedSource+string(os.PathSeparator))
+}
+
+func evalExistingSymlinks(hostPath string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(hostPath)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent := filepath.Dir(hostPath)
+	if parent == hostPath {
+		return "", err
+	}
+	resolvedParent, err := evalExistingSymlinks(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(hostPath)), nil
+}
+
+// setStat applies the SFTP file attributes to the specified file. Ownership changes are ignored.
+func setStat(hostPath string, flags uint32, attrs []byte) error {
+	// fsetstat requests on open files carry no attributes
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	next := func(size int) (uint64, error) {
+		if len(attrs) < size {
+			return 0, fmt.Errorf("invalid attributes")
+		}
+		var value uint64
+		if size == 8 {
+			value = binary.BigEndian.Uint64(attrs)
+		} else {
+			value = uint64(binary.BigEndian.Uint32(attrs))
+		}
+		attrs = attrs[size:]
+		return value, nil
+	}
+
+	if flags&attrSize != 0 {
+		size, err := next(8)
+		if err != nil {
+			return err
+		}
+		if err := os.Truncate(hostPath, int64(size)); err != nil {
+			return err
+		}
+	}
+	if flags&attrUidGid != 0 {
+		if _, err := next(4); err != nil {
+			return err
+		}
+		if _, err := next(4); err != nil {
+			return err
+		}
+	}
+	if flags&attrPermissions != 0 {
+		mode, err := next(4)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(hostPath, os.FileMode(mode&0777)); err != nil {
+			return err
+		}
+	}
+	if flags&attrAcModTime != 0 {
+		atime, err := next(4)
+		if err != nil {
+			return err
+		}
+		mtime, err := next(4)
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(hostPath, time.Unix(int64(atime), 0), time.Unix(int64(mtime), 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listerAt lists a fixed set of file infos
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(infos []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(infos, l[offset:])
+	if n < len(infos) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// dirInfo describes the virtual directories of the file system, ie its root and the host folders
+type dirInfo struct {
+	name    string
+	modTime time.Time
+}
+
+func (d *dirInfo) Name() string       { return d.name }
+func (d *dirInfo) Size() int64        { return 0 }
+func (d *dirInfo) Mode() os.FileMode  { return os.ModeDir | 0755 }
+func (d *dirInfo) ModTime() time.Time { return d.modTime }
+func (d *dirInfo) IsDir() bool        { return true }
+func (d *dirInfo) Sys() interface{}   { return nil }
+
+// namedInfo is the info of the target of a symbolic link under the name of the link
+type namedInfo struct {
+	os.FileInfo
+	name string
+}
+
+func (n *namedInfo) Name() string { return n.name }
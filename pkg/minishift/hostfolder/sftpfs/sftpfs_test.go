@@ -0,0 +1,217 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sftpfs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_only_host_folders_are_served(t *testing.T) {
+	testDir, client := setUp(t)
+	defer os.RemoveAll(testDir)
+	defer client.Close()
+
+	entries, err := client.ReadDir("/")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"data", "docs"}, names(entries))
+
+	content, err := readFile(client, "/data/hello.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", content)
+
+	for _, path := range []string{"/other/secret.txt", "/data/../other/secret.txt", "/../other/secret.txt", filepath.ToSlash(filepath.Join(testDir, "other", "secret.txt"))} {
+		_, err = readFile(client, path)
+		assert.Error(t, err, "Reading '%s' should fail", path)
+	}
+	_, err = client.Stat("/other")
+	assert.Error(t, err)
+}
+
+func Test_files_can_be_changed_in_writable_folder(t *testing.T) {
+	testDir, client := setUp(t)
+	defer os.RemoveAll(testDir)
+	defer client.Close()
+
+	file, err := client.Create("/data/new.txt")
+	assert.NoError(t, err)
+	_, err = file.Write([]byte("new content"))
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assertFileContent(t, filepath.Join(testDir, "data", "new.txt"), "new content")
+
+	assert.NoError(t, client.Mkdir("/data/sub"))
+	assert.NoError(t, client.Rename("/data/new.txt", "/data/sub/renamed.txt"))
+	assertFileContent(t, filepath.Join(testDir, "data", "sub", "renamed.txt"), "new content")
+
+	assert.NoError(t, client.Truncate("/data/sub/renamed.txt", 3))
+	assertFileContent(t, filepath.Join(testDir, "data", "sub", "renamed.txt"), "new")
+
+	if runtime.GOOS != "windows" {
+		assert.NoError(t, client.Chmod("/data/sub/renamed.txt", 0600))
+		info, err := os.Stat(filepath.Join(testDir, "data", "sub", "renamed.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	assert.NoError(t, client.Remove("/data/sub/renamed.txt"))
+	assert.NoError(t, client.Remove("/data/sub"))
+	_, err = os.Stat(filepath.Join(testDir, "data", "sub"))
+	assert.True(t, os.IsNotExist(err))
+
+	// the host folders themselves cannot be changed
+	assert.Error(t, client.Remove("/data"))
+	assert.Error(t, client.Mkdir("/new"))
+	assert.Error(t, client.Rename("/data/hello.txt", "/other.txt"))
+}
+
+func Test_read_only_folder_cannot_be_changed(t *testing.T) {
+	testDir, client := setUp(t)
+	defer os.RemoveAll(testDir)
+	defer client.Close()
+
+	content, err := readFile(client, "/docs/readme.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "read me", content)
+
+	_, err = client.Create("/docs/new.txt")
+	assertPermissionDenied(t, err)
+	_, err = client.OpenFile("/docs/readme.txt", os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	assertPermissionDenied(t, err)
+	assertPermissionDenied(t, client.Remove("/docs/readme.txt"))
+	assertPermissionDenied(t, client.Mkdir("/docs/sub"))
+	assertPermissionDenied(t, client.Rename("/docs/readme.txt", "/docs/renamed.txt"))
+	assertPermissionDenied(t, client.Rename("/data/hello.txt", "/docs/hello.txt"))
+	assertPermissionDenied(t, client.Truncate("/docs/readme.txt", 0))
+
+	assertFileContent(t, filepath.Join(testDir, "docs", "readme.txt"), "read me")
+	_, err = os.Stat(filepath.Join(testDir, "docs", "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_symbolic_links_cannot_leave_host_folder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Creating symbolic links requires privileges on Windows")
+	}
+	testDir, client := setUp(t)
+	defer os.RemoveAll(testDir)
+	defer client.Close()
+
+	assert.NoError(t, os.Symlink(filepath.Join(testDir, "other"), filepath.Join(testDir, "data", "escape")))
+	assert.NoError(t, os.Symlink(filepath.Join(testDir, "data", "hello.txt"), filepath.Join(testDir, "data", "link.txt")))
+
+	_, err := readFile(client, "/data/escape/secret.txt")
+	assertPermissionDenied(t, err)
+	_, err = client.Create("/data/escape/new.txt")
+	assertPermissionDenied(t, err)
+	_, err = os.Stat(filepath.Join(testDir, "other", "new.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	content, err := readFile(client, "/data/link.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", content)
+
+	entries, err := client.ReadDir("/data")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello.txt", "link.txt"}, names(entries))
+	for _, entry := range entries {
+		assert.True(t, entry.Mode().IsRegular(), "Expected regular file for %s", entry.Name())
+	}
+
+	assertPermissionDenied(t, client.Symlink("/data/hello.txt", "/data/new-link.txt"))
+}
+
+// setUp creates the host folders data and docs, the latter read-only, next to the directory other which is not
+// shared, and returns a client connected to a server serving the host folders
+func setUp(t *testing.T) (string, *sftp.Client) {
+	testDir, err := ioutil.TempDir("", "minishift-test-sftpfs-")
+	assert.NoError(t, err)
+	// resolve links of the temp dir, eg /tmp on macOS
+	testDir, err = filepath.EvalSymlinks(testDir)
+	assert.NoError(t, err)
+
+	files := map[string]string{
+		filepath.Join("data", "hello.txt"):   "hello",
+		filepath.Join("docs", "readme.txt"):  "read me",
+		filepath.Join("other", "secret.txt"): "secret",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(testDir, filepath.Dir(name)), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(testDir, name), []byte(content), 0644))
+	}
+
+	fs := NewFileSystem([]config.HostFolderConfig{
+		{Name: "data", Type: "sshfs", Options: map[string]string{config.Source: filepath.Join(testDir, "data")}},
+		{Name: "docs", Type: "sshfs", Options: map[string]string{config.Source: filepath.Join(testDir, "docs"), config.ReadOnly: "true"}},
+	})
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter}, fs.Handlers())
+	go func() {
+		// closing the connection once the client is closed terminates the client
+		server.Serve()
+		server.Close()
+	}()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	assert.NoError(t, err)
+	return testDir, client
+}
+
+func readFile(client *sftp.Client, path string) (string, error) {
+	file, err := client.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	return string(content), err
+}
+
+func assertFileContent(t *testing.T, path string, expected string) {
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func assertPermissionDenied(t *testing.T, err error) {
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "SSH_FX_PERMISSION_DENIED")
+	}
+}
+
+func names(infos []os.FileInfo) []string {
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -74,13 +74,15 @@ func (h *SSHFSHostFolder) Mount(driver drivers.Driver) error {
 
 	// Mount command seems to fail occasionally. Give it a couple of attempts
 	mount := func() (err error) {
+		// The sftp daemon serves each host folder under its name, not under its source path
+		extraOptions := strings.TrimSpace(strings.Join(append(sshfsMountOptions(h.config), h.config.Option(config.ExtraOptions)), " "))
 		cmd := fmt.Sprintf(
-			"sudo sshfs docker@%s:%s %s -o IdentityFile=%s -o 'StrictHostKeyChecking=no' -o reconnect -o allow_other -o idmap=none %s -p %d",
+			"sudo sshfs docker@%s:/%s %s -o IdentityFile=%s -o 'StrictHostKeyChecking=no' -o reconnect -o allow_other -o idmap=none %s -p %d",
 			ip,
-			h.config.Option(config.Source),
+			h.config.Name,
 			h.config.MountPoint(),
 			keyFile,
-			h.config.Option(config.ExtraOptions),
+			extraOptions,
 			SftpPort)
 
 		if glog.V(2) {
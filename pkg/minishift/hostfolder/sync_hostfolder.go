@@ -0,0 +1,262 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hostfolder
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	goos "os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/golang/glog"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	"github.com/minishift/minishift/pkg/minikube/sshutil"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/config"
+	"github.com/minishift/minishift/pkg/minishift/hostfolder/filesync"
+	"github.com/minishift/minishift/pkg/util/os"
+	"github.com/minishift/minishift/pkg/util/os/process"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	syncStateFile = "state.json"
+	syncPidFile   = "sync.pid"
+	syncLogFile   = "sync.log"
+
+	// syncInterval is the interval in which changes in the VM are picked up
+	syncInterval = 2 * time.Second
+
+	// maxSyncFailures is the number of consecutive failed synchronizations after which the sync daemon gives up,
+	// for example because the VM got stopped
+	maxSyncFailures = 30
+)
+
+// SyncHostFolder is a host folder which gets copied into the VM and afterwards is kept in sync in both directions
+// by a background process. Unlike SSHFS and CIFS, the files in the VM are local files.
+type SyncHostFolder struct {
+	config config.HostFolderConfig
+}
+
+func NewSyncHostFolder(config config.HostFolderConfig) HostFolder {
+	return &SyncHostFolder{config: config}
+}
+
+func (h *SyncHostFolder) Config() config.HostFolderConfig {
+	return h.config
+}
+
+// Mount copies the host folder into the VM and starts the background process keeping both sides in sync.
+func (h *SyncHostFolder) Mount(driver drivers.Driver) error {
+	connection := &sshConnection{driver: driver}
+	defer connection.close()
+
+	// the initial synchronization always starts from scratch with the host folder taking precedence
+	if err := goos.RemoveAll(h.stateDir()); err != nil {
+		return err
+	}
+	result, err := h.syncer(connection.run).Sync(true)
+	if err != nil {
+		return fmt.Errorf("error during initial synchronization of host folder '%s': %s", h.config.Name, err)
+	}
+	if glog.V(2) {
+		fmt.Println(fmt.Sprintf("Initial synchronization of host folder '%s': %s", h.config.Name, result))
+	}
+
+	return h.Resume()
+}
+
+// Resume starts the background process keeping both sides in sync, continuing from the last synchronization.
+func (h *SyncHostFolder) Resume() error {
+	syncCmd, err := createSyncCommand(h.config.Name)
+	if err != nil {
+		return err
+	}
+	if err := syncCmd.Start(); err != nil {
+		return err
+	}
+
+	if err := goos.MkdirAll(h.stateDir(), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.pidFile(), []byte(strconv.Itoa(syncCmd.Process.Pid)), 0644)
+}
+
+// Umount stops the synchronization. The files in the VM are kept.
+func (h *SyncHostFolder) Umount(driver drivers.Driver) error {
+	return h.Stop()
+}
+
+// Stop stops the background synchronization process, if it is running.
+func (h *SyncHostFolder) Stop() error {
+	if pid := h.pid(); pid > 0 {
+		if proc, err := goos.FindProcess(pid); err == nil {
+			proc.Kill()
+		}
+	}
+	return goos.RemoveAll(h.stateDir())
+}
+
+// IsRunning returns true if the background synchronization process is running.
+func (h *SyncHostFolder) IsRunning() bool {
+	return isProcessRunning(h.pid())
+}
+
+// IsStale returns true if the background synchronization process died without the host folder being unmounted.
+func (h *SyncHostFolder) IsStale() bool {
+	return h.pid() > 0 && !h.IsRunning()
+}
+
+// Watch keeps the host folder and the VM in sync until the synchronization failed maxSyncFailures times in a row.
+// Every synchronization which changed anything, found conflicts or failed is logged to the sync log of the host folder.
+func (h *SyncHostFolder) Watch(driver drivers.Driver) error {
+	if err := goos.MkdirAll(filepath.Dir(h.logFile()), 0755); err != nil {
+		return err
+	}
+	logFile, err := goos.OpenFile(h.logFile(), goos.O_CREATE|goos.O_APPEND|goos.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	connection := &sshConnection{driver: driver}
+	defer connection.close()
+
+	stop := make(chan struct{})
+	failures := 0
+	// conflicts are reported on every synchronization until they are resolved, but only logged once
+	conflicts := map[string]bool{}
+	report := func(result *filesync.Result, err error) {
+		if err != nil {
+			failures++
+			logSync(logFile, "ERROR %s", err)
+			if failures == maxSyncFailures {
+				logSync(logFile, "Giving up after %d failed synchronizations", failures)
+				close(stop)
+			}
+			return
+		}
+
+		failures = 0
+		if result.Changed() {
+			logSync(logFile, "Synchronized: %s", result)
+		}
+		current := map[string]bool{}
+		for _, path := range result.Conflicts {
+			current[path] = true
+			if !conflicts[path] {
+				logSync(logFile, "CONFLICT '%s' was changed on the host and in the VM, neither side got overwritten. Delete one side to keep the other", path)
+			}
+		}
+		conflicts = current
+	}
+
+	h.syncer(connection.run).Watch(syncInterval, stop, report)
+	return fmt.Errorf("synchronization of host folder '%s' stopped", h.config.Name)
+}
+
+func (h *SyncHostFolder) syncer(run filesync.Runner) *filesync.Syncer {
+	remote := filesync.NewShellRemote(h.config.MountPoint(), run)
+	ignore := filesync.ParseIgnore(h.config.Option(config.Ignore))
+	return filesync.NewSyncer(h.config.Option(config.Source), remote, ignore, filepath.Join(h.stateDir(), syncStateFile))
+}
+
+func (h *SyncHostFolder) pid() int {
+	content, err := ioutil.ReadFile(h.pidFile())
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+func (h *SyncHostFolder) stateDir() string {
+	return filepath.Join(constants.Minipath, "hostfolders", h.config.Name)
+}
+
+func (h *SyncHostFolder) pidFile() string {
+	return filepath.Join(h.stateDir(), syncPidFile)
+}
+
+// logFile is kept outside of the state directory, so that it survives unmounting the host folder.
+func (h *SyncHostFolder) logFile() string {
+	return filepath.Join(constants.Minipath, "hostfolders", fmt.Sprintf("%s-%s", h.config.Name, syncLogFile))
+}
+
+func logSync(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintln(w, fmt.Sprintf("%s %s", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...)))
+}
+
+// sshConnection runs commands in the VM, connecting again after a failure, for example after a restart of the VM.
+type sshConnection struct {
+	driver drivers.Driver
+	client *ssh.Client
+}
+
+func (c *sshConnection) run(cmd string, stdin io.Reader, stdout io.Writer) error {
+	if c.client == nil {
+		client, err := sshutil.NewSSHClient(c.driver)
+		if err != nil {
+			return err
+		}
+		c.client = client
+	}
+
+	err := filesync.SSHRunner(c.client)(cmd, stdin, stdout)
+	if err != nil {
+		c.close()
+	}
+	return err
+}
+
+func (c *sshConnection) close() {
+	if c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+func createSyncCommand(name string) (*exec.Cmd, error) {
+	cmd, err := os.CurrentExecutable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"daemon",
+		"hostfolder-sync",
+		name,
+		"--profile",
+		constants.ProfileName}
+	syncCmd := exec.Command(cmd, args...)
+	// don't inherit any file handles
+	syncCmd.Stderr = nil
+	syncCmd.Stdin = nil
+	syncCmd.Stdout = nil
+	syncCmd.SysProcAttr = process.SysProcForBackgroundProcess()
+	syncCmd.Env = process.EnvForBackgroundProcess()
+
+	return syncCmd, nil
+}
@@ -84,6 +84,9 @@ func Start(driver drivers.Driver) (bool, error) {
 
 	routingSuffix := configCmd.GetDefaultRoutingSuffix(ipAddress)
 	handleConfiguration(sshCommander, ipAddress, routingSuffix)
+	if err := writeRecords(sshCommander, minishiftConfig.InstanceConfig); err != nil {
+		return false, err
+	}
 
 	getServiceCommander(driver).Start()
 
@@ -78,7 +78,7 @@ func handleConfiguration(sshCommander provision.SSHCommander, ipAddress string,
 		"echo %s | openssl enc -base64 -d | sudo tee /var/lib/minishift/dnsmasq.conf > /dev/null",
 		encodedDnsmasqConfigurationFile)
 
-	execCommand := fmt.Sprintf("sudo mkdir %s && %s && sudo cp /etc/resolv.conf %s",
+	execCommand := fmt.Sprintf("sudo mkdir -p %s && %s && sudo cp /etc/resolv.conf %s",
 		additionalHostsPath,
 		configCommand,
 		resolveFilename)
@@ -0,0 +1,124 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/machine/libmachine/provision"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+)
+
+const (
+	// recordsFilename is the name of the file within the dnsmasq additional hosts directory holding the records
+	recordsFilename = "minishift.hosts"
+)
+
+var labelRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateRecord checks that name is a valid host name and ip a valid IP address.
+func ValidateRecord(name string, ip string) error {
+	normalized := normalizeName(name)
+	if normalized == "" || len(normalized) > 253 {
+		return fmt.Errorf("'%s' is not a valid host name", name)
+	}
+	for _, label := range strings.Split(normalized, ".") {
+		if !labelRegexp.MatchString(label) {
+			return fmt.Errorf("'%s' is not a valid host name", name)
+		}
+	}
+
+	if net.ParseIP(ip) == nil {
+		return fmt.Errorf("'%s' is not a valid IP address", ip)
+	}
+	return nil
+}
+
+// AddRecord adds a record resolving name to ip to the instance config, replacing an existing record of name.
+func AddRecord(instanceConfig *minishiftConfig.InstanceConfigType, name string, ip string) error {
+	if err := ValidateRecord(name, ip); err != nil {
+		return err
+	}
+
+	if instanceConfig.DnsRecords == nil {
+		instanceConfig.DnsRecords = make(map[string]string)
+	}
+	instanceConfig.DnsRecords[normalizeName(name)] = net.ParseIP(ip).String()
+	return instanceConfig.Write()
+}
+
+// RemoveRecord removes the record of name from the instance config.
+func RemoveRecord(instanceConfig *minishiftConfig.InstanceConfigType, name string) error {
+	name = normalizeName(name)
+	if _, ok := instanceConfig.DnsRecords[name]; !ok {
+		return fmt.Errorf("no DNS record defined for '%s'", name)
+	}
+
+	delete(instanceConfig.DnsRecords, name)
+	return instanceConfig.Write()
+}
+
+// RecordNames returns the sorted names of the records of the instance config.
+func RecordNames(instanceConfig *minishiftConfig.InstanceConfigType) []string {
+	var names []string
+	for name := range instanceConfig.DnsRecords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyRecords writes the records of the instance config to the additional hosts of dnsmasq in the VM and makes a
+// running dnsmasq reload them.
+func ApplyRecords(sshCommander provision.SSHCommander, instanceConfig *minishiftConfig.InstanceConfigType) error {
+	if err := writeRecords(sshCommander, instanceConfig); err != nil {
+		return err
+	}
+
+	// dnsmasq re-reads its additional hosts on SIGHUP, no matter whether it runs as service or container
+	_, err := sshCommander.SSHCommand("sudo pkill -HUP -x dnsmasq || true")
+	return err
+}
+
+func writeRecords(sshCommander provision.SSHCommander, instanceConfig *minishiftConfig.InstanceConfigType) error {
+	encodedHosts := base64.StdEncoding.EncodeToString([]byte(hostsFileContent(instanceConfig)))
+	execCommand := fmt.Sprintf("sudo mkdir -p %s && echo %s | openssl enc -base64 -d -A | sudo tee %s > /dev/null",
+		additionalHostsPath,
+		encodedHosts,
+		path.Join(additionalHostsPath, recordsFilename))
+	if _, err := sshCommander.SSHCommand(execCommand); err != nil {
+		return fmt.Errorf("error writing the DNS records: %s", err)
+	}
+	return nil
+}
+
+// hostsFileContent returns the records of the instance config in hosts file format.
+func hostsFileContent(instanceConfig *minishiftConfig.InstanceConfigType) string {
+	content := &bytes.Buffer{}
+	content.WriteString("# Managed by Minishift, use 'minishift dns add-record' and 'minishift dns remove-record' to change\n")
+	for _, name := range RecordNames(instanceConfig) {
+		fmt.Fprintf(content, "%s\t%s\n", instanceConfig.DnsRecords[name], name)
+	}
+	return content.String()
+}
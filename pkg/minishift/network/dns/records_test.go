@@ -0,0 +1,120 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSSHCommander struct {
+	commands []string
+	err      error
+}
+
+func (c *recordingSSHCommander) SSHCommand(command string) (string, error) {
+	c.commands = append(c.commands, command)
+	return "", c.err
+}
+
+func Test_validate_record(t *testing.T) {
+	assert.NoError(t, ValidateRecord("git.corp.example.com", "10.1.2.3"))
+	assert.NoError(t, ValidateRecord("Fake-Service", "fd00::1"))
+
+	var testCases = []struct {
+		name          string
+		ip            string
+		expectedError string
+	}{
+		{"", "10.1.2.3", "'' is not a valid host name"},
+		{"under_score.example.com", "10.1.2.3", "'under_score.example.com' is not a valid host name"},
+		{"-dash.example.com", "10.1.2.3", "'-dash.example.com' is not a valid host name"},
+		{"git.example.com", "10.1.2", "'10.1.2' is not a valid IP address"},
+	}
+	for _, testCase := range testCases {
+		err := ValidateRecord(testCase.name, testCase.ip)
+		if assert.Error(t, err) {
+			assert.Equal(t, testCase.expectedError, err.Error())
+		}
+	}
+}
+
+func Test_add_and_remove_records(t *testing.T) {
+	instanceConfig, cleanup := tmpInstanceConfig(t)
+	defer cleanup()
+
+	assert.NoError(t, AddRecord(instanceConfig, "Git.Corp.Example.com.", "10.1.2.3"))
+	assert.NoError(t, AddRecord(instanceConfig, "db.fake", "10.1.2.4"))
+	assert.NoError(t, AddRecord(instanceConfig, "db.fake", "10.1.2.5"))
+	assert.Error(t, AddRecord(instanceConfig, "db.fake", "invalid"))
+	assert.Equal(t, []string{"db.fake", "git.corp.example.com"}, RecordNames(instanceConfig))
+
+	// the records are persisted
+	persistedConfig, err := minishiftConfig.NewInstanceConfig(instanceConfig.FilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db.fake": "10.1.2.5", "git.corp.example.com": "10.1.2.3"}, persistedConfig.DnsRecords)
+
+	assert.NoError(t, RemoveRecord(instanceConfig, "db.fake"))
+	err = RemoveRecord(instanceConfig, "db.fake")
+	if assert.Error(t, err) {
+		assert.Equal(t, "no DNS record defined for 'db.fake'", err.Error())
+	}
+	assert.Equal(t, []string{"git.corp.example.com"}, RecordNames(instanceConfig))
+}
+
+func Test_apply_records(t *testing.T) {
+	instanceConfig, cleanup := tmpInstanceConfig(t)
+	defer cleanup()
+	assert.NoError(t, AddRecord(instanceConfig, "git.corp.example.com", "10.1.2.3"))
+	assert.NoError(t, AddRecord(instanceConfig, "db.fake", "10.1.2.4"))
+
+	sshCommander := &recordingSSHCommander{}
+	assert.NoError(t, ApplyRecords(sshCommander, instanceConfig))
+	assert.Len(t, sshCommander.commands, 2)
+
+	writeCommand := sshCommander.commands[0]
+	assert.True(t, strings.HasSuffix(writeCommand, "| sudo tee /var/lib/minishift/dnsmasq.hosts/minishift.hosts > /dev/null"))
+	encodedHosts := strings.Fields(writeCommand)[6]
+	hosts, err := base64.StdEncoding.DecodeString(encodedHosts)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(hosts), "\n10.1.2.4\tdb.fake\n10.1.2.3\tgit.corp.example.com\n"))
+
+	assert.Equal(t, "sudo pkill -HUP -x dnsmasq || true", sshCommander.commands[1])
+
+	sshCommander = &recordingSSHCommander{err: errors.New("connection refused")}
+	err = ApplyRecords(sshCommander, instanceConfig)
+	if assert.Error(t, err) {
+		assert.Equal(t, "error writing the DNS records: connection refused", err.Error())
+	}
+}
+
+func tmpInstanceConfig(t *testing.T) (*minishiftConfig.InstanceConfigType, func()) {
+	testDir, err := ioutil.TempDir("", "minishift-test-dns-records-")
+	assert.NoError(t, err)
+
+	instanceConfig, err := minishiftConfig.NewInstanceConfig(filepath.Join(testDir, "minishift.json"))
+	assert.NoError(t, err)
+	return instanceConfig, func() { os.RemoveAll(testDir) }
+}
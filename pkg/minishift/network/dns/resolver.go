@@ -0,0 +1,287 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ResolverTTL is the time to live in seconds of the answers for the routing suffix
+	ResolverTTL = 60
+
+	upstreamTimeout = 5 * time.Second
+	maxMessageSize  = 4096
+	headerLength    = 12
+
+	typeA                  = 1
+	classINET              = 1
+	rcodeOK                = 0
+	rcodeFormErr           = 1
+	rcodeServFail          = 2
+	rcodeNotImpl           = 4
+	flagResponse           = 1 << 15
+	flagAuthority          = 1 << 10
+	flagRecursionDesired   = 1 << 8
+	flagRecursionAvailable = 1 << 7
+	opcodeMask             = 0xf << 11
+)
+
+var errMalformedQuery = errors.New("malformed query")
+
+// Resolver is a DNS server answering queries for names of the routing suffix with the IP of the Minishift VM and
+// forwarding all other queries to an upstream DNS server.
+type Resolver struct {
+	domain   string
+	upstream string
+	log      io.Writer
+
+	mutex sync.RWMutex
+	ip    net.IP
+	conn  net.PacketConn
+}
+
+// NewResolver creates a resolver for the names of domain, which are resolved to ip. All other queries are forwarded
+// to upstream, an address in the form host:port.
+func NewResolver(domain string, ip net.IP, upstream string, log io.Writer) *Resolver {
+	return &Resolver{
+		domain:   normalizeName(domain),
+		upstream: upstream,
+		log:      log,
+		ip:       ip.To4(),
+	}
+}
+
+// SetIP changes the IP the names of the routing suffix are resolved to.
+func (r *Resolver) SetIP(ip net.IP) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.ip = ip.To4()
+}
+
+// ListenAndServe listens on the UDP address addr and answers queries until the resolver is closed.
+func (r *Resolver) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(conn)
+}
+
+// Serve answers the queries received on conn until the resolver is closed.
+func (r *Resolver) Serve(conn net.PacketConn) error {
+	r.mutex.Lock()
+	r.conn = conn
+	r.mutex.Unlock()
+
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if r.isClosed() {
+				return nil
+			}
+			return err
+		}
+
+		query := make([]byte, n)
+		copy(query, buffer[:n])
+		go func() {
+			if response := r.handle(query); response != nil {
+				conn.WriteTo(response, addr)
+			}
+		}()
+	}
+}
+
+// Close stops serving queries.
+func (r *Resolver) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	conn := r.conn
+	r.conn = nil
+	return conn.Close()
+}
+
+func (r *Resolver) isClosed() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.conn == nil
+}
+
+// handle returns the response to query, nil if the query cannot be answered at all.
+func (r *Resolver) handle(query []byte) []byte {
+	name, qtype, qclass, questionEnd, err := parseQuestion(query)
+	if err != nil {
+		// never answer responses or messages too short to carry an ID
+		if len(query) < headerLength || binary.BigEndian.Uint16(query[2:4])&flagResponse != 0 {
+			return nil
+		}
+		return response(query, headerLength, rcodeFormErr, nil)
+	}
+
+	if binary.BigEndian.Uint16(query[2:4])&opcodeMask != 0 {
+		return response(query, questionEnd, rcodeNotImpl, nil)
+	}
+
+	if r.isResolved(name) {
+		r.mutex.RLock()
+		ip := r.ip
+		r.mutex.RUnlock()
+
+		if ip == nil {
+			return response(query, questionEnd, rcodeServFail, nil)
+		}
+		// names of the routing suffix only have an IPv4 address, other types get an empty answer
+		if qtype != typeA || qclass != classINET {
+			return response(query, questionEnd, rcodeOK, nil)
+		}
+		return response(query, questionEnd, rcodeOK, ip)
+	}
+
+	answer, err := r.forward(query)
+	if err != nil {
+		r.logf("Error forwarding query for '%s': %s", name, err)
+		return response(query, questionEnd, rcodeServFail, nil)
+	}
+	return answer
+}
+
+func (r *Resolver) isResolved(name string) bool {
+	return name == r.domain || strings.HasSuffix(name, "."+r.domain)
+}
+
+func (r *Resolver) forward(query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", r.upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(upstreamTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+		// ignore stray datagrams not belonging to the query
+		if n >= headerLength && buffer[0] == query[0] && buffer[1] == query[1] {
+			return buffer[:n], nil
+		}
+	}
+}
+
+func (r *Resolver) logf(format string, args ...interface{}) {
+	if r.log != nil {
+		fmt.Fprintf(r.log, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	}
+}
+
+// parseQuestion returns the lower case name, type and class of the single question of query, as well as the offset
+// of the end of the question.
+func parseQuestion(query []byte) (string, uint16, uint16, int, error) {
+	if len(query) < headerLength {
+		return "", 0, 0, 0, errMalformedQuery
+	}
+	if binary.BigEndian.Uint16(query[2:4])&flagResponse != 0 || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		return "", 0, 0, 0, errMalformedQuery
+	}
+
+	var labels []string
+	offset := headerLength
+	for {
+		if offset >= len(query) {
+			return "", 0, 0, 0, errMalformedQuery
+		}
+		length := int(query[offset])
+		offset++
+		if length == 0 {
+			break
+		}
+		// compression is not used for the question of a query
+		if length > 63 || offset+length > len(query) {
+			return "", 0, 0, 0, errMalformedQuery
+		}
+		labels = append(labels, string(query[offset:offset+length]))
+		offset += length
+	}
+
+	if offset+4 > len(query) {
+		return "", 0, 0, 0, errMalformedQuery
+	}
+	qtype := binary.BigEndian.Uint16(query[offset : offset+2])
+	qclass := binary.BigEndian.Uint16(query[offset+2 : offset+4])
+	return normalizeName(strings.Join(labels, ".")), qtype, qclass, offset + 4, nil
+}
+
+// response creates the response to query, repeating its header and question up to questionEnd. If ip is not nil,
+// it is added as A record answer.
+func response(query []byte, questionEnd int, rcode uint16, ip net.IP) []byte {
+	message := make([]byte, questionEnd, questionEnd+16)
+	copy(message, query[:questionEnd])
+
+	flags := binary.BigEndian.Uint16(query[2:4])
+	flags = flagResponse | flags&opcodeMask | flags&flagRecursionDesired | flagRecursionAvailable | rcode
+	if rcode == rcodeOK {
+		flags |= flagAuthority
+	}
+	binary.BigEndian.PutUint16(message[2:4], flags)
+
+	var questions, answers uint16
+	if questionEnd > headerLength {
+		questions = 1
+	}
+	if ip != nil {
+		answers = 1
+	}
+	binary.BigEndian.PutUint16(message[4:6], questions)
+	binary.BigEndian.PutUint16(message[6:8], answers)
+	binary.BigEndian.PutUint16(message[8:10], 0)
+	binary.BigEndian.PutUint16(message[10:12], 0)
+
+	if ip != nil {
+		answer := make([]byte, 16)
+		// pointer to the name of the question
+		binary.BigEndian.PutUint16(answer[0:2], 0xc000|headerLength)
+		binary.BigEndian.PutUint16(answer[2:4], typeA)
+		binary.BigEndian.PutUint16(answer[4:6], classINET)
+		binary.BigEndian.PutUint32(answer[6:10], ResolverTTL)
+		binary.BigEndian.PutUint16(answer[10:12], 4)
+		copy(answer[12:16], ip.To4())
+		message = append(message, answer...)
+	}
+	return message
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Trim(name, "."))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	goos "os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/state"
+	"github.com/golang/glog"
+	configCmd "github.com/minishift/minishift/cmd/minishift/cmd/config"
+	"github.com/minishift/minishift/pkg/minikube/constants"
+	minishiftConfig "github.com/minishift/minishift/pkg/minishift/config"
+	"github.com/minishift/minishift/pkg/util/os"
+	"github.com/minishift/minishift/pkg/util/os/process"
+)
+
+const (
+	// DefaultResolverPort is the UDP port the host resolver listens on, unless configured otherwise
+	DefaultResolverPort = 5300
+
+	// DefaultResolverUpstream is the upstream DNS server used if none can be determined from the host
+	DefaultResolverUpstream = "8.8.8.8"
+
+	// ResolverRefreshInterval is the interval in which the resolver updates the IP of the VM
+	ResolverRefreshInterval = 30 * time.Second
+
+	resolverLogFile = "dns-resolver.log"
+	resolvConf      = "/etc/resolv.conf"
+)
+
+// ResolverAddress returns the local address the host resolver listens on.
+func ResolverAddress(port int) string {
+	if port == 0 {
+		port = DefaultResolverPort
+	}
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+// ResolverUpstream returns the address of the DNS server the host resolver forwards queries to. It is the configured
+// server, the first name server of the host or DefaultResolverUpstream in this order.
+func ResolverUpstream(configured string) string {
+	if configured == "" {
+		configured = hostNameserver(resolvConf)
+	}
+	if configured == "" {
+		configured = DefaultResolverUpstream
+	}
+	if _, _, err := net.SplitHostPort(configured); err != nil {
+		configured = net.JoinHostPort(configured, "53")
+	}
+	return configured
+}
+
+// hostNameserver returns the first name server of the resolv.conf file at path, which is not a loopback address.
+func hostNameserver(path string) string {
+	file, err := goos.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		// a loopback name server might be the resolver itself
+		if ip := net.ParseIP(fields[1]); ip != nil && !ip.IsLoopback() {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// RunResolver answers DNS queries on addr for the routing suffix of the VM, until the VM is not running anymore.
+func RunResolver(driver drivers.Driver, addr string, upstream string, log io.Writer) error {
+	ip, err := driver.GetIP()
+	if err != nil {
+		return err
+	}
+
+	routingSuffix := configCmd.GetDefaultRoutingSuffix(ip)
+	resolver := NewResolver(routingSuffix, net.ParseIP(ip), upstream, log)
+	resolver.logf("Resolving '*.%s' to %s on %s, forwarding other queries to %s", resolver.domain, ip, addr, upstream)
+
+	go func() {
+		for range time.Tick(ResolverRefreshInterval) {
+			if !drivers.MachineInState(driver, state.Running)() {
+				resolver.logf("VM is not running anymore, stopping")
+				resolver.Close()
+				return
+			}
+			if currentIP, err := driver.GetIP(); err == nil && currentIP != ip {
+				resolver.logf("IP of the VM changed from %s to %s", ip, currentIP)
+				resolver.SetIP(net.ParseIP(currentIP))
+				ip = currentIP
+			}
+		}
+	}()
+
+	return resolver.ListenAndServe(addr)
+}
+
+// ResolverLogFile returns the path of the log file of the host resolver of the current instance.
+func ResolverLogFile() string {
+	return filepath.Join(constants.Minipath, "logs", resolverLogFile)
+}
+
+// EnsureResolverRunning starts the host resolver daemon for the current instance, unless it is running already.
+func EnsureResolverRunning() error {
+	if pid := ResolverPID(); pid > 0 {
+		if glog.V(2) {
+			fmt.Println(fmt.Sprintf("dns resolver running with pid %d", pid))
+		}
+		return nil
+	}
+
+	resolverCmd, err := createResolverCommand()
+	if err != nil {
+		return err
+	}
+
+	err = resolverCmd.Start()
+	if err != nil {
+		return err
+	}
+
+	minishiftConfig.InstanceStateConfig.DnsResolverPID = resolverCmd.Process.Pid
+	return minishiftConfig.InstanceStateConfig.Write()
+}
+
+// ResolverPID returns the PID of the host resolver daemon of the current instance, 0 if it is not running.
+func ResolverPID() int {
+	pid := minishiftConfig.InstanceStateConfig.DnsResolverPID
+	if isProcessRunning(pid) {
+		return pid
+	}
+	return 0
+}
+
+func createResolverCommand() (*exec.Cmd, error) {
+	cmd, err := os.CurrentExecutable()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"daemon",
+		"dns-resolver",
+		"--profile",
+		constants.ProfileName}
+	resolverCmd := exec.Command(cmd, args...)
+	// don't inherit any file handles
+	resolverCmd.Stderr = nil
+	resolverCmd.Stdin = nil
+	resolverCmd.Stdout = nil
+	resolverCmd.SysProcAttr = process.SysProcForBackgroundProcess()
+	resolverCmd.Env = process.EnvForBackgroundProcess()
+
+	return resolverCmd, nil
+}
+
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := goos.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	// for Windows FindProcess is enough
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	// for non Windows we need to send a signal to get more information
+	return process.Signal(syscall.Signal(0)) == nil
+}
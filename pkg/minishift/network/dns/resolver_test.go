@@ -0,0 +1,162 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_resolver_answers_routing_suffix(t *testing.T) {
+	addr, stop := startResolver(t, "192.168.99.100.nip.io", "127.0.0.1:1")
+	defer stop()
+
+	response := query(t, addr, "myapp-myproject.192.168.99.100.NIP.io.", typeA)
+	assert.Equal(t, uint16(rcodeOK), rcode(response))
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(response[6:8]))
+	assert.Equal(t, []byte{192, 168, 99, 100}, response[len(response)-4:])
+	assert.Equal(t, uint32(ResolverTTL), binary.BigEndian.Uint32(response[len(response)-10:len(response)-6]))
+
+	response = query(t, addr, "192.168.99.100.nip.io", typeA)
+	assert.Equal(t, uint16(1), binary.BigEndian.Uint16(response[6:8]))
+
+	// AAAA
+	response = query(t, addr, "myapp.192.168.99.100.nip.io", 28)
+	assert.Equal(t, uint16(rcodeOK), rcode(response))
+	assert.Equal(t, uint16(0), binary.BigEndian.Uint16(response[6:8]))
+}
+
+func Test_resolver_forwards_other_queries(t *testing.T) {
+	upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer upstream.Close()
+	go func() {
+		buffer := make([]byte, maxMessageSize)
+		for {
+			n, addr, err := upstream.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			upstream.WriteTo(response(buffer[:n], n, rcodeOK, net.IPv4(10, 0, 0, 1)), addr)
+		}
+	}()
+
+	addr, stop := startResolver(t, "apps.example.com", upstream.LocalAddr().String())
+	defer stop()
+
+	response := query(t, addr, "www.redhat.com", typeA)
+	assert.Equal(t, uint16(rcodeOK), rcode(response))
+	assert.Equal(t, []byte{10, 0, 0, 1}, response[len(response)-4:])
+
+	// a name merely ending with the suffix is not part of it
+	response = query(t, addr, "myapps.example.com", typeA)
+	assert.Equal(t, []byte{10, 0, 0, 1}, response[len(response)-4:])
+}
+
+func Test_resolver_fails_without_upstream(t *testing.T) {
+	upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	upstreamAddr := upstream.LocalAddr().String()
+	upstream.Close()
+
+	addr, stop := startResolver(t, "apps.example.com", upstreamAddr)
+	defer stop()
+
+	response := query(t, addr, "www.redhat.com", typeA)
+	assert.Equal(t, uint16(rcodeServFail), rcode(response))
+}
+
+func Test_resolver_rejects_malformed_queries(t *testing.T) {
+	resolver := NewResolver("apps.example.com", net.IPv4(10, 0, 0, 2), "127.0.0.1:1", nil)
+
+	assert.Nil(t, resolver.handle([]byte{0, 1, 2}))
+
+	malformed := []byte{0, 1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 5, 'a'}
+	response := resolver.handle(malformed)
+	assert.Equal(t, uint16(rcodeFormErr), rcode(response))
+	assert.Equal(t, []byte{0, 1}, response[0:2])
+}
+
+func Test_host_nameserver(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-dns-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	resolvConf := filepath.Join(testDir, "resolv.conf")
+	content := "# generated\nsearch example.com\nnameserver 127.0.0.53\nnameserver 10.0.0.53\nnameserver 10.0.0.54\n"
+	assert.NoError(t, ioutil.WriteFile(resolvConf, []byte(content), 0644))
+
+	assert.Equal(t, "10.0.0.53", hostNameserver(resolvConf))
+	assert.Equal(t, "", hostNameserver(filepath.Join(testDir, "missing")))
+}
+
+func Test_resolver_upstream(t *testing.T) {
+	assert.Equal(t, "10.0.0.53:53", ResolverUpstream("10.0.0.53"))
+	assert.Equal(t, "10.0.0.53:5353", ResolverUpstream("10.0.0.53:5353"))
+	assert.Equal(t, "127.0.0.1:5300", ResolverAddress(0))
+}
+
+func startResolver(t *testing.T, domain string, upstream string) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	resolver := NewResolver(domain, net.ParseIP("192.168.99.100"), upstream, nil)
+	go resolver.Serve(conn)
+	return conn.LocalAddr().String(), func() { resolver.Close() }
+}
+
+func query(t *testing.T, addr string, name string, qtype uint16) []byte {
+	message := []byte{0xbe, 0xef, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split(strings.Trim(name, "."), ".") {
+		message = append(message, byte(len(label)))
+		message = append(message, label...)
+	}
+	message = append(message, 0, byte(qtype>>8), byte(qtype), 0, classINET)
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if _, err := conn.Write(message); err != nil {
+		t.Fatal(err)
+	}
+	buffer := make([]byte, maxMessageSize)
+	n, err := conn.Read(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := buffer[:n]
+	assert.Equal(t, message[0:2], response[0:2])
+	assert.NotZero(t, binary.BigEndian.Uint16(response[2:4])&flagResponse)
+	return response
+}
+
+func rcode(response []byte) uint16 {
+	return binary.BigEndian.Uint16(response[2:4]) & 0xf
+}
@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elazarl/goproxy"
+)
+
+const (
+	deniedMessage = "Access to %s is denied by the Minishift proxy rules.\n"
+)
+
+// requestInfo is kept in the proxy context from the request to the response.
+type requestInfo struct {
+	start  time.Time
+	denied bool
+}
+
+// addAccessControl registers the handlers enforcing rules and logging all requests to accessLog. It needs to be
+// called before any other handlers are registered, so that denied requests never reach the other handlers.
+func addAccessControl(proxy *goproxy.ProxyHttpServer, rules *HostRules, accessLog *AccessLog) {
+	logEntry := func(entry AccessLogEntry) {
+		if accessLog == nil {
+			return
+		}
+		if err := accessLog.Log(entry); err != nil {
+			log.Println(fmt.Sprintf("Error writing access log: %s", err))
+		}
+	}
+
+	proxy.OnRequest().HandleConnectFunc(func(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+		entry := AccessLogEntry{Time: time.Now(), Method: http.MethodConnect, Host: host, Status: http.StatusOK, Bytes: -1}
+		if !rules.Allowed(host) {
+			ctx.Resp = goproxy.NewResponse(ctx.Req, goproxy.ContentTypeText, http.StatusForbidden, fmt.Sprintf(deniedMessage, hostWithoutPort(host)))
+			entry.Status = http.StatusForbidden
+			entry.Denied = true
+			logEntry(entry)
+			return goproxy.RejectConnect, host
+		}
+		logEntry(entry)
+		return nil, host
+	})
+
+	proxy.OnRequest().DoFunc(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+		info := &requestInfo{start: time.Now()}
+		ctx.UserData = info
+		if !rules.Allowed(req.URL.Host) {
+			info.denied = true
+			return req, goproxy.NewResponse(req, goproxy.ContentTypeText, http.StatusForbidden, fmt.Sprintf(deniedMessage, hostWithoutPort(req.URL.Host)))
+		}
+		return req, nil
+	})
+
+	proxy.OnResponse().DoFunc(func(resp *http.Response, ctx *goproxy.ProxyCtx) *http.Response {
+		info, ok := ctx.UserData.(*requestInfo)
+		if !ok {
+			info = &requestInfo{start: time.Now()}
+		}
+		entry := AccessLogEntry{Time: info.start, Method: ctx.Req.Method, Host: ctx.Req.URL.Host, Denied: info.denied}
+
+		if resp == nil {
+			// goproxy answers with an internal server error
+			entry.Status = http.StatusInternalServerError
+			entry.Bytes = -1
+			if ctx.Error != nil {
+				entry.Error = ctx.Error.Error()
+			}
+			entry.Duration = durationMillis(info.start)
+			logEntry(entry)
+			return nil
+		}
+
+		entry.Status = resp.StatusCode
+		if resp.Body == nil || resp.ContentLength >= 0 {
+			if resp.ContentLength > 0 {
+				entry.Bytes = resp.ContentLength
+			}
+			entry.Duration = durationMillis(info.start)
+			logEntry(entry)
+			return resp
+		}
+
+		// the size is only known once the body of the response got copied to the client
+		resp.Body = &countingReadCloser{ReadCloser: resp.Body, onClose: func(n int64) {
+			entry.Bytes = n
+			entry.Duration = durationMillis(info.start)
+			logEntry(entry)
+		}}
+		return resp
+	})
+}
+
+func durationMillis(start time.Time) int64 {
+	return int64(time.Since(start) / time.Millisecond)
+}
+
+// countingReadCloser counts the bytes read and reports them once on close.
+type countingReadCloser struct {
+	io.ReadCloser
+	onClose func(n int64)
+
+	n    int64
+	once sync.Once
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(func() { c.onClose(c.n) })
+	return err
+}
@@ -0,0 +1,202 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAccessLogMaxSize is the size in bytes at which the access log is rotated
+	DefaultAccessLogMaxSize = 10 * 1024 * 1024
+
+	// DefaultAccessLogBackups is the number of rotated access logs which are kept
+	DefaultAccessLogBackups = 3
+
+	accessLogFile = "proxy-access.log"
+)
+
+// AccessLogEntry is a single request handled by the proxy.
+type AccessLogEntry struct {
+	Time     time.Time `json:"time"`
+	Method   string    `json:"method"`
+	Host     string    `json:"host"`
+	Status   int       `json:"status"`
+	Bytes    int64     `json:"bytes"` // -1 if unknown, like for tunneled connections
+	Duration int64     `json:"duration_ms"`
+	Denied   bool      `json:"denied,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// String returns the entry in a human readable format.
+func (e AccessLogEntry) String() string {
+	bytes := "-"
+	if e.Bytes >= 0 {
+		bytes = fmt.Sprintf("%d", e.Bytes)
+	}
+	line := fmt.Sprintf("%s %-7s %s %d %s %dms", e.Time.Format(time.RFC3339), e.Method, e.Host, e.Status, bytes, e.Duration)
+	if e.Denied {
+		line += " denied"
+	}
+	if e.Error != "" {
+		line += fmt.Sprintf(" (%s)", e.Error)
+	}
+	return line
+}
+
+// AccessLog writes access log entries as JSON lines into a file, which gets rotated once it exceeds its maximum size.
+type AccessLog struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+}
+
+// AccessLogFile returns the path of the access log within logDir.
+func AccessLogFile(logDir string) string {
+	return filepath.Join(logDir, accessLogFile)
+}
+
+// NewAccessLog opens the access log at path for appending.
+func NewAccessLog(path string, maxSize int64, maxBackups int) (*AccessLog, error) {
+	accessLog := &AccessLog{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := accessLog.open(); err != nil {
+		return nil, err
+	}
+	return accessLog, nil
+}
+
+// Log appends entry to the access log.
+func (l *AccessLog) Log(entry AccessLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// Close closes the access log.
+func (l *AccessLog) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.file.Close()
+}
+
+func (l *AccessLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
+
+// rotate renames the access log to <path>.1, shifting existing backups and dropping the oldest one.
+func (l *AccessLog) rotate() error {
+	l.file.Close()
+
+	os.Remove(backupPath(l.path, l.maxBackups))
+	for i := l.maxBackups - 1; i >= 1; i-- {
+		os.Rename(backupPath(l.path, i), backupPath(l.path, i+1))
+	}
+	if l.maxBackups > 0 {
+		if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(l.path)
+	}
+	return l.open()
+}
+
+// TailAccessLog returns the last n entries of the access log at path, including its rotated backups if required.
+func TailAccessLog(path string, n int) ([]AccessLogEntry, error) {
+	var entries []AccessLogEntry
+	for i := 0; len(entries) < n; i++ {
+		logPath := path
+		if i > 0 {
+			logPath = backupPath(path, i)
+		}
+
+		fileEntries, err := readAccessLog(logPath)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(fileEntries, entries...)
+	}
+
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries, nil
+}
+
+func readAccessLog(path string) ([]AccessLogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []AccessLogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AccessLogEntry
+		// skip lines truncated by a crash
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elazarl/goproxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_access_log_rotation_and_tail(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-proxy-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := AccessLogFile(testDir)
+	accessLog, err := NewAccessLog(path, 400, 2)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, accessLog.Log(AccessLogEntry{Time: time.Now(), Method: "GET", Host: fmt.Sprintf("host-%02d", i), Status: 200}))
+	}
+	assert.NoError(t, accessLog.Close())
+
+	for _, logPath := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(logPath)
+		if assert.NoError(t, err) {
+			assert.True(t, info.Size() <= 400)
+		}
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := TailAccessLog(path, 5)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+	assert.Equal(t, "host-15", entries[0].Host)
+	assert.Equal(t, "host-19", entries[4].Host)
+
+	// the oldest entries are gone with the dropped backup
+	entries, err = TailAccessLog(path, 100)
+	assert.NoError(t, err)
+	assert.True(t, len(entries) < 20)
+	assert.Equal(t, "host-19", entries[len(entries)-1].Host)
+
+	entries, err = TailAccessLog(filepath.Join(testDir, "missing.log"), 5)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func Test_access_control_logs_and_denies_requests(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-proxy-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer backend.Close()
+
+	accessLog, err := NewAccessLog(AccessLogFile(testDir), DefaultAccessLogMaxSize, DefaultAccessLogBackups)
+	assert.NoError(t, err)
+	defer accessLog.Close()
+
+	goProxy := goproxy.NewProxyHttpServer()
+	addAccessControl(goProxy, NewHostRules(nil, []string{"denied.example.com"}), accessLog)
+	proxyServer := httptest.NewServer(goProxy)
+	defer proxyServer.Close()
+
+	proxyURL, _ := url.Parse(proxyServer.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get(backend.URL + "/path")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello", string(body))
+	}
+
+	resp, err = client.Get("http://denied.example.com/")
+	if assert.NoError(t, err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.True(t, strings.Contains(string(body), "denied.example.com is denied"))
+	}
+
+	entries, err := TailAccessLog(AccessLogFile(testDir), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		backendURL, _ := url.Parse(backend.URL)
+		assert.Equal(t, "GET", entries[0].Method)
+		assert.Equal(t, backendURL.Host, entries[0].Host)
+		assert.Equal(t, http.StatusOK, entries[0].Status)
+		assert.Equal(t, int64(5), entries[0].Bytes)
+		assert.False(t, entries[0].Denied)
+
+		assert.Equal(t, "denied.example.com", entries[1].Host)
+		assert.Equal(t, http.StatusForbidden, entries[1].Status)
+		assert.True(t, entries[1].Denied)
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/minishift/minishift/pkg/minishift/config"
 	minishiftTLS "github.com/minishift/minishift/pkg/minishift/tls"
+	"github.com/minishift/minishift/pkg/util"
 	"github.com/minishift/minishift/pkg/util/os"
 	"github.com/minishift/minishift/pkg/util/os/process"
 )
@@ -42,13 +43,19 @@ const (
 	proxyAuthHeader = "Proxy-Authorization"
 )
 
-func StartProxy(proxyPort int, proxyUpstreamAddr string, reEncrypt bool) {
+// StartProxy starts the proxy server on proxyPort, denying access to hosts not allowed by rules and logging all
+// requests to accessLog, if specified. Requests to hosts matching one of routes are sent via the upstream of the first
+// matching route, all other requests via proxyUpstreamAddr, if specified. Re-encrypted traffic is signed by ca.
+func StartProxy(proxyPort int, proxyUpstreamAddr string, routes []util.ProxyRoute, reEncrypt bool, ca *minishiftTLS.CA, rules *HostRules, accessLog *AccessLog) {
 	// set custom CA
-	setCA(minishiftTLS.CACert, minishiftTLS.CAKey)
+	if err := setCA(ca.Cert, ca.Key); err != nil {
+		log.Fatal(fmt.Sprintf("Error loading the proxy CA: %s", err))
+	}
 
 	proxy := goproxy.NewProxyHttpServer()
 	proxy.Verbose = true
 	proxy.KeepProxyHeaders = true
+	addAccessControl(proxy, rules, accessLog)
 	if reEncrypt {
 		proxy.OnRequest().HandleConnect(goproxy.AlwaysMitm)
 	}
@@ -78,13 +85,21 @@ func StartProxy(proxyPort int, proxyUpstreamAddr string, reEncrypt bool) {
 
 		proxy.ConnectDial = proxy.NewConnectDialToProxyWithHandler(proxyUpstreamAddr, connectReqHandler)
 		proxy.OnRequest().Do(goproxy.FuncReqHandler(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
-			if credentials != "" {
+			// requests matching a route must not carry the credentials of the default upstream
+			if credentials != "" && util.MatchProxyRoute(routes, req.URL.Host) == nil {
 				SetBasicAuth(credentials, req)
 			}
 			return req, nil
 		}))
 	}
 
+	if len(routes) > 0 {
+		addRoutes(proxy, routes)
+		for _, route := range routes {
+			log.Println(fmt.Sprintf("Routing %s", route))
+		}
+	}
+
 	log.Println(fmt.Sprintf("Serving as HTTP proxy on %s%s", bindAddr, chain))
 	log.Fatal(http.ListenAndServe(bindAddr, proxy))
 }
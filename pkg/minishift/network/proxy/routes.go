@@ -0,0 +1,86 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/elazarl/goproxy"
+	"github.com/minishift/minishift/pkg/util"
+)
+
+type dialFunc func(network, addr string) (net.Conn, error)
+
+// addRoutes makes the proxy send requests to hosts matching one of routes via the upstream of the first matching
+// route. Requests to other hosts are handled as before.
+func addRoutes(proxy *goproxy.ProxyHttpServer, routes []util.ProxyRoute) {
+	defaultProxy := proxy.Tr.Proxy
+	defaultDial := dialFunc(proxy.ConnectDial)
+	if defaultDial == nil {
+		defaultDial = net.Dial
+	}
+
+	// keyed by the route itself, as returned by util.MatchProxyRoute, so that each route uses its own upstream
+	dials := make(map[*util.ProxyRoute]dialFunc)
+	for i := range routes {
+		if routes[i].Upstream == nil {
+			continue
+		}
+		dials[&routes[i]] = connectDialToUpstream(proxy, routes[i].Upstream)
+	}
+
+	// plain HTTP requests and the requests of re-encrypted connections
+	proxy.Tr.Proxy = func(req *http.Request) (*url.URL, error) {
+		if route := util.MatchProxyRoute(routes, req.URL.Host); route != nil {
+			// the transport sends the credentials of the URL as proxy authorization
+			return route.Upstream, nil
+		}
+		if defaultProxy == nil {
+			return nil, nil
+		}
+		return defaultProxy(req)
+	}
+
+	// tunneled connections
+	proxy.ConnectDial = func(network, addr string) (net.Conn, error) {
+		route := util.MatchProxyRoute(routes, addr)
+		if route == nil {
+			return defaultDial(network, addr)
+		}
+		if route.Upstream == nil {
+			return net.Dial(network, addr)
+		}
+		return dials[route](network, addr)
+	}
+}
+
+func connectDialToUpstream(proxy *goproxy.ProxyHttpServer, upstream *url.URL) dialFunc {
+	credentials := ""
+	withoutCredentials := *upstream
+	if upstream.User != nil {
+		credentials = upstream.User.String()
+		withoutCredentials.User = nil
+	}
+
+	return proxy.NewConnectDialToProxyWithHandler(withoutCredentials.String(), func(req *http.Request) {
+		if credentials != "" {
+			SetBasicAuth(credentials, req)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/elazarl/goproxy"
+	"github.com/minishift/minishift/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_requests_are_routed_per_host(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("backend"))
+	}))
+	defer backend.Close()
+
+	var upstreamAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth = r.Header.Get(proxyAuthHeader)
+		w.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+
+	backendURL, _ := url.Parse(backend.URL)
+	upstreamURL, _ := url.Parse(upstream.URL)
+	routes, err := util.ParseProxyRoutes([]string{
+		"localhost=http://user:secret@" + upstreamURL.Host,
+		"127.0.0.1=DIRECT",
+	})
+	assert.NoError(t, err)
+
+	goProxy := goproxy.NewProxyHttpServer()
+	addRoutes(goProxy, routes)
+	proxyServer := httptest.NewServer(goProxy)
+	defer proxyServer.Close()
+
+	proxyURL, _ := url.Parse(proxyServer.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	assert.Equal(t, "backend", get(t, client, backend.URL))
+	assert.Equal(t, "", upstreamAuth)
+
+	assert.Equal(t, "upstream", get(t, client, "http://localhost:"+backendURL.Port()))
+	assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), upstreamAuth)
+}
+
+func Test_connections_are_tunneled_per_host(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	connectRequests := make(chan *http.Request, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+		connectRequests <- req
+	}()
+
+	routes, err := util.ParseProxyRoutes([]string{"*.github.com=http://user:secret@" + listener.Addr().String()})
+	assert.NoError(t, err)
+
+	goProxy := goproxy.NewProxyHttpServer()
+	addRoutes(goProxy, routes)
+
+	conn, err := goProxy.ConnectDial("tcp", "api.github.com:443")
+	if assert.NoError(t, err) {
+		conn.Close()
+		req := <-connectRequests
+		assert.Equal(t, "CONNECT", req.Method)
+		assert.Equal(t, "api.github.com:443", req.Host)
+		assert.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")), req.Header.Get(proxyAuthHeader))
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net"
+	"strings"
+
+	"github.com/minishift/minishift/pkg/util"
+)
+
+// HostRules decide which hosts may be accessed via the proxy. A host is denied if it matches any deny pattern. If
+// there are allow patterns, a host also needs to match one of them.
+//
+// A pattern is either a host name, a glob like '*.example.com' or a domain with a leading dot like '.example.com',
+// matching the domain itself as well as all its sub domains.
+type HostRules struct {
+	allow []string
+	deny  []string
+}
+
+// NewHostRules creates the rules for the specified allow and deny patterns.
+func NewHostRules(allow []string, deny []string) *HostRules {
+	return &HostRules{allow: normalizePatterns(allow), deny: normalizePatterns(deny)}
+}
+
+// IsEmpty returns true if the rules allow all hosts.
+func (r *HostRules) IsEmpty() bool {
+	return r == nil || len(r.allow) == 0 && len(r.deny) == 0
+}
+
+// Allowed returns true if host, optionally including a port, may be accessed.
+func (r *HostRules) Allowed(host string) bool {
+	if r.IsEmpty() {
+		return true
+	}
+
+	if matchesAny(r.deny, host) {
+		return false
+	}
+	return len(r.allow) == 0 || matchesAny(r.allow, host)
+}
+
+func matchesAny(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if util.MatchesHostPattern(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePatterns(patterns []string) []string {
+	var normalized []string
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
+
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
@@ -0,0 +1,52 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_empty_rules_allow_all_hosts(t *testing.T) {
+	var rules *HostRules
+	assert.True(t, rules.Allowed("www.example.com"))
+	assert.True(t, NewHostRules(nil, []string{" "}).Allowed("www.example.com:443"))
+}
+
+func Test_host_rules(t *testing.T) {
+	rules := NewHostRules([]string{".example.com", "registry-*.redhat.io", "10.0.0.1"}, []string{"Secret.Example.com", "*.ads.example.com"})
+
+	var testCases = []struct {
+		host    string
+		allowed bool
+	}{
+		{"example.com", true},
+		{"www.example.com:443", true},
+		{"WWW.EXAMPLE.COM", true},
+		{"notexample.com", false},
+		{"secret.example.com", false},
+		{"tracker.ads.example.com:80", false},
+		{"registry-1.redhat.io", true},
+		{"registry.redhat.io", false},
+		{"10.0.0.1:8080", true},
+		{"[::1]:80", false},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.allowed, rules.Allowed(testCase.host), testCase.host)
+	}
+}
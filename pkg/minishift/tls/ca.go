@@ -0,0 +1,155 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minishift/minishift/pkg/minikube/constants"
+)
+
+const (
+	caCertFile = "proxy-ca.pem"
+	caKeyFile  = "proxy-ca-key.pem"
+
+	// goproxy derives the keys of the generated host certificates from the CA key, which needs to be RSA
+	caKeyBits  = 2048
+	caValidity = 10 * 365 * 24 * time.Hour
+)
+
+// CA is a PEM encoded CA certificate with its private key.
+type CA struct {
+	Cert []byte
+	Key  []byte
+}
+
+// CADir returns the directory holding the CA of the local proxy. It is shared by all profiles, like the proxy itself.
+func CADir() string {
+	return filepath.Join(constants.GetMinishiftHomeDir(), "certs")
+}
+
+// CACertPath returns the path of the CA certificate of the local proxy within dir.
+func CACertPath(dir string) string {
+	return filepath.Join(dir, caCertFile)
+}
+
+// ProxyCA returns the CA the local proxy uses to re-encrypt traffic. Unless legacy is set, this is the CA generated
+// for this installation, which gets created on first use.
+func ProxyCA(legacy bool) (*CA, error) {
+	if legacy {
+		return &CA{Cert: LegacyCACert, Key: LegacyCAKey}, nil
+	}
+	return LoadOrCreateCA(CADir())
+}
+
+// LoadOrCreateCA loads the CA stored in dir, generating and storing a new one if there is none.
+func LoadOrCreateCA(dir string) (*CA, error) {
+	cert, certErr := ioutil.ReadFile(CACertPath(dir))
+	key, keyErr := ioutil.ReadFile(filepath.Join(dir, caKeyFile))
+	if certErr == nil && keyErr == nil {
+		return &CA{Cert: cert, Key: key}, nil
+	}
+	if !os.IsNotExist(certErr) && certErr != nil {
+		return nil, certErr
+	}
+	if !os.IsNotExist(keyErr) && keyErr != nil {
+		return nil, keyErr
+	}
+	return RotateCA(dir)
+}
+
+// RotateCA generates a new CA and stores it in dir, replacing an existing one.
+func RotateCA(dir string) (*CA, error) {
+	ca, err := GenerateCA()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// the key is written first, a certificate without key is never used
+	if err := writeFileAtomic(filepath.Join(dir, caKeyFile), ca.Key, 0600); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(CACertPath(dir), ca.Cert, 0644); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// GenerateCA generates a new self-signed CA.
+func GenerateCA() (*CA, error) {
+	key, err := rsa.GenerateKey(rand.Reader, caKeyBits)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	notBefore := time.Now().Add(-time.Hour)
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization:       []string{"Minishift"},
+			OrganizationalUnit: []string{"Local proxy"},
+			CommonName:         fmt.Sprintf("Minishift local proxy CA %s", hostname),
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		Cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, perm); err != nil {
+		return err
+	}
+	// WriteFile does not change the permissions of an existing file
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
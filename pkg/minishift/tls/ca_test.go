@@ -0,0 +1,78 @@
+/*
+Copyright (C) 2018 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_generated_ca_is_usable(t *testing.T) {
+	ca, err := GenerateCA()
+	assert.NoError(t, err)
+
+	keyPair, err := tls.X509KeyPair(ca.Cert, ca.Key)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	assert.NoError(t, err)
+	assert.True(t, cert.IsCA)
+	assert.Equal(t, []string{"Minishift"}, cert.Subject.Organization)
+	assert.NotEqual(t, LegacyCACert, ca.Cert)
+}
+
+func Test_ca_is_created_once(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "minishift-test-ca-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(testDir)
+	caDir := filepath.Join(testDir, "certs")
+
+	ca, err := LoadOrCreateCA(caDir)
+	assert.NoError(t, err)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(filepath.Join(caDir, caKeyFile))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	loadedCA, err := LoadOrCreateCA(caDir)
+	assert.NoError(t, err)
+	assert.Equal(t, ca, loadedCA)
+
+	rotatedCA, err := RotateCA(caDir)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ca.Cert, rotatedCA.Cert)
+
+	loadedCA, err = LoadOrCreateCA(caDir)
+	assert.NoError(t, err)
+	assert.Equal(t, rotatedCA, loadedCA)
+}
+
+func Test_legacy_ca(t *testing.T) {
+	ca, err := ProxyCA(true)
+	assert.NoError(t, err)
+	assert.Equal(t, LegacyCACert, ca.Cert)
+	assert.Equal(t, LegacyCAKey, ca.Key)
+}
@@ -186,7 +186,7 @@ func downloadAndVerifyArchive(url, tmpDir string) (string, error) {
 	}
 
 	// Find checksum of archive
-	archiveChecksum, err := checksumFor(crypto.SHA256, archiveBytes)
+	archiveChecksum, err := util.ChecksumFor(crypto.SHA256, archiveBytes)
 	if err != nil {
 		return "", err
 	}
@@ -235,20 +235,6 @@ func downloadAndVerifyArchive(url, tmpDir string) (string, error) {
 	return downloadedArchivePath, nil
 }
 
-// checksumFor evaluates and returns the checksum for the payload passed to it.
-// It returns an error if given hash function is not linked into the binary.
-// Check "crypto" package for more info on hash function.
-func checksumFor(h crypto.Hash, payload []byte) ([]byte, error) {
-	if !h.Available() {
-		return nil, errors.New("Requested hash function not available")
-	}
-
-	hash := h.New()
-	hash.Write(payload) // Guaranteed not to error
-
-	return hash.Sum([]byte{}), nil
-}
-
 // extractBinary extracts the downloaded archive and returns path to the extracted binary file.
 // It returns an error if extraction fails.
 func extractBinary(downloadedArchivePath, archiveDir string) (string, error) {
@@ -20,9 +20,11 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func Ungzip(source, target string) error {
@@ -73,7 +75,10 @@ func Untar(tarball, targetDir string) error {
 		}
 
 		// the target location where the dir/file should be created
-		path := filepath.Join(targetDir, header.Name)
+		path, err := targetPath(targetDir, header.Name)
+		if err != nil {
+			return err
+		}
 
 		// check the file type
 		switch header.Typeflag {
@@ -96,10 +101,11 @@ func Untar(tarball, targetDir string) error {
 			if err != nil {
 				return err
 			}
-			defer file.Close()
 
-			// copy over contents
-			if _, err := io.Copy(file, tarReader); err != nil {
+			// copy over contents, closing the file right away since archives can contain a lot of files
+			_, err = io.Copy(file, tarReader)
+			file.Close()
+			if err != nil {
 				return err
 			}
 		}
@@ -117,7 +123,10 @@ func Unzip(archive, target string) error {
 	}
 
 	for _, file := range reader.File {
-		path := filepath.Join(target, file.Name)
+		path, err := targetPath(target, file.Name)
+		if err != nil {
+			return err
+		}
 		if file.FileInfo().IsDir() {
 			os.MkdirAll(path, file.Mode())
 			continue
@@ -142,3 +151,81 @@ func Unzip(archive, target string) error {
 
 	return nil
 }
+
+// targetPath returns the path an archive entry gets extracted to, making sure that the entry does not escape targetDir
+func targetPath(targetDir, name string) (string, error) {
+	path := filepath.Join(targetDir, name)
+	if path != filepath.Clean(targetDir) && !strings.HasPrefix(path, filepath.Clean(targetDir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("Archive entry '%s' points outside of the target directory", name)
+	}
+	return path, nil
+}
+
+// Entry is a file to add to an archive. Name is the path of the file within the archive, Path the file on disk.
+type Entry struct {
+	Name string
+	Path string
+}
+
+// TarGz creates the gzip compressed tar archive target containing the specified entries.
+func TarGz(target string, entries []Entry) error {
+	writer, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	gzipWriter := gzip.NewWriter(writer)
+	if err := writeTar(gzipWriter, entries); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// Tar creates the uncompressed tar archive target containing the specified entries.
+func Tar(target string, entries []Entry) error {
+	writer, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	if err := writeTar(writer, entries); err != nil {
+		return err
+	}
+	return writer.Sync()
+}
+
+func writeTar(writer io.Writer, entries []Entry) error {
+	tarWriter := tar.NewWriter(writer)
+	for _, entry := range entries {
+		if err := addToTar(tarWriter, entry); err != nil {
+			return err
+		}
+	}
+	return tarWriter.Close()
+}
+
+func addToTar(tarWriter *tar.Writer, entry Entry) error {
+	file, err := os.Open(entry.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(entry.Name)
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, file)
+	return err
+}
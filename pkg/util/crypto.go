@@ -18,6 +18,7 @@ package util
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -140,3 +141,17 @@ func DecryptText(cryptoText string) (string, error) {
 
 	return fmt.Sprintf("%s", ciphertext), nil
 }
+
+// ChecksumFor evaluates and returns the checksum for the payload passed to it.
+// It returns an error if given hash function is not linked into the binary.
+// Check "crypto" package for more info on hash function.
+func ChecksumFor(h crypto.Hash, payload []byte) ([]byte, error) {
+	if !h.Available() {
+		return nil, errors.New("Requested hash function not available")
+	}
+
+	hash := h.New()
+	hash.Write(payload) // Guaranteed not to error
+
+	return hash.Sum([]byte{}), nil
+}